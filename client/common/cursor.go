@@ -0,0 +1,114 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package clientcommon
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"time"
+)
+
+const (
+	forwardFlag  = 'f'
+	backwardFlag = 'b'
+	cursorLen    = 17
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor is the position of a keyset pagination, sent to client as an opaque string.
+// A backward cursor ask for the page before the position, a forward one for the page after.
+type Cursor struct {
+	CreatedAt time.Time
+	Id        uint64
+	Backward  bool
+	// when true, the cursor is the starting point (no position)
+	First bool
+}
+
+func (c Cursor) Encode() string {
+	buffer := make([]byte, cursorLen)
+	buffer[0] = forwardFlag
+	if c.Backward {
+		buffer[0] = backwardFlag
+	}
+	var nano int64
+	if !c.CreatedAt.IsZero() {
+		nano = c.CreatedAt.UnixNano()
+	}
+	binary.BigEndian.PutUint64(buffer[1:], uint64(nano))
+	binary.BigEndian.PutUint64(buffer[9:], c.Id)
+	return base64.RawURLEncoding.EncodeToString(buffer)
+}
+
+// an empty string is decoded as the first page
+func DecodeCursor(encoded string) (Cursor, error) {
+	if encoded == "" {
+		return Cursor{First: true}, nil
+	}
+
+	buffer, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(buffer) != cursorLen {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var backward bool
+	switch buffer[0] {
+	case forwardFlag:
+	case backwardFlag:
+		backward = true
+	default:
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var createdAt time.Time
+	if nano := int64(binary.BigEndian.Uint64(buffer[1:])); nano != 0 {
+		createdAt = time.Unix(0, nano)
+	}
+	return Cursor{CreatedAt: createdAt, Id: binary.BigEndian.Uint64(buffer[9:]), Backward: backward}, nil
+}
+
+// BuildPage expects items queried with a limit of size + 1 (in reverse order for a backward cursor),
+// it returns the page in display order with the encoded cursors to the next and previous pages (empty when there is none).
+func BuildPage[T any](items []T, cursor Cursor, size uint64, positionOf func(T) Cursor) ([]T, string, string) {
+	hasMore := uint64(len(items)) > size
+	if hasMore {
+		items = items[:size]
+	}
+	if cursor.Backward {
+		slices.Reverse(items)
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	if hasMore || cursor.Backward {
+		nextCursor := positionOf(items[len(items)-1])
+		next = nextCursor.Encode()
+	}
+	if (hasMore && cursor.Backward) || !(cursor.First || cursor.Backward) {
+		prevCursor := positionOf(items[0])
+		prevCursor.Backward = true
+		prev = prevCursor.Encode()
+	}
+	return items, next, prev
+}
@@ -0,0 +1,118 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package clientcommon
+
+import (
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxSearchTerms = 8
+	// in runes
+	snippetLen      = 160
+	snippetContext  = 40
+	snippetEllipsis = "…"
+
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// SplitSearchTerms keeps only the words of a user filter (letters and digits), lowercased,
+// so no operator or wildcard of any search backend could be injected.
+func SplitSearchTerms(filter string) []string {
+	words := strings.FieldsFunc(filter, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if !slices.Contains(terms, word) {
+			terms = append(terms, word)
+		}
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// BuildSnippet returns an html escaped extract of text around the first match,
+// each occurrence of a term is surrounded by MarkStart and MarkEnd.
+func BuildSnippet(text string, terms []string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	lowered := make([]rune, len(runes))
+	for i, r := range runes {
+		lowered[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i := 0; i+len(termRunes) <= len(lowered); i++ {
+			if slices.Equal(lowered[i:i+len(termRunes)], termRunes) {
+				for j := i; j < i+len(termRunes); j++ {
+					marked[j] = true
+				}
+				if first == -1 || i < first {
+					first = i
+				}
+			}
+		}
+	}
+
+	start := max(first-snippetContext, 0)
+	end := min(start+snippetLen, len(runes))
+	var snippetBuilder strings.Builder
+	if start != 0 {
+		snippetBuilder.WriteString(snippetEllipsis)
+	}
+	inMark := false
+	segmentStart := start
+	for i := start; i <= end; i++ {
+		if i != end && marked[i] == inMark {
+			continue
+		}
+		snippetBuilder.WriteString(html.EscapeString(string(runes[segmentStart:i])))
+		if i != end {
+			if inMark {
+				snippetBuilder.WriteString(MarkEnd)
+			} else {
+				snippetBuilder.WriteString(MarkStart)
+			}
+			inMark = !inMark
+			segmentStart = i
+		}
+	}
+	if inMark {
+		snippetBuilder.WriteString(MarkEnd)
+	}
+	if end != len(runes) {
+		snippetBuilder.WriteString(snippetEllipsis)
+	}
+	return snippetBuilder.String()
+}
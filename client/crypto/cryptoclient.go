@@ -0,0 +1,310 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cryptoclient
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	fsclient "github.com/dvaumoron/puzzleweaver/client/fs"
+	"github.com/spf13/afero"
+)
+
+const (
+	prefix        = "enc:"
+	indexKeyLabel = "index"
+	// AES-256 for the data keys
+	dataKeySize = 32
+)
+
+var (
+	encoding = base64.RawStdEncoding
+
+	errMissingActiveKey = errors.New("the active key is not in the key files")
+	errInvalidKeyId     = errors.New("a key id can not be empty nor contain ':'")
+	errInvalidKey       = errors.New("a key must have 16, 24 or 32 bytes (raw or base64)")
+	errUnknownKey       = errors.New("value encrypted with an unknown key")
+	errMalformed        = errors.New("malformed encrypted value")
+	errNotEncrypted     = errors.New("value not encrypted")
+)
+
+// Conf is a key ring block, used for the encryption of stored data, the pepper of password hashes
+// and the signature of tokens.
+type Conf struct {
+	// where the key files are read (local when Kind is empty)
+	Fs fsclient.FsConf
+	// key files by key id, a key is kept after a rotation to decrypt the older values
+	KeyFiles map[string]string
+	// id of the key used for the new values
+	ActiveKey string
+	// only read by the key rings of encryption, accepts the values stored before the activation of the encryption,
+	// to set while they are rewritten (they are refused otherwise)
+	AcceptPlaintext bool
+}
+
+func (c Conf) Enabled() bool {
+	return c.ActiveKey != ""
+}
+
+// KeyRing does envelope encryption : each value is encrypted with its own data key,
+// which is encrypted by the active key, whose id is stored with the value.
+type KeyRing struct {
+	activeId        string
+	keys            map[string]cipher.AEAD
+	indexKeys       map[string][]byte
+	acceptPlaintext bool
+}
+
+// Load returns nil when the conf is not enabled.
+func Load(logger *slog.Logger, conf Conf) (*KeyRing, error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+
+	rawKeys, err := readKeys(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]cipher.AEAD, len(rawKeys))
+	indexKeys := make(map[string][]byte, len(rawKeys))
+	for id, rawKey := range rawKeys {
+		if keys[id], err = newAEAD(rawKey); err != nil {
+			return nil, err
+		}
+		// derived, so the key is not used both by AES and HMAC
+		indexKeys[id] = hmacSum(rawKey, indexKeyLabel)
+	}
+
+	logger.Info("Encryption keys loaded", "activeKey", conf.ActiveKey, "keyNumber", len(keys))
+	return &KeyRing{activeId: conf.ActiveKey, keys: keys, indexKeys: indexKeys, acceptPlaintext: conf.AcceptPlaintext}, nil
+}
+
+func readKeys(conf Conf) (map[string][]byte, error) {
+	if _, ok := conf.KeyFiles[conf.ActiveKey]; !ok {
+		return nil, errMissingActiveKey
+	}
+
+	var fileSystem afero.Fs = afero.NewOsFs()
+	if conf.Fs.Kind != "" {
+		var err error
+		if fileSystem, err = fsclient.New(conf.Fs); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make(map[string][]byte, len(conf.KeyFiles))
+	for id, path := range conf.KeyFiles {
+		if id == "" || strings.ContainsAny(id, ":$") {
+			return nil, errInvalidKeyId
+		}
+
+		data, err := afero.ReadFile(fileSystem, path)
+		if err != nil {
+			return nil, err
+		}
+
+		key := decodeKey(data)
+		if size := len(key); size != 16 && size != 24 && size != 32 {
+			return nil, errInvalidKey
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// accept a raw key or a base64 one (like the output of "openssl rand -base64 32")
+func decodeKey(data []byte) []byte {
+	if size := len(data); size == 16 || size == 24 || size == 32 {
+		return data
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if size := len(key); size != 16 && size != 24 && size != 32 {
+		return nil, errInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt returns the value unchanged on a nil receiver. recordId identifies the record (a session, a user)
+// and name is the key of the value in its map, both are authenticated so an encrypted value
+// can not be moved to another key nor to another record.
+func (r *KeyRing) Encrypt(recordId string, name string, value string) (string, error) {
+	if r == nil {
+		return value, nil
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(r.keys[r.activeId], dataKey, []byte(r.activeId))
+	if err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(value), additionalData(recordId, name))
+	if err != nil {
+		return "", err
+	}
+	return prefix + r.activeId + ":" + encoding.EncodeToString(wrappedKey) + ":" + encoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt returns the value unchanged on a nil receiver, a value which is not encrypted
+// (written before the activation of the encryption) is refused unless the conf has AcceptPlaintext.
+func (r *KeyRing) Decrypt(recordId string, name string, value string) (string, error) {
+	if r == nil {
+		return value, nil
+	}
+	if !strings.HasPrefix(value, prefix) {
+		if r.acceptPlaintext {
+			return value, nil
+		}
+		return "", errNotEncrypted
+	}
+
+	parts := strings.Split(value[len(prefix):], ":")
+	if len(parts) != 3 {
+		return "", errMalformed
+	}
+	keyAEAD, ok := r.keys[parts[0]]
+	if !ok {
+		return "", errUnknownKey
+	}
+
+	wrappedKey, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := open(keyAEAD, wrappedKey, []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, additionalData(recordId, name))
+	return string(plaintext), err
+}
+
+// EncryptMap returns a new map (or the same on a nil receiver).
+func (r *KeyRing) EncryptMap(recordId string, info map[string]string) (map[string]string, error) {
+	return r.convertMap(recordId, info, r.Encrypt)
+}
+
+// DecryptMap returns a new map (or the same on a nil receiver).
+func (r *KeyRing) DecryptMap(recordId string, info map[string]string) (map[string]string, error) {
+	return r.convertMap(recordId, info, r.Decrypt)
+}
+
+func (r *KeyRing) convertMap(recordId string, info map[string]string, convert func(string, string, string) (string, error)) (map[string]string, error) {
+	if r == nil {
+		return info, nil
+	}
+
+	res := make(map[string]string, len(info))
+	for name, value := range info {
+		converted, err := convert(recordId, name, value)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = converted
+	}
+	return res, nil
+}
+
+// Index returns a keyed digest of value with the active key, to look a record up by value without
+// storing it (name separates the uses), the value unchanged on a nil receiver.
+func (r *KeyRing) Index(name string, value string) string {
+	if r == nil {
+		return value
+	}
+	return r.index(r.activeId, name, value)
+}
+
+// Indexes returns the results of Index with each key of the ring (the active one first),
+// to find the records indexed before a rotation.
+func (r *KeyRing) Indexes(name string, value string) []string {
+	if r == nil {
+		return []string{value}
+	}
+
+	res := make([]string, 0, len(r.indexKeys))
+	res = append(res, r.index(r.activeId, name, value))
+	for id := range r.indexKeys {
+		if id != r.activeId {
+			res = append(res, r.index(id, name, value))
+		}
+	}
+	return res
+}
+
+// the key id avoids a collision between the keys
+func (r *KeyRing) index(keyId string, name string, value string) string {
+	return keyId + ":" + mac(r.indexKeys[keyId], string(additionalData(name, value)))
+}
+
+// the length of the record id avoids an ambiguity between the two parts
+func additionalData(recordId string, name string) []byte {
+	return []byte(strconv.Itoa(len(recordId)) + ":" + recordId + name)
+}
+
+// the nonce is put before the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, data []byte, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errMalformed
+	}
+	return aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData)
+}
@@ -0,0 +1,329 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package cryptoclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writes a key file by id (the key is derived from the id, so two rings share their keys)
+func testConf(t *testing.T, activeKey string, ids ...string) Conf {
+	t.Helper()
+	dir := t.TempDir()
+	keyFiles := make(map[string]string, len(ids))
+	for _, id := range ids {
+		path := filepath.Join(dir, id)
+		key := bytes.Repeat([]byte(id), dataKeySize)[:dataKeySize]
+		if err := os.WriteFile(path, key, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		keyFiles[id] = path
+	}
+	return Conf{KeyFiles: keyFiles, ActiveKey: activeKey}
+}
+
+func testKeyRing(t *testing.T, activeKey string, ids ...string) *KeyRing {
+	t.Helper()
+	ring, err := Load(testLogger, testConf(t, activeKey, ids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func TestKeyRingRoundTrip(t *testing.T) {
+	ring := testKeyRing(t, "k1", "k1")
+	tests := []struct {
+		name     string
+		recordId string
+		key      string
+		value    string
+	}{
+		{name: "simple", recordId: "42", key: "Login", value: "alice"},
+		{name: "empty value", recordId: "42", key: "Login", value: ""},
+		{name: "empty record", recordId: "", key: "Login", value: "alice"},
+		{name: "unicode", recordId: "7", key: "Name", value: "héhé ☃"},
+		{name: "separators", recordId: "1:2", key: "a:b", value: "enc:k1:x:y"},
+		{name: "long", recordId: "9", key: "Data", value: strings.Repeat("x", 10000)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted, err := ring.Encrypt(test.recordId, test.key, test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(encrypted, prefix+"k1:") {
+				t.Errorf("unexpected format : %q", encrypted)
+			}
+			if test.value != "" && strings.Contains(encrypted, test.value) {
+				t.Error("the value is readable in the encrypted one")
+			}
+
+			decrypted, err := ring.Decrypt(test.recordId, test.key, encrypted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted != test.value {
+				t.Errorf("got %q, want %q", decrypted, test.value)
+			}
+		})
+	}
+}
+
+func TestKeyRingTamper(t *testing.T) {
+	ring := testKeyRing(t, "k1", "k1")
+	encrypted, err := ring.Encrypt("ab", "c", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(encrypted[len(prefix):], ":")
+
+	flipped, _ := encoding.DecodeString(parts[2])
+	flipped[len(flipped)-1] ^= 1
+
+	tests := []struct {
+		name     string
+		recordId string
+		key      string
+		value    string
+	}{
+		{name: "other record", recordId: "ba", key: "c", value: encrypted},
+		{name: "other name", recordId: "ab", key: "d", value: encrypted},
+		{name: "shifted boundary", recordId: "a", key: "bc", value: encrypted},
+		{name: "flipped ciphertext", recordId: "ab", key: "c", value: prefix + parts[0] + ":" + parts[1] + ":" + encoding.EncodeToString(flipped)},
+		{name: "swapped wrapped key", recordId: "ab", key: "c", value: prefix + parts[0] + ":" + parts[2] + ":" + parts[1]},
+		{name: "missing part", recordId: "ab", key: "c", value: prefix + parts[0] + ":" + parts[1]},
+		{name: "extra part", recordId: "ab", key: "c", value: encrypted + ":x"},
+		{name: "truncated", recordId: "ab", key: "c", value: prefix + parts[0] + ":" + parts[1] + ":AAAA"},
+		{name: "not base64", recordId: "ab", key: "c", value: prefix + parts[0] + ":" + parts[1] + ":!!"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if decrypted, err := ring.Decrypt(test.recordId, test.key, test.value); err == nil {
+				t.Errorf("tampered value accepted : %q", decrypted)
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	oldRing := testKeyRing(t, "k1", "k1")
+	encrypted, err := oldRing.Encrypt("42", "Login", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotatedRing := testKeyRing(t, "k2", "k1", "k2")
+	tests := []struct {
+		name    string
+		ring    *KeyRing
+		wantErr error
+	}{
+		{name: "old key kept", ring: rotatedRing},
+		{name: "old key removed", ring: testKeyRing(t, "k2", "k2"), wantErr: errUnknownKey},
+		{name: "rotation reverted", ring: testKeyRing(t, "k1", "k1", "k2")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decrypted, err := test.ring.Decrypt("42", "Login", encrypted)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("got error %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted != "alice" {
+				t.Errorf("got %q, want %q", decrypted, "alice")
+			}
+		})
+	}
+
+	reencrypted, err := rotatedRing.Encrypt("42", "Login", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(reencrypted, prefix+"k2:") {
+		t.Errorf("new value not encrypted with the active key : %q", reencrypted)
+	}
+}
+
+func TestKeyRingPassThrough(t *testing.T) {
+	var nilRing *KeyRing
+	conf := testConf(t, "k1", "k1")
+	ring, err := Load(testLogger, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.AcceptPlaintext = true
+	migrationRing, err := Load(testLogger, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ring    *KeyRing
+		value   string
+		wantErr error
+	}{
+		{name: "nil ring plain value", ring: nilRing, value: "alice"},
+		{name: "nil ring prefixed value", ring: nilRing, value: prefix + "k1:x:y"},
+		{name: "value stored before the encryption", ring: ring, value: "alice", wantErr: errNotEncrypted},
+		{name: "empty value stored before the encryption", ring: ring, value: "", wantErr: errNotEncrypted},
+		{name: "value stored before the encryption during the migration", ring: migrationRing, value: "alice"},
+		{name: "empty value stored before the encryption during the migration", ring: migrationRing, value: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decrypted, err := test.ring.Decrypt("42", "Login", test.value)
+			if err != test.wantErr {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil && decrypted != test.value {
+				t.Errorf("got %q, want %q", decrypted, test.value)
+			}
+		})
+	}
+
+	if encrypted, _ := nilRing.Encrypt("42", "Login", "alice"); encrypted != "alice" {
+		t.Errorf("nil ring changed the value : %q", encrypted)
+	}
+}
+
+func TestKeyRingIndex(t *testing.T) {
+	oldRing := testKeyRing(t, "k1", "k1")
+	rotatedRing := testKeyRing(t, "k2", "k1", "k2")
+
+	index := oldRing.Index("user", "42")
+	if index == "42" {
+		t.Error("the value is stored in the index")
+	}
+	if other := oldRing.Index("user", "43"); other == index {
+		t.Error("two values share their index")
+	}
+	if other := oldRing.Index("login", "42"); other == index {
+		t.Error("two uses share their index")
+	}
+
+	indexes := rotatedRing.Indexes("user", "42")
+	if len(indexes) != 2 {
+		t.Fatalf("got %d indexes, want 2", len(indexes))
+	}
+	if indexes[0] != rotatedRing.Index("user", "42") || indexes[0] == index {
+		t.Error("the first index is not the one of the active key")
+	}
+	if indexes[1] != index {
+		t.Error("the index of the previous key is not found")
+	}
+
+	var nilRing *KeyRing
+	if got := nilRing.Indexes("user", "42"); len(got) != 1 || got[0] != "42" || nilRing.Index("user", "42") != "42" {
+		t.Errorf("nil ring changed the value : %q", got)
+	}
+}
+
+func TestKeyRingMap(t *testing.T) {
+	ring := testKeyRing(t, "k1", "k1")
+	info := map[string]string{"Login": "alice", "UserId": "42", "Empty": ""}
+	encrypted, err := ring.EncryptMap("s1", info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := ring.DecryptMap("s1", encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decrypted) != len(info) {
+		t.Fatalf("got %d values, want %d", len(decrypted), len(info))
+	}
+	for name, value := range info {
+		if decrypted[name] != value {
+			t.Errorf("%s : got %q, want %q", name, decrypted[name], value)
+		}
+	}
+
+	// values swapped between two keys of the same record
+	encrypted["Login"], encrypted["UserId"] = encrypted["UserId"], encrypted["Login"]
+	if _, err = ring.DecryptMap("s1", encrypted); err == nil {
+		t.Error("swapped values accepted")
+	}
+	if _, err = ring.DecryptMap("s2", map[string]string{"Empty": encrypted["Empty"]}); err == nil {
+		t.Error("value of another record accepted")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeKey := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	rawKey := writeKey("raw", bytes.Repeat([]byte{1}, 32))
+	base64Key := writeKey("base64", []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 16))+"\n"))
+	shortKey := writeKey("short", bytes.Repeat([]byte{3}, 15))
+	longKey := writeKey("long", bytes.Repeat([]byte{4}, 33))
+
+	tests := []struct {
+		name    string
+		conf    Conf
+		wantErr error
+		wantNil bool
+	}{
+		{name: "disabled", conf: Conf{KeyFiles: map[string]string{"k1": rawKey}}, wantNil: true},
+		{name: "raw key", conf: Conf{KeyFiles: map[string]string{"k1": rawKey}, ActiveKey: "k1"}},
+		{name: "base64 key", conf: Conf{KeyFiles: map[string]string{"k1": base64Key}, ActiveKey: "k1"}},
+		{name: "missing active key", conf: Conf{KeyFiles: map[string]string{"k1": rawKey}, ActiveKey: "k2"}, wantErr: errMissingActiveKey},
+		{name: "key id with separator", conf: Conf{KeyFiles: map[string]string{"k:1": rawKey}, ActiveKey: "k:1"}, wantErr: errInvalidKeyId},
+		{name: "key too short", conf: Conf{KeyFiles: map[string]string{"k1": shortKey}, ActiveKey: "k1"}, wantErr: errInvalidKey},
+		{name: "key too long", conf: Conf{KeyFiles: map[string]string{"k1": longKey}, ActiveKey: "k1"}, wantErr: errInvalidKey},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ring, err := Load(testLogger, test.conf)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("got error %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (ring == nil) != test.wantNil {
+				t.Errorf("got ring %v, want nil %v", ring, test.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cryptoclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+)
+
+const pepperPrefix = "pepper:"
+
+// Pepper keys the password hashes with a secret kept out of the database,
+// the id of the key is stored with the result.
+type Pepper struct {
+	activeId string
+	keys     map[string][]byte
+}
+
+// LoadPepper returns nil when the conf is not enabled.
+func LoadPepper(logger *slog.Logger, conf Conf) (*Pepper, error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+
+	keys, err := readKeys(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Pepper keys loaded", "activeKey", conf.ActiveKey, "keyNumber", len(keys))
+	return &Pepper{activeId: conf.ActiveKey, keys: keys}, nil
+}
+
+// Hash returns the value unchanged on a nil receiver.
+func (p *Pepper) Hash(value string) string {
+	if p == nil {
+		return value
+	}
+	return pepperPrefix + p.activeId + ":" + mac(p.keys[p.activeId], value)
+}
+
+func mac(key []byte, value string) string {
+	return encoding.EncodeToString(hmacSum(key, value))
+}
+
+func hmacSum(key []byte, value string) []byte {
+	hasher := hmac.New(sha256.New, key)
+	hasher.Write([]byte(value))
+	return hasher.Sum(nil)
+}
+
+// Verify compares in constant time value with a stored result of Hash (or an unchanged value,
+// stored before the activation of the pepper), outdated is true when Hash would give another result.
+func (p *Pepper) Verify(value string, stored string) (match bool, outdated bool) {
+	rest, peppered := strings.CutPrefix(stored, pepperPrefix)
+	if !peppered {
+		return subtle.ConstantTimeCompare([]byte(value), []byte(stored)) == 1, p != nil
+	}
+	if p == nil {
+		// the pepper has been removed from the configuration
+		return false, false
+	}
+
+	keyId, storedMac, ok := strings.Cut(rest, ":")
+	if !ok {
+		return false, false
+	}
+	key, ok := p.keys[keyId]
+	if !ok {
+		return false, false
+	}
+	return hmac.Equal([]byte(mac(key, value)), []byte(storedMac)), keyId != p.activeId
+}
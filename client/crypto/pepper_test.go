@@ -0,0 +1,96 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package cryptoclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func testPepper(t *testing.T, activeKey string, ids ...string) *Pepper {
+	t.Helper()
+	pepper, err := LoadPepper(testLogger, testConf(t, activeKey, ids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pepper
+}
+
+func TestPepperVerify(t *testing.T) {
+	var nilPepper *Pepper
+	oldPepper := testPepper(t, "k1", "k1")
+	rotatedPepper := testPepper(t, "k2", "k1", "k2")
+
+	hashedK1 := oldPepper.Hash("hash")
+	hashedK2 := rotatedPepper.Hash("hash")
+	keyId, storedMac, _ := strings.Cut(strings.TrimPrefix(hashedK1, pepperPrefix), ":")
+	flippedMac := []byte(storedMac)
+	flippedMac[0] ^= 1
+
+	tests := []struct {
+		name         string
+		pepper       *Pepper
+		value        string
+		stored       string
+		wantMatch    bool
+		wantOutdated bool
+	}{
+		{name: "round trip", pepper: oldPepper, value: "hash", stored: hashedK1, wantMatch: true},
+		{name: "wrong value", pepper: oldPepper, value: "hash2", stored: hashedK1},
+		{name: "empty value", pepper: oldPepper, value: "", stored: hashedK1},
+		{name: "altered mac", pepper: oldPepper, value: "hash", stored: pepperPrefix + keyId + ":" + string(flippedMac)},
+		{name: "truncated mac", pepper: oldPepper, value: "hash", stored: hashedK1[:len(hashedK1)-1]},
+		{name: "missing key id", pepper: oldPepper, value: "hash", stored: pepperPrefix + storedMac},
+		{name: "unknown key id", pepper: oldPepper, value: "hash", stored: hashedK2},
+		{name: "old key after rotation", pepper: rotatedPepper, value: "hash", stored: hashedK1, wantMatch: true, wantOutdated: true},
+		{name: "wrong value with the old key", pepper: rotatedPepper, value: "hash2", stored: hashedK1, wantOutdated: true},
+		{name: "active key after rotation", pepper: rotatedPepper, value: "hash", stored: hashedK2, wantMatch: true},
+		{name: "stored before the pepper", pepper: oldPepper, value: "hash", stored: "hash", wantMatch: true, wantOutdated: true},
+		{name: "wrong value stored before the pepper", pepper: oldPepper, value: "hash2", stored: "hash", wantOutdated: true},
+		{name: "no pepper", pepper: nilPepper, value: "hash", stored: "hash", wantMatch: true},
+		{name: "pepper removed", pepper: nilPepper, value: "hash", stored: hashedK1},
+		{name: "pepper prefix given as value", pepper: nilPepper, value: hashedK1, stored: hashedK1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, outdated := test.pepper.Verify(test.value, test.stored)
+			if match != test.wantMatch || outdated != test.wantOutdated {
+				t.Errorf("got (%v, %v), want (%v, %v)", match, outdated, test.wantMatch, test.wantOutdated)
+			}
+		})
+	}
+}
+
+func TestPepperHash(t *testing.T) {
+	var nilPepper *Pepper
+	if hashed := nilPepper.Hash("hash"); hashed != "hash" {
+		t.Errorf("nil pepper changed the value : %q", hashed)
+	}
+
+	pepper := testPepper(t, "k2", "k1", "k2")
+	hashed := pepper.Hash("hash")
+	if !strings.HasPrefix(hashed, pepperPrefix+"k2:") {
+		t.Errorf("not hashed with the active key : %q", hashed)
+	}
+	if hashed != pepper.Hash("hash") {
+		t.Error("the hash is not deterministic")
+	}
+	if hashed == pepper.Hash("hash2") {
+		t.Error("two values with the same hash")
+	}
+}
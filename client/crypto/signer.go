@@ -0,0 +1,110 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package cryptoclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	tokenEncoding = base64.RawURLEncoding
+
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("expired token")
+)
+
+// Signer builds tokens carrying a value and its expiration, readable only by their signer
+// (the values are not encrypted), the id of the signing key is put in the token.
+type Signer struct {
+	activeId string
+	keys     map[string][]byte
+}
+
+// LoadSigner returns nil when the conf is not enabled.
+func LoadSigner(logger *slog.Logger, conf Conf) (*Signer, error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+
+	keys, err := readKeys(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Signing keys loaded", "activeKey", conf.ActiveKey, "keyNumber", len(keys))
+	return &Signer{activeId: conf.ActiveKey, keys: keys}, nil
+}
+
+// Sign returns an URL safe token, purpose is signed too, so a token can not be used for another purpose.
+func (s *Signer) Sign(purpose string, value string, expiration time.Time) string {
+	payload := strconv.FormatInt(expiration.Unix(), 10) + ":" + value
+	return tokenEncoding.EncodeToString([]byte(s.activeId)) + "." + tokenEncoding.EncodeToString([]byte(payload)) +
+		"." + tokenMac(s.keys[s.activeId], purpose, payload)
+}
+
+// Open returns the value of a token built by Sign with the same purpose.
+func (s *Signer) Open(purpose string, token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	keyId, err := tokenEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	key, ok := s.keys[string(keyId)]
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	payload, err := tokenEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(tokenMac(key, purpose, string(payload))), []byte(parts[2])) {
+		return "", ErrInvalidToken
+	}
+
+	rawExpiration, value, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	expiration, err := strconv.ParseInt(rawExpiration, 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if now.Unix() > expiration {
+		return "", ErrExpiredToken
+	}
+	return value, nil
+}
+
+func tokenMac(key []byte, purpose string, payload string) string {
+	hasher := hmac.New(sha256.New, key)
+	hasher.Write([]byte(purpose))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(payload))
+	return tokenEncoding.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,160 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package cryptoclient
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+func testSigner(t *testing.T, activeKey string, ids ...string) *Signer {
+	t.Helper()
+	signer, err := LoadSigner(testLogger, testConf(t, activeKey, ids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := testSigner(t, "k1", "k1")
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "simple", value: "42:alice@example.com"},
+		{name: "empty", value: ""},
+		{name: "separators", value: "a.b:c.d"},
+		{name: "unicode", value: "héhé ☃"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := signer.Sign("email", test.value, testNow.Add(time.Hour))
+			if strings.ContainsAny(token, "+/=") {
+				t.Errorf("token not URL safe : %q", token)
+			}
+
+			value, err := signer.Open("email", token, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("got %q, want %q", value, test.value)
+			}
+		})
+	}
+}
+
+func TestSignerTamper(t *testing.T) {
+	signer := testSigner(t, "k1", "k1")
+	expiration := testNow.Add(time.Hour)
+	token := signer.Sign("email", "42:alice@example.com", expiration)
+	parts := strings.Split(token, ".")
+
+	payload, err := tokenEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPayload := tokenEncoding.EncodeToString([]byte(strings.Replace(string(payload), "42:", "43:", 1)))
+	laterPayload := tokenEncoding.EncodeToString([]byte("9999999999:42:alice@example.com"))
+	noExpiration := signer.Sign("email", "", expiration)
+	noExpirationParts := strings.Split(noExpiration, ".")
+
+	tests := []struct {
+		name    string
+		purpose string
+		token   string
+	}{
+		{name: "other purpose", purpose: "reset", token: token},
+		{name: "other value", purpose: "email", token: parts[0] + "." + otherPayload + "." + parts[2]},
+		{name: "later expiration", purpose: "email", token: parts[0] + "." + laterPayload + "." + parts[2]},
+		{name: "other signature", purpose: "email", token: parts[0] + "." + parts[1] + "." + noExpirationParts[2]},
+		{name: "truncated signature", purpose: "email", token: token[:len(token)-1]},
+		{name: "unknown key", purpose: "email", token: tokenEncoding.EncodeToString([]byte("k2")) + "." + parts[1] + "." + parts[2]},
+		{name: "missing part", purpose: "email", token: parts[0] + "." + parts[1]},
+		{name: "extra part", purpose: "email", token: token + ".x"},
+		{name: "not base64", purpose: "email", token: "!!." + parts[1] + "." + parts[2]},
+		{name: "empty", purpose: "email", token: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if value, err := signer.Open(test.purpose, test.token, testNow); err != ErrInvalidToken {
+				t.Errorf("got (%q, %v), want error %v", value, err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestSignerExpiration(t *testing.T) {
+	signer := testSigner(t, "k1", "k1")
+	token := signer.Sign("email", "value", testNow)
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "before", now: testNow.Add(-time.Hour)},
+		{name: "at the expiration", now: testNow},
+		{name: "same second", now: testNow.Add(999 * time.Millisecond)},
+		{name: "next second", now: testNow.Add(time.Second), wantErr: ErrExpiredToken},
+		{name: "long after", now: testNow.Add(24 * time.Hour), wantErr: ErrExpiredToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := signer.Open("email", token, test.now); err != test.wantErr {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignerRotation(t *testing.T) {
+	token := testSigner(t, "k1", "k1").Sign("email", "value", testNow.Add(time.Hour))
+	rotatedSigner := testSigner(t, "k2", "k1", "k2")
+	tests := []struct {
+		name    string
+		signer  *Signer
+		wantErr error
+	}{
+		{name: "old key kept", signer: rotatedSigner},
+		{name: "old key removed", signer: testSigner(t, "k2", "k2"), wantErr: ErrInvalidToken},
+		{name: "rotation reverted", signer: testSigner(t, "k1", "k1", "k2")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := test.signer.Open("email", token, testNow)
+			if err != test.wantErr {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if err == nil && value != "value" {
+				t.Errorf("got %q, want %q", value, "value")
+			}
+		})
+	}
+
+	newToken := rotatedSigner.Sign("email", "value", testNow.Add(time.Hour))
+	if keyId, _, _ := strings.Cut(newToken, "."); keyId != tokenEncoding.EncodeToString([]byte("k2")) {
+		t.Errorf("new token not signed with the active key : %q", newToken)
+	}
+	if _, err := testSigner(t, "k1", "k1").Open("email", newToken, testNow); err != ErrInvalidToken {
+		t.Errorf("got error %v, want %v", err, ErrInvalidToken)
+	}
+}
@@ -19,9 +19,20 @@
 package dbclient
 
 import (
+	"database/sql"
 	"errors"
+	"net"
 	"strings"
 
+	clickhousedriver "github.com/ClickHouse/clickhouse-go/v2"
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
+	"github.com/dvaumoron/puzzleweaver/client/db/sqlite"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
 	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
 	"gorm.io/driver/clickhouse"
 	"gorm.io/driver/mysql"
@@ -30,22 +41,30 @@ import (
 	"gorm.io/gorm"
 )
 
-var errUnknownKind = errors.New("Unknown database type")
+const (
+	// store time in a sortable format (the driver default is time.Time.String)
+	sqliteTimeFormatParam = "_time_format=sqlite"
+	// wait for the writer of another connection or process (like a concurrent migrate) instead of failing
+	sqliteBusyTimeoutParam = "_pragma=busy_timeout(5000)"
+)
+
+var (
+	errUnknownKind    = errors.New("Unknown database type")
+	errTLSUnsupported = errors.New("TLS is not supported with this database type")
+)
 
-func New(kind string, address string) (*gorm.DB, error) {
+// certificates can be nil (TLS is then driven by the address)
+func New(kind string, address string, certificates *tlsclient.Certificates) (*gorm.DB, error) {
 	kind = strings.ToLower(kind)
 	var dialector gorm.Dialector
-	switch kind {
-	case "postgres":
-		dialector = postgres.Open(address)
-	case "mysql":
-		dialector = mysql.Open(address)
-	case "sqlserver":
-		dialector = sqlserver.Open(address)
-	case "clickhouse":
-		dialector = clickhouse.Open(address)
-	default:
-		return nil, errUnknownKind
+	var err error
+	if certificates == nil {
+		dialector, err = newDialector(kind, address)
+	} else {
+		dialector, err = newTLSDialector(kind, address, certificates)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	db, err := gorm.Open(dialector)
@@ -57,19 +76,124 @@ func New(kind string, address string) (*gorm.DB, error) {
 	return db, err
 }
 
+func newDialector(kind string, address string) (gorm.Dialector, error) {
+	switch kind {
+	case "postgres":
+		return postgres.Open(address), nil
+	case "mysql":
+		return mysql.Open(address), nil
+	case "sqlserver":
+		return sqlserver.Open(address), nil
+	case "clickhouse":
+		return clickhouse.Open(address), nil
+	case "sqlite":
+		// pure go driver modernc.org/sqlite (no cgo needed), address could be a file path
+		// or an in memory DSN (like "file::memory:?cache=shared")
+		return sqlite.Open(addSqliteParams(address)), nil
+	}
+	return nil, errUnknownKind
+}
+
+// the parsed address is completed with the TLS configuration, so the handshakes follow the certificate rotation
+func newTLSDialector(kind string, address string, certificates *tlsclient.Certificates) (gorm.Dialector, error) {
+	switch kind {
+	case "postgres":
+		config, err := pgx.ParseConfig(address)
+		if err != nil {
+			return nil, err
+		}
+
+		config.TLSConfig = certificates.ClientConfig(config.Host)
+		// no plain text fallback (like with sslmode=prefer)
+		fallbacks := config.Fallbacks[:0]
+		for _, fallback := range config.Fallbacks {
+			if fallback.TLSConfig != nil {
+				fallback.TLSConfig = certificates.ClientConfig(fallback.Host)
+				fallbacks = append(fallbacks, fallback)
+			}
+		}
+		config.Fallbacks = fallbacks
+		return postgres.New(postgres.Config{Conn: stdlib.OpenDB(*config)}), nil
+	case "mysql":
+		config, err := mysqldriver.ParseDSN(address)
+		if err != nil {
+			return nil, err
+		}
+
+		host, _, err := net.SplitHostPort(config.Addr)
+		if err != nil {
+			host = config.Addr
+		}
+		config.TLS = certificates.ClientConfig(host)
+		connector, err := mysqldriver.NewConnector(config)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.New(mysql.Config{Conn: sql.OpenDB(connector)}), nil
+	case "sqlserver":
+		config, err := msdsn.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Encryption = msdsn.EncryptionRequired
+		config.TLSConfig = certificates.ClientConfig(config.Host)
+		return sqlserver.New(sqlserver.Config{Conn: sql.OpenDB(mssql.NewConnectorConfig(config))}), nil
+	case "clickhouse":
+		options, err := clickhousedriver.ParseDSN(address)
+		if err != nil {
+			return nil, err
+		}
+
+		var host string
+		if len(options.Addr) != 0 {
+			if host, _, err = net.SplitHostPort(options.Addr[0]); err != nil {
+				host = options.Addr[0]
+			}
+		}
+		options.TLS = certificates.ClientConfig(host)
+		return clickhouse.New(clickhouse.Config{Conn: clickhousedriver.OpenDB(options)}), nil
+	case "sqlite":
+		return nil, errTLSUnsupported
+	}
+	return nil, errUnknownKind
+}
+
+func addSqliteParams(address string) string {
+	if !strings.Contains(address, "_time_format=") {
+		address = addSqliteParam(address, sqliteTimeFormatParam)
+	}
+	if !strings.Contains(address, "busy_timeout") {
+		address = addSqliteParam(address, sqliteBusyTimeoutParam)
+	}
+	return address
+}
+
+func addSqliteParam(address string, param string) string {
+	if strings.IndexByte(address, '?') == -1 {
+		return address + "?" + param
+	}
+	return address + "&" + param
+}
+
 func Paginate(db *gorm.DB, start uint64, end uint64) *gorm.DB {
 	return db.Offset(int(start)).Limit(int(end - start))
 }
 
-func BuildLikeFilter(filter string) string {
-	filter = strings.ReplaceAll(filter, ".*", "%")
-	var likeBuilder strings.Builder
-	if strings.IndexByte(filter, '%') != 0 {
-		likeBuilder.WriteByte('%')
+// KeysetPaginate orders on (created_at, id) and keeps the rows after the cursor position,
+// the limit is size + 1 to detect a following page (see clientcommon.BuildPage).
+func KeysetPaginate(db *gorm.DB, cursor clientcommon.Cursor, size uint64, desc bool) *gorm.DB {
+	// a backward cursor reverse the reading order
+	order, comparator := "created_at asc, id asc", ">"
+	if desc != cursor.Backward {
+		order, comparator = "created_at desc, id desc", "<"
 	}
-	likeBuilder.WriteString(filter)
-	if strings.LastIndexByte(filter, '%') != len(filter)-1 {
-		likeBuilder.WriteByte('%')
+
+	if !cursor.First {
+		db = db.Where(
+			"(created_at "+comparator+" ? OR (created_at = ? AND id "+comparator+" ?))",
+			cursor.CreatedAt, cursor.CreatedAt, cursor.Id,
+		)
 	}
-	return likeBuilder.String()
+	return db.Order(order).Limit(int(size + 1))
 }
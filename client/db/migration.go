@@ -0,0 +1,258 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	migrationclient "github.com/dvaumoron/puzzleweaver/client/migration"
+	"gorm.io/gorm"
+)
+
+var ErrSchemaBehind = migrationclient.ErrSchemaBehind
+
+// a row by applied migration
+type SchemaMigration struct {
+	Component string `gorm:"primaryKey"`
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// at most one row by component, the primary key ensure only one process own it,
+// the owner refreshes LockedAt while it works
+type SchemaMigrationLock struct {
+	Component string `gorm:"primaryKey"`
+	Owner     string `gorm:"size:32"`
+	LockedAt  time.Time
+}
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      func(*gorm.DB) error
+	Down    func(*gorm.DB) error
+}
+
+type MigrationStatus = migrationclient.Status
+
+// Migrations are the ordered schema changes of one component,
+// versions are compared to the one recorded in the migrations table.
+type Migrations struct {
+	Component string
+	Steps     []Migration
+}
+
+// each step runs in a transaction with the record of its version
+func (m Migrations) engine(db *gorm.DB) migrationclient.Engine {
+	steps := make([]migrationclient.Step, 0, len(m.Steps))
+	for _, migration := range m.Steps {
+		migration := migration
+		step := migrationclient.Step{Version: migration.Version, Name: migration.Name, Up: func(context.Context) error {
+			return db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{
+					Component: m.Component, Version: migration.Version, Name: migration.Name, AppliedAt: time.Now(),
+				}).Error
+			})
+		}}
+		if migration.Down != nil {
+			step.Down = func(context.Context) error {
+				return db.Transaction(func(tx *gorm.DB) error {
+					if err := migration.Down(tx); err != nil {
+						return err
+					}
+					return tx.Delete(&SchemaMigration{}, "component = ? AND version = ?", m.Component, migration.Version).Error
+				})
+			}
+		}
+		steps = append(steps, step)
+	}
+	return migrationclient.Engine{Component: m.Component, Steps: steps, Store: migrationStore{db: db}}
+}
+
+// Check returns ErrSchemaBehind when some migration have not been applied.
+func (m Migrations) Check(db *gorm.DB) error {
+	return m.engine(db).Check(db.Statement.Context)
+}
+
+func (m Migrations) Status(db *gorm.DB) ([]MigrationStatus, error) {
+	return m.engine(db).Status(db.Statement.Context)
+}
+
+// Up applies all missing migrations in version order, returns the applied versions.
+func (m Migrations) Up(db *gorm.DB) ([]uint64, error) {
+	return m.engine(db).Up(db.Statement.Context)
+}
+
+// Down reverts the last applied migrations (at most count), returns the reverted versions.
+func (m Migrations) Down(db *gorm.DB, count int) ([]uint64, error) {
+	return m.engine(db).Down(db.Statement.Context, count)
+}
+
+type migrationStore struct {
+	db *gorm.DB
+}
+
+func (s migrationStore) Prepare(context.Context) error {
+	return s.db.AutoMigrate(&SchemaMigration{}, &SchemaMigrationLock{})
+}
+
+func (s migrationStore) Applied(ctx context.Context, component string) ([]MigrationStatus, error) {
+	db := s.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil, nil
+	}
+
+	var applieds []SchemaMigration
+	if err := db.Find(&applieds, "component = ?", component).Error; err != nil {
+		return nil, err
+	}
+
+	res := make([]MigrationStatus, 0, len(applieds))
+	for _, applied := range applieds {
+		res = append(res, MigrationStatus{Version: applied.Version, Name: applied.Name, Applied: true, AppliedAt: applied.AppliedAt})
+	}
+	return res, nil
+}
+
+func (s migrationStore) InsertLock(ctx context.Context, component string, owner string, now time.Time) (bool, error) {
+	db := s.db.WithContext(ctx)
+	err := db.Create(&SchemaMigrationLock{Component: component, Owner: owner, LockedAt: now}).Error
+	if err == nil {
+		return true, nil
+	}
+	// only a lock owned by another process means waiting
+	if IsDuplicateKey(db, err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s migrationStore) DeleteExpiredLock(ctx context.Context, component string, limit time.Time) error {
+	return s.db.WithContext(ctx).Delete(&SchemaMigrationLock{}, "component = ? AND locked_at < ?", component, limit).Error
+}
+
+func (s migrationStore) RefreshLock(ctx context.Context, component string, owner string, now time.Time) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&SchemaMigrationLock{}).Where(
+		"component = ? AND owner = ?", component, owner,
+	).Update("locked_at", now)
+	return result.RowsAffected != 0, result.Error
+}
+
+func (s migrationStore) DeleteLock(ctx context.Context, component string, owner string) error {
+	return s.db.WithContext(ctx).Delete(&SchemaMigrationLock{}, "component = ? AND owner = ?", component, owner).Error
+}
+
+// IsDuplicateKey checks an error with the translation of the dialector (when it has one).
+func IsDuplicateKey(db *gorm.DB, err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	return ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+}
+
+// helper for Migration.Down
+func DropTables(models ...any) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(models...)
+	}
+}
+
+// helper for Migration.Up, models must be copies frozen at the version of the migration
+// (not the live models), so the step stays the same when a model changes later
+func CreateTables(models ...any) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(models...)
+	}
+}
+
+// helper for the Migration.Up of a first version, the tables created by AutoMigrate before the migrations
+// (an existing install) are completed instead of created again, so the step can be applied on them
+func AdoptTables(models ...any) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, model := range models {
+			var err error
+			if migrator.HasTable(model) {
+				err = migrator.AutoMigrate(model)
+			} else {
+				err = migrator.CreateTable(model)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// helper for Migration.Up, the index does not need to be declared in model tags
+func CreateIndex(model any, name string, columns ...string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		quotedColumns := make([]string, 0, len(columns))
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, stmt.Quote(column))
+		}
+		return tx.Exec(
+			"CREATE INDEX " + stmt.Quote(name) + " ON " + stmt.Quote(stmt.Schema.Table) + " (" + strings.Join(quotedColumns, ", ") + ")",
+		).Error
+	}
+}
+
+// helper for Migration.Down
+func DropIndex(model any, name string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Migrator().DropIndex(model, name)
+	}
+}
+
+// helper for Migration.Up, several rows can keep a NULL value in the column
+// (SQL Server counts NULL as a value in a plain unique index, MySQL does not and has no filtered index)
+func CreateNullableUniqueIndex(model any, name string, column string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		quotedColumn := stmt.Quote(column)
+		query := "CREATE UNIQUE INDEX " + stmt.Quote(name) + " ON " + stmt.Quote(stmt.Schema.Table) + " (" + quotedColumn + ")"
+		switch tx.Dialector.Name() {
+		case "clickhouse":
+			// no unique index
+			return nil
+		case mysqlKind:
+		default:
+			query += " WHERE " + quotedColumn + " IS NOT NULL"
+		}
+		return tx.Exec(query).Error
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package dbclient
+
+import (
+	"errors"
+	"strings"
+
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// full text search of the database when supported (postgres and mysql), like otherwise
+	SearchNative = "native"
+	// portable mode, can not use an index
+	SearchLike = "like"
+
+	postgresKind = "postgres"
+	mysqlKind    = "mysql"
+)
+
+var errUnknownSearchMode = errors.New("unknown search mode")
+
+// Searcher translates user filters to the search mode chosen for a database.
+type Searcher struct {
+	kind string
+}
+
+func NewSearcher(db *gorm.DB, mode string) (Searcher, error) {
+	switch strings.ToLower(mode) {
+	case "", SearchNative:
+		if name := db.Dialector.Name(); name == postgresKind || name == mysqlKind {
+			return Searcher{kind: name}, nil
+		}
+		return Searcher{kind: SearchLike}, nil
+	case SearchLike:
+		return Searcher{kind: SearchLike}, nil
+	}
+	return Searcher{}, errUnknownSearchMode
+}
+
+// Search is a parsed filter, the terms contain only letters and digits,
+// so they can not carry any operator of the query syntax.
+type Search struct {
+	kind  string
+	query string
+	Terms []string
+}
+
+func (s Searcher) Parse(filter string) Search {
+	terms := clientcommon.SplitSearchTerms(filter)
+	queryTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		switch s.kind {
+		case postgresKind:
+			// prefix matching
+			queryTerms = append(queryTerms, term+":*")
+		case mysqlKind:
+			// mandatory term with prefix matching
+			queryTerms = append(queryTerms, "+"+term+"*")
+		}
+	}
+
+	separator := " "
+	if s.kind == postgresKind {
+		separator = " & "
+	}
+	return Search{kind: s.kind, query: strings.Join(queryTerms, separator), Terms: terms}
+}
+
+func (s Search) Empty() bool {
+	return len(s.Terms) == 0
+}
+
+// Where keeps the rows where column match all the terms (column must come from code, never from user),
+// an empty search keeps all rows.
+func (s Search) Where(db *gorm.DB, column string) *gorm.DB {
+	if s.Empty() {
+		return db
+	}
+
+	switch s.kind {
+	case postgresKind:
+		return db.Where(postgresVector(column)+" @@ to_tsquery('simple', ?)", s.query)
+	case mysqlKind:
+		return db.Where(mysqlMatch(column), s.query)
+	}
+
+	for _, term := range s.Terms {
+		db = db.Where("LOWER("+column+") LIKE ?", "%"+term+"%")
+	}
+	return db
+}
+
+// Order sorts by relevance then by order (only order is used in like mode).
+func (s Search) Order(db *gorm.DB, column string, order string) *gorm.DB {
+	if s.Empty() {
+		return db.Order(order)
+	}
+
+	var rankSQL string
+	switch s.kind {
+	case postgresKind:
+		rankSQL = "ts_rank(" + postgresVector(column) + ", to_tsquery('simple', ?)) desc"
+	case mysqlKind:
+		rankSQL = mysqlMatch(column) + " desc"
+	default:
+		return db.Order(order)
+	}
+
+	return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL: rankSQL + ", " + order, Vars: []any{s.query}, WithoutParentheses: true,
+	}})
+}
+
+// Snippet returns an html extract of text with the terms highlighted (empty without terms).
+func (s Search) Snippet(text string) string {
+	return clientcommon.BuildSnippet(text, s.Terms)
+}
+
+func postgresVector(column string) string {
+	// must be the same expression as in CreateSearchIndex to use the index
+	return "to_tsvector('simple', " + column + ")"
+}
+
+func mysqlMatch(column string) string {
+	return "MATCH(" + column + ") AGAINST (? IN BOOLEAN MODE)"
+}
+
+// helper for Migration.Up, create the index used by native search (nothing to do with other kinds)
+func CreateSearchIndex(model any, name string, column string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		switch tx.Dialector.Name() {
+		case postgresKind:
+			return tx.Exec(
+				"CREATE INDEX " + stmt.Quote(name) + " ON " + stmt.Quote(stmt.Schema.Table) + " USING GIN (" + postgresVector(stmt.Quote(column)) + ")",
+			).Error
+		case mysqlKind:
+			return tx.Exec(
+				"CREATE FULLTEXT INDEX " + stmt.Quote(name) + " ON " + stmt.Quote(stmt.Schema.Table) + " (" + stmt.Quote(column) + ")",
+			).Error
+		}
+		return nil
+	}
+}
+
+// helper for Migration.Down
+func DropSearchIndex(model any, name string) func(*gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if kind := tx.Dialector.Name(); kind != postgresKind && kind != mysqlKind {
+			return nil
+		}
+		return tx.Migrator().DropIndex(model, name)
+	}
+}
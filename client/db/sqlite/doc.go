@@ -0,0 +1,4 @@
+// Package sqlite is a copy of the gorm dialector gorm.io/driver/sqlite (v1.5.4, MIT License, see LICENSE)
+// working with the pure go driver modernc.org/sqlite instead of the cgo one github.com/mattn/go-sqlite3
+// (the two drivers can not be linked together, modernc.org/sqlite is already used by ServiceWeaver).
+package sqlite
@@ -0,0 +1,80 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fsclient
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	DefaultEmbedName = "default"
+
+	nameKey     = "name"
+	rootKey     = "root"
+	overrideKey = "override"
+)
+
+var errUnknownEmbed = errors.New("no embedded file system registered with this name")
+
+// EmbedFSs are the bundles usable with the "embed" kind, a main package can register
+// its go:embed assets here (before weaver.Run), the one under DefaultEmbedName is used
+// when the kind is empty.
+var EmbedFSs = map[string]fs.FS{}
+
+// Params : name (default to DefaultEmbedName), root (sub directory of the bundle)
+// and override (local directory layered over the bundle).
+func newEmbed(params map[string]string) (afero.Fs, error) {
+	name := params[nameKey]
+	if name == "" {
+		name = DefaultEmbedName
+	}
+	bundle, ok := EmbedFSs[name]
+	if !ok {
+		return nil, errUnknownEmbed
+	}
+
+	if root := cleanName(params[rootKey]); root != "" {
+		var err error
+		if bundle, err = fs.Sub(bundle, root); err != nil {
+			return nil, err
+		}
+	}
+
+	var res afero.Fs = afero.FromIOFS{FS: cleanedFS{inner: bundle}}
+	if override := params[overrideKey]; override != "" {
+		// a file missing in the override directory is read from the bundle
+		res = afero.NewCopyOnWriteFs(res, afero.NewBasePathFs(afero.NewOsFs(), override))
+	}
+	return res, nil
+}
+
+// io/fs names can not start with a "/" nor be empty
+type cleanedFS struct {
+	inner fs.FS
+}
+
+func (c cleanedFS) Open(name string) (fs.File, error) {
+	if name = cleanName(name); name == "" {
+		name = "."
+	}
+	return c.inner.Open(name)
+}
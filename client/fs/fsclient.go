@@ -19,24 +19,207 @@
 package fsclient
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/afero/tarfs"
+	"github.com/spf13/afero/zipfs"
 )
 
-var errUnknonwKind = errors.New("unknown file system kind")
+const (
+	pathKey        = "path"
+	compressionKey = "compression"
 
+	gzipCompression = "gzip"
+)
+
+var (
+	errUnknonwKind     = errors.New("unknown file system kind")
+	errMissingPath     = errors.New("missing path parameter")
+	errTooManyInner    = errors.New("this file system kind wraps at most one inner file system")
+	errOverlayLayers   = errors.New("overlay file system needs at least two inner file systems")
+	errInvalidTar      = errors.New("invalid tar archive")
+	errUnknownCompress = errors.New("unknown compression")
+)
+
+// Kinds :
+//   - "local" : the disk of the machine
+//   - "memory" : empty in memory file system (for tests)
+//   - "basepath" : Inner (or local) restricted to Params["path"]
+//   - "readonly" : Inner (or local) without write access
+//   - "overlay" : Inner[0] is the base, each following Inner is a layer over the previous ones (like a theme),
+//     writes go to the last layer
+//   - "zip" and "tar" : archive at Params["path"] read from Inner (or local), a tar could be compressed
+//     with Params["compression"] = "gzip" (the default for a path ending with .gz or .tgz)
+//   - "s3" : read only objects of a bucket, cached in memory (see newS3 for Params)
+//   - "embed" : bundle registered in EmbedFSs (see newEmbed for Params), an empty kind use the default bundle
 type FsConf struct {
 	Kind   string
 	Params map[string]string
+	Inner  []FsConf
 }
 
 func New(conf FsConf) (afero.Fs, error) {
-	// TODO add other kind
 	switch conf.Kind {
+	case "":
+		if _, ok := EmbedFSs[DefaultEmbedName]; !ok {
+			return nil, errUnknonwKind
+		}
+		return newEmbed(conf.Params)
 	case "local":
 		return afero.NewOsFs(), nil
+	case "memory":
+		return afero.NewMemMapFs(), nil
+	case "basepath":
+		path := conf.Params[pathKey]
+		if path == "" {
+			return nil, errMissingPath
+		}
+		inner, err := newInner(conf)
+		if err != nil {
+			return nil, err
+		}
+		return afero.NewBasePathFs(inner, path), nil
+	case "readonly":
+		inner, err := newInner(conf)
+		if err != nil {
+			return nil, err
+		}
+		return afero.NewReadOnlyFs(inner), nil
+	case "overlay":
+		return newOverlay(conf.Inner)
+	case "zip":
+		return newArchive(conf, openZip)
+	case "tar":
+		return newArchive(conf, openTar)
+	case "s3":
+		return newS3(conf.Params)
+	case "embed":
+		return newEmbed(conf.Params)
 	default:
 		return nil, errUnknonwKind
 	}
 }
+
+// default to local when there is no inner conf
+func newInner(conf FsConf) (afero.Fs, error) {
+	switch len(conf.Inner) {
+	case 0:
+		return afero.NewOsFs(), nil
+	case 1:
+		return New(conf.Inner[0])
+	}
+	return nil, errTooManyInner
+}
+
+func newOverlay(confs []FsConf) (afero.Fs, error) {
+	if len(confs) < 2 {
+		return nil, errOverlayLayers
+	}
+
+	res, err := New(confs[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, layerConf := range confs[1:] {
+		layer, err := New(layerConf)
+		if err != nil {
+			return nil, err
+		}
+		res = afero.NewCopyOnWriteFs(res, layer)
+	}
+	return res, nil
+}
+
+func newArchive(conf FsConf, opener func(afero.File, FsConf) (afero.Fs, error)) (afero.Fs, error) {
+	path := conf.Params[pathKey]
+	if path == "" {
+		return nil, errMissingPath
+	}
+	inner, err := newInner(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := inner.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return opener(file, conf)
+}
+
+// the file stay open, it is read on demand
+func openZip(file afero.File, conf FsConf) (afero.Fs, error) {
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return zipfs.New(reader), nil
+}
+
+// the archive is loaded in memory
+func openTar(file afero.File, conf FsConf) (afero.Fs, error) {
+	defer file.Close()
+
+	compression, ok := conf.Params[compressionKey]
+	if !ok {
+		if path := conf.Params[pathKey]; strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+			compression = gzipCompression
+		}
+	}
+
+	var reader io.Reader = file
+	switch compression {
+	case "":
+	case gzipCompression:
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	default:
+		return nil, errUnknownCompress
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	// tarfs stops silently on error, so the whole archive is checked first
+	if err = checkTar(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return tarfs.New(tar.NewReader(bytes.NewReader(data))), nil
+}
+
+func checkTar(reader io.Reader) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		_, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == nil {
+			// detect truncated content
+			_, err = io.Copy(io.Discard, tarReader)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidTar, err)
+		}
+	}
+}
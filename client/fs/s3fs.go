@@ -0,0 +1,325 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fsclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/spf13/afero"
+)
+
+const (
+	endpointKey     = "endpoint"
+	bucketKey       = "bucket"
+	prefixKey       = "prefix"
+	regionKey       = "region"
+	accessKeyKey    = "accessKey"
+	secretKeyKey    = "secretKey"
+	sessionTokenKey = "sessionToken"
+	secureKey       = "secure"
+	pathStyleKey    = "pathStyle"
+	cacheKey        = "cache"
+	timeoutKey      = "timeout"
+
+	cacheOff            = "off"
+	defaultCacheTime    = 5 * time.Minute
+	defaultS3OpTimeout  = 30 * time.Second
+	s3DirMode           = fs.ModeDir | 0555
+	s3FileMode          = 0444
+	s3NotFoundErrorCode = "NoSuchKey"
+)
+
+var errMissingS3Param = errors.New("s3 file system needs endpoint and bucket parameters")
+
+// read only access to the objects of a bucket under a prefix, the "/" in keys are seen as directories
+type s3FS struct {
+	client  *minio.Client
+	bucket  string
+	prefix  string
+	timeout time.Duration
+}
+
+// Params : endpoint, bucket, prefix, region, accessKey, secretKey, sessionToken (without accessKey,
+// credentials come from environment or IAM), secure ("false" for plain http), pathStyle ("true" for
+// most self hosted stand-in), cache (duration of the in memory copies before checking the object
+// modification, "0s" to keep them forever, "off" to disable, default 5m) and timeout (by call, default 30s).
+func newS3(params map[string]string) (afero.Fs, error) {
+	endpoint, bucket := params[endpointKey], params[bucketKey]
+	if endpoint == "" || bucket == "" {
+		return nil, errMissingS3Param
+	}
+
+	options := &minio.Options{Region: params[regionKey], Secure: params[secureKey] != "false"}
+	if accessKey := params[accessKeyKey]; accessKey == "" {
+		options.Creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{}, &credentials.EnvMinio{}, &credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		})
+	} else {
+		options.Creds = credentials.NewStaticV4(accessKey, params[secretKeyKey], params[sessionTokenKey])
+	}
+	if params[pathStyleKey] == "true" {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+
+	timeout, err := parseDurationParam(params, timeoutKey, defaultS3OpTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := minio.New(endpoint, options)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Trim(params[prefixKey], "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var res afero.Fs = afero.FromIOFS{FS: s3FS{client: client, bucket: bucket, prefix: prefix, timeout: timeout}}
+	if params[cacheKey] == cacheOff {
+		return res, nil
+	}
+
+	cacheTime, err := parseDurationParam(params, cacheKey, defaultCacheTime)
+	if err != nil {
+		return nil, err
+	}
+	return afero.NewCacheOnReadFs(res, afero.NewMemMapFs(), cacheTime), nil
+}
+
+func parseDurationParam(params map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := params[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// cleaned name relative to the prefix, empty for the root
+func cleanName(name string) string {
+	return path.Clean("/" + name)[1:]
+}
+
+func (s s3FS) Open(name string) (fs.File, error) {
+	info, err := s.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &s3Dir{fs: s, name: name, info: info}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.prefix+cleanName(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, convertS3Error("open", name, err)
+	}
+	defer object.Close()
+
+	// loaded in memory, the object must not stay linked to the context
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, convertS3Error("open", name, err)
+	}
+	return s3File{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+func (s s3FS) Stat(name string) (fs.FileInfo, error) {
+	return s.stat(name)
+}
+
+func (s s3FS) stat(name string) (s3FileInfo, error) {
+	cleaned := cleanName(name)
+	if cleaned == "" {
+		return s3FileInfo{name: ".", dir: true}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	objectInfo, err := s.client.StatObject(ctx, s.bucket, s.prefix+cleaned, minio.StatObjectOptions{})
+	if err == nil {
+		return s3FileInfo{name: path.Base(cleaned), size: objectInfo.Size, modTime: objectInfo.LastModified}, nil
+	}
+	if !isS3NotFound(err) {
+		return s3FileInfo{}, convertS3Error("stat", name, err)
+	}
+
+	// no object, it is a directory when some key use it as prefix
+	for objectInfo := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.prefix + cleaned + "/", MaxKeys: 1}) {
+		if objectInfo.Err != nil {
+			return s3FileInfo{}, convertS3Error("stat", name, objectInfo.Err)
+		}
+		return s3FileInfo{name: path.Base(cleaned), dir: true}, nil
+	}
+	return s3FileInfo{}, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (s s3FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	dirKey := s.prefix
+	if cleaned := cleanName(name); cleaned != "" {
+		dirKey += cleaned + "/"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var entries []fs.DirEntry
+	for objectInfo := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: dirKey}) {
+		if objectInfo.Err != nil {
+			return nil, convertS3Error("readdir", name, objectInfo.Err)
+		}
+
+		entryName := strings.TrimPrefix(objectInfo.Key, dirKey)
+		if entryName == "" {
+			// directory marker created by some tools
+			continue
+		}
+		if dirName, isDir := strings.CutSuffix(entryName, "/"); isDir {
+			entries = append(entries, fs.FileInfoToDirEntry(s3FileInfo{name: dirName, dir: true}))
+		} else {
+			entries = append(entries, fs.FileInfoToDirEntry(s3FileInfo{
+				name: entryName, size: objectInfo.Size, modTime: objectInfo.LastModified,
+			}))
+		}
+	}
+
+	slices.SortFunc(entries, func(a fs.DirEntry, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+func isS3NotFound(err error) bool {
+	errResponse := minio.ToErrorResponse(err)
+	return errResponse.StatusCode == http.StatusNotFound || errResponse.Code == s3NotFoundErrorCode
+}
+
+func convertS3Error(op string, name string, err error) error {
+	if isS3NotFound(err) {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i s3FileInfo) Name() string {
+	return i.name
+}
+
+func (i s3FileInfo) Size() int64 {
+	return i.size
+}
+
+func (i s3FileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return s3DirMode
+	}
+	return s3FileMode
+}
+
+func (i s3FileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i s3FileInfo) IsDir() bool {
+	return i.dir
+}
+
+func (i s3FileInfo) Sys() any {
+	return nil
+}
+
+// the embedded reader provide Read, ReadAt and Seek
+type s3File struct {
+	*bytes.Reader
+	info s3FileInfo
+}
+
+func (f s3File) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f s3File) Close() error {
+	return nil
+}
+
+type s3Dir struct {
+	fs      s3FS
+	name    string
+	info    s3FileInfo
+	entries []fs.DirEntry
+	loaded  bool
+}
+
+func (d *s3Dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *s3Dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *s3Dir) Close() error {
+	return nil
+}
+
+func (d *s3Dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.loaded = entries, true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	count = min(count, len(d.entries))
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+	return entries, nil
+}
@@ -0,0 +1,312 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fsclient
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const testBucket = "test-bucket"
+
+var testModTime = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+// minimal stand-in of a S3 server (path style), enough for HEAD, GET and ListObjectsV2 with delimiter
+type fakeS3 struct {
+	objects map[string][]byte
+}
+
+type fakeListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	KeyCount       int
+	MaxKeys        int
+	Delimiter      string
+	IsTruncated    bool
+	Contents       []fakeListContent
+	CommonPrefixes []fakeListPrefix
+}
+
+type fakeListContent struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+type fakeListPrefix struct {
+	Prefix string
+}
+
+func (f fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" {
+		f.list(w, r)
+		return
+	}
+
+	data, ok := f.objects[key]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, s3NotFoundErrorCode)
+		return
+	}
+	w.Header().Set("ETag", `"fake"`)
+	http.ServeContent(w, r, key, testModTime, bytes.NewReader(data))
+}
+
+func (f fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	result := fakeListResult{Name: testBucket, Prefix: prefix, MaxKeys: 1000, Delimiter: delimiter}
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	seen := map[string]bool{}
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if delimiter != "" {
+			if index := strings.Index(rest, delimiter); index >= 0 {
+				commonPrefix := prefix + rest[:index+len(delimiter)]
+				if !seen[commonPrefix] {
+					seen[commonPrefix] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, fakeListPrefix{Prefix: commonPrefix})
+				}
+				continue
+			}
+		}
+		result.Contents = append(result.Contents, fakeListContent{
+			Key: key, LastModified: testModTime.Format(time.RFC3339), ETag: `"fake"`,
+			Size: len(f.objects[key]), StorageClass: "STANDARD",
+		})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
+
+func newTestS3(t *testing.T, objects map[string][]byte, params map[string]string) afero.Fs {
+	t.Helper()
+
+	server := httptest.NewServer(fakeS3{objects: objects})
+	t.Cleanup(server.Close)
+
+	allParams := map[string]string{
+		endpointKey: strings.TrimPrefix(server.URL, "http://"), bucketKey: testBucket, regionKey: "us-east-1",
+		accessKeyKey: "access", secretKeyKey: "secret", secureKey: "false", pathStyleKey: "true", cacheKey: cacheOff,
+	}
+	for key, value := range params {
+		allParams[key] = value
+	}
+
+	res, err := newS3(allParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+var testObjects = map[string][]byte{
+	"site/index.html":          []byte("<html></html>"),
+	"site/static/style.css":    []byte("body {}"),
+	"site/static/img/logo.svg": []byte("<svg/>"),
+	"site/static/":             nil, // directory marker
+	"other/secret.txt":         []byte("hidden"),
+}
+
+func TestS3ReadFile(t *testing.T) {
+	s3 := newTestS3(t, testObjects, map[string]string{prefixKey: "/site/"})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		err      error
+	}{
+		{name: "root file", path: "index.html", expected: "<html></html>"},
+		{name: "nested file", path: "static/img/logo.svg", expected: "<svg/>"},
+		{name: "leading slash", path: "/static/style.css", expected: "body {}"},
+		{name: "missing file", path: "missing.html", err: fs.ErrNotExist},
+		{name: "outside prefix", path: "../other/secret.txt", err: fs.ErrNotExist},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := afero.ReadFile(s3, test.path)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestS3Stat(t *testing.T) {
+	s3 := newTestS3(t, testObjects, map[string]string{prefixKey: "site"})
+
+	tests := []struct {
+		name  string
+		path  string
+		dir   bool
+		size  int64
+		exist bool
+	}{
+		{name: "root", path: "/", dir: true, exist: true},
+		{name: "file", path: "index.html", size: 13, exist: true},
+		{name: "directory", path: "static", dir: true, exist: true},
+		{name: "implicit directory", path: "static/img", dir: true, exist: true},
+		{name: "missing", path: "static/missing"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := s3.Stat(test.path)
+			if !test.exist {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Fatalf("expected not exist error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.IsDir() != test.dir {
+				t.Errorf("expected dir %v, got %v", test.dir, info.IsDir())
+			}
+			if !test.dir && (info.Size() != test.size || !info.ModTime().Equal(testModTime)) {
+				t.Errorf("unexpected size %d or modification time %v", info.Size(), info.ModTime())
+			}
+		})
+	}
+}
+
+func TestS3ReadDir(t *testing.T) {
+	s3 := newTestS3(t, testObjects, map[string]string{prefixKey: "site"})
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{path: "/", expected: []string{"index.html", "static"}},
+		{path: "static", expected: []string{"img", "style.css"}},
+		{path: "static/img", expected: []string{"logo.svg"}},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			infos, err := afero.ReadDir(s3, test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0, len(infos))
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			if !slices.Equal(names, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestS3FS(t *testing.T) {
+	s3 := newTestS3(t, testObjects, map[string]string{prefixKey: "site"})
+
+	err := fstest.TestFS(afero.NewIOFS(s3), "index.html", "static/style.css", "static/img/logo.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3Cache(t *testing.T) {
+	objects := map[string][]byte{"page.html": []byte("first")}
+	s3 := newTestS3(t, objects, map[string]string{cacheKey: "1h"})
+
+	tests := []struct {
+		name     string
+		update   []byte
+		expected string
+	}{
+		{name: "first read", expected: "first"},
+		{name: "cached copy", update: []byte("second"), expected: "first"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.update != nil {
+				objects["page.html"] = test.update
+			}
+			data, err := afero.ReadFile(s3, "page.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestS3Params(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{name: "missing endpoint", params: map[string]string{bucketKey: testBucket}},
+		{name: "missing bucket", params: map[string]string{endpointKey: "localhost:9000"}},
+		{name: "wrong timeout", params: map[string]string{endpointKey: "localhost:9000", bucketKey: testBucket, timeoutKey: "soon"}},
+		{name: "wrong cache", params: map[string]string{endpointKey: "localhost:9000", bucketKey: testBucket, accessKeyKey: "a", cacheKey: "later"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newS3(test.params); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fsclient
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dvaumoron/puzzleweb/common"
+	"github.com/spf13/afero"
+)
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// Watch polls the files every interval (by stat, so it works with every kind) and calls reload
+// when one of them has changed, reload must keep the previous version when it returns an error.
+// The polling stops with ctx, a zero interval disables it.
+func Watch(ctx context.Context, logger *slog.Logger, fileSystem afero.Fs, interval time.Duration, paths []string, reload func() error) {
+	if interval <= 0 {
+		return
+	}
+
+	states := readStates(fileSystem, paths)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			newStates := readStates(fileSystem, paths)
+			if sameStates(states, newStates) {
+				continue
+			}
+			// retried only after a new change
+			states = newStates
+
+			if err := reload(); err != nil {
+				logger.Error("Failed to reload files, previous version kept", "paths", paths, common.ErrorKey, err)
+			} else {
+				logger.Info("Files reloaded", "paths", paths)
+			}
+		}
+	}()
+}
+
+func readStates(fileSystem afero.Fs, paths []string) []fileState {
+	states := make([]fileState, 0, len(paths))
+	for _, path := range paths {
+		var state fileState
+		if info, err := fileSystem.Stat(path); err == nil {
+			state = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func sameStates(states []fileState, newStates []fileState) bool {
+	for i, state := range states {
+		newState := newStates[i]
+		if state.exists != newState.exists || state.size != newState.size || !state.modTime.Equal(newState.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package mailclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	errUnknownKind     = errors.New("unknown mailer kind")
+	errMissingAddress  = errors.New("missing SMTP address")
+	errMissingFrom     = errors.New("missing sender address")
+	errStartTLSMissing = errors.New("the SMTP server does not offer STARTTLS")
+)
+
+// Kinds :
+//   - "smtp" : SMTP server at Address (a local stand-in like Mailpit or MailHog on "localhost:1025" works too),
+//     with STARTTLS when the server offers it (required when TLS is enabled) and PLAIN authentication when Username is set
+//   - "log" : the messages are only written in the log (for development)
+type Conf struct {
+	Kind    string
+	Address string
+	// sender of the messages
+	From     string
+	Username string
+	Password string
+	// connection in TLS from the start (usually on port 465) instead of STARTTLS
+	ImplicitTLS bool
+	TLS         tlsclient.Conf
+	// for a whole message (default 10s)
+	Timeout time.Duration
+}
+
+func (c Conf) Enabled() bool {
+	return c.Kind != ""
+}
+
+// Mailer sends plain text messages.
+type Mailer interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+	// releases the TLS certificates
+	Close()
+}
+
+// New returns nil when the conf is not enabled.
+func New(logger *slog.Logger, conf Conf) (Mailer, error) {
+	switch conf.Kind {
+	case "":
+		return nil, nil
+	case "log":
+		return logMailer{logger: logger}, nil
+	case "smtp":
+		return newSmtp(logger, conf)
+	}
+	return nil, errUnknownKind
+}
+
+type logMailer struct {
+	logger *slog.Logger
+}
+
+func (m logMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	m.logger.InfoContext(ctx, "Message not sent (log mailer)", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+func (m logMailer) Close() {}
+
+type smtpMailer struct {
+	address      string
+	host         string
+	from         *mail.Address
+	auth         smtp.Auth
+	implicitTLS  bool
+	requireTLS   bool
+	certificates *tlsclient.Certificates
+	timeout      time.Duration
+}
+
+func newSmtp(logger *slog.Logger, conf Conf) (smtpMailer, error) {
+	if conf.Address == "" {
+		return smtpMailer{}, errMissingAddress
+	}
+	host, _, err := net.SplitHostPort(conf.Address)
+	if err != nil {
+		return smtpMailer{}, err
+	}
+	if conf.From == "" {
+		return smtpMailer{}, errMissingFrom
+	}
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return smtpMailer{}, err
+	}
+
+	certificates, err := tlsclient.Load(logger, conf.TLS)
+	if err != nil {
+		return smtpMailer{}, err
+	}
+
+	var auth smtp.Auth
+	if conf.Username != "" {
+		// refused by net/smtp without TLS, except on localhost
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return smtpMailer{
+		address: conf.Address, host: host, from: from, auth: auth, implicitTLS: conf.ImplicitTLS,
+		requireTLS: conf.ImplicitTLS || conf.TLS.Enabled(), certificates: certificates, timeout: timeout,
+	}, nil
+}
+
+func (m smtpMailer) Close() {
+	tlsclient.Release(m.certificates)
+}
+
+func (m smtpMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	toAddress, err := mail.ParseAddress(to)
+	if err != nil {
+		return err
+	}
+	message, err := m.buildMessage(toAddress, subject, body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	if m.implicitTLS {
+		conn = tls.Client(conn, m.tlsConfig())
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !m.implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err = client.StartTLS(m.tlsConfig()); err != nil {
+				return err
+			}
+		} else if m.requireTLS {
+			return errStartTLSMissing
+		}
+	}
+	if m.auth != nil {
+		if err = client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err = client.Rcpt(toAddress.Address); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(message); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (m smtpMailer) tlsConfig() *tls.Config {
+	if tlsConfig := m.certificates.ClientConfig(m.host); tlsConfig != nil {
+		return tlsConfig
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, ServerName: m.host}
+}
+
+// the addresses come from mail.ParseAddress, so the headers can not be injected
+func (m smtpMailer) buildMessage(to *mail.Address, subject string, body string) ([]byte, error) {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "From: %s\r\n", m.from)
+	fmt.Fprintf(&builder, "To: %s\r\n", to)
+	fmt.Fprintf(&builder, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&builder, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&builder)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(builder.String()), nil
+}
@@ -0,0 +1,327 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package migrationclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	lockRetryDelay = 500 * time.Millisecond
+	lockWaitLimit  = 2 * time.Minute
+	// a lock not refreshed for this duration is considered abandoned by a crashed process
+	lockExpiration      = 2 * time.Minute
+	lockRefreshInterval = lockExpiration / 4
+)
+
+var (
+	ErrSchemaBehind    = errors.New("schema is behind, run the migrate mode")
+	errLockTimeout     = errors.New("timeout while waiting for migration lock")
+	errLockLost        = errors.New("migration lock lost (not refreshed in time)")
+	errDuplicateOrder  = errors.New("migration versions must be unique and strictly positive")
+	errMissingDownStep = errors.New("migration has no down step")
+)
+
+// Store keeps the applied versions and the locks (one by component) of a database kind.
+type Store interface {
+	// Prepare creates what the store needs (like tables), only called before a change
+	Prepare(ctx context.Context) error
+	// Applied is read only, so a component start does not create anything
+	Applied(ctx context.Context, component string) ([]Status, error)
+	// InsertLock returns false when the lock is owned by another process
+	InsertLock(ctx context.Context, component string, owner string, now time.Time) (bool, error)
+	DeleteExpiredLock(ctx context.Context, component string, limit time.Time) error
+	// RefreshLock returns false when the lock is no longer owned
+	RefreshLock(ctx context.Context, component string, owner string, now time.Time) (bool, error)
+	DeleteLock(ctx context.Context, component string, owner string) error
+}
+
+// Step runs the change of a migration and records (or removes) its version with the store.
+type Step struct {
+	Version uint64
+	Name    string
+	Up      func(context.Context) error
+	// nil when the migration can not be reverted
+	Down func(context.Context) error
+}
+
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// false for a migration applied by a newer binary
+	Known bool
+}
+
+// Engine applies the ordered steps of one component,
+// versions are compared to the ones recorded in the store.
+type Engine struct {
+	Component string
+	Steps     []Step
+	Store     Store
+}
+
+func (e Engine) sortedSteps() ([]Step, error) {
+	steps := make([]Step, len(e.Steps))
+	copy(steps, e.Steps)
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].Version < steps[j].Version
+	})
+
+	var previous uint64
+	for _, step := range steps {
+		if step.Version <= previous {
+			return nil, errDuplicateOrder
+		}
+		previous = step.Version
+	}
+	return steps, nil
+}
+
+func (e Engine) appliedVersions(ctx context.Context) (map[uint64]Status, error) {
+	applieds, err := e.Store.Applied(ctx, e.Component)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[uint64]Status, len(applieds))
+	for _, applied := range applieds {
+		res[applied.Version] = applied
+	}
+	return res, nil
+}
+
+// Check returns ErrSchemaBehind when some migration have not been applied.
+func (e Engine) Check(ctx context.Context) error {
+	statuses, err := e.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if !status.Applied {
+			return fmt.Errorf("%w (component %s, missing version %d)", ErrSchemaBehind, e.Component, status.Version)
+		}
+	}
+	return nil
+}
+
+func (e Engine) Status(ctx context.Context) ([]Status, error) {
+	steps, err := e.sortedSteps()
+	if err != nil {
+		return nil, err
+	}
+
+	applieds, err := e.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(steps))
+	for _, step := range steps {
+		applied, ok := applieds[step.Version]
+		statuses = append(statuses, Status{
+			Version: step.Version, Name: step.Name, Applied: ok, AppliedAt: applied.AppliedAt, Known: true,
+		})
+		delete(applieds, step.Version)
+	}
+
+	// the database could have been migrated by a newer binary
+	unknownIndex := len(statuses)
+	for version, applied := range applieds {
+		statuses = append(statuses, Status{
+			Version: version, Name: applied.Name, Applied: true, AppliedAt: applied.AppliedAt,
+		})
+	}
+	unknowns := statuses[unknownIndex:]
+	sort.Slice(unknowns, func(i, j int) bool {
+		return unknowns[i].Version < unknowns[j].Version
+	})
+	return statuses, nil
+}
+
+// Up applies all missing migrations in version order, returns the applied versions.
+func (e Engine) Up(ctx context.Context) (done []uint64, err error) {
+	steps, err := e.sortedSteps()
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := e.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if unlockErr := lock.release(ctx); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	// reload after lock to see the migrations applied by concurrent process
+	applieds, err := e.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range steps {
+		if _, ok := applieds[step.Version]; ok {
+			continue
+		}
+		if lock.lost.Load() {
+			return done, errLockLost
+		}
+
+		if err = step.Up(ctx); err != nil {
+			return done, fmt.Errorf("migration %d (%s) of %s failed : %w", step.Version, step.Name, e.Component, err)
+		}
+		done = append(done, step.Version)
+	}
+	return done, nil
+}
+
+// Down reverts the last applied migrations (at most count), returns the reverted versions.
+func (e Engine) Down(ctx context.Context, count int) (done []uint64, err error) {
+	steps, err := e.sortedSteps()
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := e.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if unlockErr := lock.release(ctx); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	applieds, err := e.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(steps) - 1; i >= 0 && len(done) < count; i-- {
+		step := steps[i]
+		if _, ok := applieds[step.Version]; !ok {
+			continue
+		}
+		if lock.lost.Load() {
+			return done, errLockLost
+		}
+		if step.Down == nil {
+			return done, fmt.Errorf("%w (component %s, version %d)", errMissingDownStep, e.Component, step.Version)
+		}
+
+		if err = step.Down(ctx); err != nil {
+			return done, fmt.Errorf("revert of migration %d (%s) of %s failed : %w", step.Version, step.Name, e.Component, err)
+		}
+		done = append(done, step.Version)
+	}
+	return done, nil
+}
+
+type migrationLock struct {
+	store     Store
+	component string
+	owner     string
+	lost      atomic.Bool
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func (e Engine) lock(ctx context.Context) (*migrationLock, error) {
+	if err := e.Store.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	ownerBytes := make([]byte, 16)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(ownerBytes)
+
+	deadline := time.Now().Add(lockWaitLimit)
+	for {
+		now := time.Now()
+		acquired, err := e.Store.InsertLock(ctx, e.Component, owner, now)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		// clean abandoned lock, the insertion will be retried
+		if err = e.Store.DeleteExpiredLock(ctx, e.Component, now.Add(-lockExpiration)); err != nil {
+			return nil, err
+		}
+
+		if now.After(deadline) {
+			return nil, errLockTimeout
+		}
+		time.Sleep(lockRetryDelay)
+	}
+
+	lock := &migrationLock{
+		store: e.Store, component: e.Component, owner: owner, stop: make(chan struct{}), stopped: make(chan struct{}),
+	}
+	go lock.refresh(context.WithoutCancel(ctx))
+	return lock, nil
+}
+
+// a long migration keeps its lock, lost is set when another process has taken it
+func (l *migrationLock) refresh(ctx context.Context) {
+	defer close(l.stopped)
+
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			held, err := l.store.RefreshLock(ctx, l.component, l.owner, now)
+			// a transient error is retried at the next tick (before the expiration)
+			if err == nil && !held {
+				l.lost.Store(true)
+				return
+			}
+		}
+	}
+}
+
+// only remove the lock of this owner
+func (l *migrationLock) release(ctx context.Context) error {
+	close(l.stop)
+	<-l.stopped
+	err := l.store.DeleteLock(ctx, l.component, l.owner)
+	if err == nil && l.lost.Load() {
+		err = errLockLost
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// create the missing indexes at component start
+	IndexEnsure = "ensure"
+	// fail at component start when an index is missing (for a user without createIndex right)
+	IndexCheck = "check"
+)
+
+var (
+	ErrMissingIndexes   = errors.New("missing mongo indexes, run the indexes mode")
+	errUnknownIndexMode = errors.New("unknown index mode")
+)
+
+// Indexes are the ones required by the collection of a component,
+// they carry the uniqueness the component rely on.
+type Indexes struct {
+	Component  string
+	Collection string
+	Models     []mongo.IndexModel
+}
+
+func Index(name string, keys ...string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: ascendingKeys(keys), Options: options.Index().SetName(name)}
+}
+
+func UniqueIndex(name string, keys ...string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: ascendingKeys(keys), Options: options.Index().SetName(name).SetUnique(true)}
+}
+
+// a collection can have only one text index, it should contain all searchable keys
+func TextIndex(name string, keys ...string) mongo.IndexModel {
+	indexKeys := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		indexKeys = append(indexKeys, bson.E{Key: key, Value: "text"})
+	}
+	return mongo.IndexModel{Keys: indexKeys, Options: options.Index().SetName(name)}
+}
+
+func ascendingKeys(keys []string) bson.D {
+	indexKeys := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		indexKeys = append(indexKeys, bson.E{Key: key, Value: 1})
+	}
+	return indexKeys
+}
+
+// CreateIndexes does nothing for the already existing indexes.
+func CreateIndexes(ctx context.Context, collection *mongo.Collection, models ...mongo.IndexModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+	_, err := collection.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+// With returns a copy with more models (like the optional text index).
+func (i Indexes) With(models ...mongo.IndexModel) Indexes {
+	i.Models = append(slices.Clip(i.Models), models...)
+	return i
+}
+
+// Apply ensures or checks the indexes depending on mode (empty means IndexEnsure).
+func (i Indexes) Apply(ctx context.Context, database *mongo.Database, mode string) error {
+	switch strings.ToLower(mode) {
+	case "", IndexEnsure:
+		return i.Ensure(ctx, database)
+	case IndexCheck:
+		return i.Check(ctx, database)
+	}
+	return errUnknownIndexMode
+}
+
+func (i Indexes) Ensure(ctx context.Context, database *mongo.Database) error {
+	return CreateIndexes(ctx, database.Collection(i.Collection), i.Models...)
+}
+
+// Check returns ErrMissingIndexes when some index does not exist.
+func (i Indexes) Check(ctx context.Context, database *mongo.Database) error {
+	missing, err := i.Missing(ctx, database)
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%w (component %s, collection %s : %s)", ErrMissingIndexes, i.Component, i.Collection, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Missing returns the names of the indexes absent from the collection
+// (an index with the same name but other keys or uniqueness is also reported).
+func (i Indexes) Missing(ctx context.Context, database *mongo.Database) ([]string, error) {
+	cursor, err := database.Collection(i.Collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var existings []bson.M
+	if err = cursor.All(ctx, &existings); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, model := range i.Models {
+		name := *model.Options.Name
+		if !slices.ContainsFunc(existings, func(existing bson.M) bool {
+			return sameIndex(existing, name, model)
+		}) {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+func sameIndex(existing bson.M, name string, model mongo.IndexModel) bool {
+	if existingName, _ := existing["name"].(string); existingName != name {
+		return false
+	}
+
+	unique, _ := existing["unique"].(bool)
+	if wanted := model.Options.Unique != nil && *model.Options.Unique; unique != wanted {
+		return false
+	}
+
+	// a text index is listed with internal keys (_fts and _ftsx), only its name is compared
+	keys := model.Keys.(bson.D)
+	if len(keys) != 0 && keys[0].Value == "text" {
+		return true
+	}
+
+	existingKeys, _ := existing["key"].(bson.M)
+	if len(existingKeys) != len(keys) {
+		return false
+	}
+	for _, key := range keys {
+		value, ok := existingKeys[key.Key]
+		if !ok || fmt.Sprint(value) != fmt.Sprint(key.Value) {
+			return false
+		}
+	}
+	return true
+}
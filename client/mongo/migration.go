@@ -0,0 +1,197 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongoclient
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	migrationclient "github.com/dvaumoron/puzzleweaver/client/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	migrationsCollection     = "schema_migrations"
+	migrationLocksCollection = "schema_migration_locks"
+
+	documentIdKey = "_id"
+	componentKey  = "component"
+	versionKey    = "version"
+	nameKey       = "name"
+	appliedAtKey  = "appliedAt"
+	ownerKey      = "owner"
+	lockedAtKey   = "lockedAt"
+)
+
+var ErrSchemaBehind = migrationclient.ErrSchemaBehind
+
+// there is no transaction, so the steps should be idempotent
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      func(context.Context, *mongo.Database) error
+	Down    func(context.Context, *mongo.Database) error
+}
+
+type MigrationStatus = migrationclient.Status
+
+// Migrations are the ordered data changes of one component,
+// versions are compared to the ones recorded in the migrations collection.
+type Migrations struct {
+	Component string
+	Steps     []Migration
+}
+
+func (m Migrations) recordId(version uint64) string {
+	return m.Component + ":" + strconv.FormatUint(version, 10)
+}
+
+// each step is followed by the record of its version
+func (m Migrations) engine(database *mongo.Database) migrationclient.Engine {
+	migrations := database.Collection(migrationsCollection)
+	steps := make([]migrationclient.Step, 0, len(m.Steps))
+	for _, migration := range m.Steps {
+		migration := migration
+		step := migrationclient.Step{Version: migration.Version, Name: migration.Name, Up: func(ctx context.Context) error {
+			if err := migration.Up(ctx, database); err != nil {
+				return err
+			}
+			_, err := migrations.InsertOne(ctx, bson.D{
+				{Key: documentIdKey, Value: m.recordId(migration.Version)}, {Key: componentKey, Value: m.Component},
+				{Key: versionKey, Value: migration.Version}, {Key: nameKey, Value: migration.Name}, {Key: appliedAtKey, Value: time.Now()},
+			})
+			return err
+		}}
+		if migration.Down != nil {
+			step.Down = func(ctx context.Context) error {
+				if err := migration.Down(ctx, database); err != nil {
+					return err
+				}
+				_, err := migrations.DeleteOne(ctx, bson.D{{Key: documentIdKey, Value: m.recordId(migration.Version)}})
+				return err
+			}
+		}
+		steps = append(steps, step)
+	}
+	return migrationclient.Engine{Component: m.Component, Steps: steps, Store: migrationStore{database: database}}
+}
+
+// Check returns ErrSchemaBehind when some migration have not been applied.
+func (m Migrations) Check(ctx context.Context, database *mongo.Database) error {
+	return m.engine(database).Check(ctx)
+}
+
+func (m Migrations) Status(ctx context.Context, database *mongo.Database) ([]MigrationStatus, error) {
+	return m.engine(database).Status(ctx)
+}
+
+// Up applies all missing migrations in version order, returns the applied versions.
+func (m Migrations) Up(ctx context.Context, database *mongo.Database) ([]uint64, error) {
+	return m.engine(database).Up(ctx)
+}
+
+// Down reverts the last applied migrations (at most count), returns the reverted versions.
+func (m Migrations) Down(ctx context.Context, database *mongo.Database, count int) ([]uint64, error) {
+	return m.engine(database).Down(ctx, count)
+}
+
+// the _id of the lock documents ensure only one process own them
+type migrationStore struct {
+	database *mongo.Database
+}
+
+// collections are created on first write
+func (s migrationStore) Prepare(context.Context) error {
+	return nil
+}
+
+func (s migrationStore) Applied(ctx context.Context, component string) ([]MigrationStatus, error) {
+	cursor, err := s.database.Collection(migrationsCollection).Find(ctx, bson.D{{Key: componentKey, Value: component}})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	res := make([]MigrationStatus, 0, len(results))
+	for _, result := range results {
+		name, _ := result[nameKey].(string)
+		res = append(res, MigrationStatus{
+			Version: ExtractUint64(result[versionKey]), Name: name, Applied: true, AppliedAt: ExtractDate(result[appliedAtKey]),
+		})
+	}
+	return res, nil
+}
+
+func (s migrationStore) InsertLock(ctx context.Context, component string, owner string, now time.Time) (bool, error) {
+	_, err := s.database.Collection(migrationLocksCollection).InsertOne(ctx, bson.D{
+		{Key: documentIdKey, Value: component}, {Key: ownerKey, Value: owner}, {Key: lockedAtKey, Value: now},
+	})
+	if err == nil {
+		return true, nil
+	}
+	// only a lock owned by another process means waiting
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s migrationStore) DeleteExpiredLock(ctx context.Context, component string, limit time.Time) error {
+	_, err := s.database.Collection(migrationLocksCollection).DeleteOne(ctx, bson.D{
+		{Key: documentIdKey, Value: component}, {Key: lockedAtKey, Value: bson.D{{Key: "$lt", Value: limit}}},
+	})
+	return err
+}
+
+func (s migrationStore) RefreshLock(ctx context.Context, component string, owner string, now time.Time) (bool, error) {
+	result, err := s.database.Collection(migrationLocksCollection).UpdateOne(ctx, bson.D{
+		{Key: documentIdKey, Value: component}, {Key: ownerKey, Value: owner},
+	}, bson.D{{Key: "$set", Value: bson.D{{Key: lockedAtKey, Value: now}}}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount != 0, nil
+}
+
+func (s migrationStore) DeleteLock(ctx context.Context, component string, owner string) error {
+	_, err := s.database.Collection(migrationLocksCollection).DeleteOne(ctx, bson.D{
+		{Key: documentIdKey, Value: component}, {Key: ownerKey, Value: owner},
+	})
+	return err
+}
+
+// helper for Migration.Up
+func SeedSequence(name string, collectionName string, scopeKey string, idKey string) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, database *mongo.Database) error {
+		return NewSequence(database, name).Seed(ctx, database.Collection(collectionName), scopeKey, idKey)
+	}
+}
+
+// helper for Migration.Down
+func DropSequence(name string) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, database *mongo.Database) error {
+		return NewSequence(database, name).Drop(ctx)
+	}
+}
@@ -21,27 +21,295 @@ package mongoclient
 import (
 	"context"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	"github.com/dvaumoron/puzzleweb/common"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
-func New(serverAddress string) *options.ClientOptions {
+// Options completes the address (zero values keep the driver or URI settings).
+type Options struct {
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	HeartbeatInterval      time.Duration
+	// by operation
+	Timeout time.Duration
+	// primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	ReadPreference string
+	// "majority" or a number of nodes
+	WriteConcern string
+	// ping of the shared client, its failures and recoveries are logged
+	// (default 1m, negative to disable)
+	HealthCheckInterval time.Duration
+}
+
+type clientKey struct {
+	address      string
+	options      Options
+	certificates *tlsclient.Certificates
+}
+
+const defaultHealthCheckInterval = time.Minute
+
+// ready is closed once the client is connected (client is set) or its connection has failed (err is set)
+type sharedClient struct {
+	client      *mongo.Client
+	err         error
+	ready       chan struct{}
+	users       int
+	stopHealth  context.CancelFunc
+	healthEnded chan struct{}
+}
+
+var (
+	clientsMutex sync.Mutex
+	clients      = map[clientKey]*sharedClient{}
+)
+
+// certificates can be nil (TLS is then driven by the address)
+func New(serverAddress string, conf Options, certificates *tlsclient.Certificates) (*options.ClientOptions, error) {
 	clientOptions := options.Client()
 	clientOptions.Monitor = otelmongo.NewMonitor()
 	clientOptions.ApplyURI(serverAddress)
-	return clientOptions
+
+	if conf.MaxPoolSize != 0 {
+		clientOptions.SetMaxPoolSize(conf.MaxPoolSize)
+	}
+	if conf.MinPoolSize != 0 {
+		clientOptions.SetMinPoolSize(conf.MinPoolSize)
+	}
+	if conf.MaxConnIdleTime != 0 {
+		clientOptions.SetMaxConnIdleTime(conf.MaxConnIdleTime)
+	}
+	if conf.ConnectTimeout != 0 {
+		clientOptions.SetConnectTimeout(conf.ConnectTimeout)
+	}
+	if conf.ServerSelectionTimeout != 0 {
+		clientOptions.SetServerSelectionTimeout(conf.ServerSelectionTimeout)
+	}
+	if conf.HeartbeatInterval != 0 {
+		clientOptions.SetHeartbeatInterval(conf.HeartbeatInterval)
+	}
+	if conf.Timeout != 0 {
+		clientOptions.SetTimeout(conf.Timeout)
+	}
+	if conf.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(conf.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		readPreference, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetReadPreference(readPreference)
+	}
+	if conf.WriteConcern != "" {
+		clientOptions.SetWriteConcern(parseWriteConcern(conf.WriteConcern))
+	}
+	if certificates != nil {
+		// the driver set the server name of each host
+		clientOptions.SetTLSConfig(certificates.ClientConfig(""))
+	}
+	return clientOptions, clientOptions.Validate()
 }
 
-func Disconnect(client *mongo.Client, ctx context.Context, logger *slog.Logger) {
-	if err := client.Disconnect(ctx); err != nil {
-		logger.Error("Error during MongoDB disconnect", common.ErrorKey, err)
+func parseWriteConcern(value string) *writeconcern.WriteConcern {
+	if nodes, err := strconv.Atoi(value); err == nil {
+		return &writeconcern.WriteConcern{W: nodes}
+	}
+	if strings.EqualFold(value, "majority") {
+		return writeconcern.Majority()
 	}
+	// custom tag set
+	return &writeconcern.WriteConcern{W: value}
+}
+
+// Connect returns the client shared by all the callers with the same address and options,
+// it is created and checked (with a ping) at the first call, then checked periodically.
+// Each call must be paired with a call to Release.
+// The connection is done outside the lock, the other callers with the same key wait for it.
+func Connect(ctx context.Context, logger *slog.Logger, serverAddress string, conf Options, certificates *tlsclient.Certificates) (*mongo.Client, error) {
+	key := clientKey{address: serverAddress, options: conf, certificates: certificates}
+
+	clientsMutex.Lock()
+	if shared, ok := clients[key]; ok {
+		shared.users++
+		clientsMutex.Unlock()
+		return shared.wait(ctx)
+	}
+
+	shared := &sharedClient{ready: make(chan struct{}), users: 1}
+	clients[key] = shared
+	clientsMutex.Unlock()
+
+	client, err := connect(ctx, serverAddress, conf, certificates)
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	if err != nil {
+		// the next call will try again
+		delete(clients, key)
+		shared.err = err
+		close(shared.ready)
+		return nil, err
+	}
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	shared.client, shared.stopHealth, shared.healthEnded = client, stopHealth, make(chan struct{})
+	close(shared.ready)
+
+	interval := conf.HealthCheckInterval
+	if interval == 0 {
+		interval = defaultHealthCheckInterval
+	}
+	go shared.checkHealth(healthCtx, logger, interval)
+	return client, nil
+}
+
+func connect(ctx context.Context, serverAddress string, conf Options, certificates *tlsclient.Certificates) (*mongo.Client, error) {
+	clientOptions, err := New(serverAddress, conf, certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, err
+	}
+	return client, nil
+}
+
+// the caller has already been counted as a user
+func (s *sharedClient) wait(ctx context.Context) (*mongo.Client, error) {
+	select {
+	case <-s.ready:
+		return s.client, s.err
+	case <-ctx.Done():
+	}
+
+	// the connection can end at the same time, so Release is used when the client is there
+	clientsMutex.Lock()
+	client := s.client
+	if client == nil {
+		s.users--
+	}
+	clientsMutex.Unlock()
+	if client != nil {
+		Release(context.WithoutCancel(ctx), client)
+	}
+	return nil, ctx.Err()
+}
+
+func (s *sharedClient) checkHealth(ctx context.Context, logger *slog.Logger, interval time.Duration) {
+	defer close(s.healthEnded)
+	if interval < 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		err := s.client.Ping(pingCtx, nil)
+		cancel()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil && healthy:
+			healthy = false
+			logger.Error("MongoDB health check failed", common.ErrorKey, err)
+		case err == nil && !healthy:
+			healthy = true
+			logger.Info("MongoDB health check succeeded again")
+		}
+	}
+}
+
+// Release disconnects the shared client when its last user releases it.
+func Release(ctx context.Context, client *mongo.Client) error {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	for key, shared := range clients {
+		if shared.client != client {
+			continue
+		}
+
+		if shared.users--; shared.users > 0 {
+			return nil
+		}
+		delete(clients, key)
+		return shared.close(ctx)
+	}
+	return nil
+}
+
+// DisconnectAll closes the shared clients, for the commands of the binary.
+func DisconnectAll(ctx context.Context, logger *slog.Logger) {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	for key, shared := range clients {
+		// a connection in progress is not shared yet
+		if shared.client == nil {
+			continue
+		}
+		if err := shared.close(ctx); err != nil {
+			logger.Error("Error during MongoDB disconnect", common.ErrorKey, err)
+		}
+		delete(clients, key)
+	}
+}
+
+func (s *sharedClient) close(ctx context.Context) error {
+	s.stopHealth()
+	<-s.healthEnded
+	return s.client.Disconnect(ctx)
+}
+
+// KeysetPaginate adds to filters the condition to be after the cursor position on the idKey field
+// (which must be increasing with creation), the limit is size + 1 to detect a following page (see clientcommon.BuildPage).
+// Unlike the SQL variant, the order is on the id alone : the ids given by a Sequence are unique and follow
+// the insertions in their scope, so with filters limited to a scope the id order is the (created_at, id) one
+// (the creation date read from _id has only a precision of one second and is set by the client).
+func KeysetPaginate(filters bson.D, idKey string, cursor clientcommon.Cursor, size uint64, desc bool) (bson.D, *options.FindOptions) {
+	// a backward cursor reverse the reading order
+	order, comparator := 1, "$gt"
+	if desc != cursor.Backward {
+		order, comparator = -1, "$lt"
+	}
+
+	if !cursor.First {
+		filters = append(filters, bson.E{Key: idKey, Value: bson.D{{Key: comparator, Value: cursor.Id}}})
+	}
+	return filters, options.Find().SetSort(bson.D{{Key: idKey, Value: order}}).SetLimit(int64(size + 1))
 }
 
 func ExtractCreateDate(doc bson.M) time.Time {
@@ -59,6 +327,11 @@ func ExtractUint64(value any) uint64 {
 	return 0
 }
 
+func ExtractDate(value any) time.Time {
+	date, _ := value.(primitive.DateTime)
+	return date.Time()
+}
+
 func ExtractBinary(value any) []byte {
 	binary, _ := value.(primitive.Binary)
 	return binary.Data
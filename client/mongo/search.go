@@ -0,0 +1,115 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongoclient
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// use the text index of the collection (need an index created with TextIndex)
+	SearchText = "text"
+	// portable mode, can not use an index
+	SearchRegex = "regex"
+
+	textScoreKey = "score"
+)
+
+var errUnknownSearchMode = errors.New("unknown search mode")
+
+// Searcher translates user filters to the search mode chosen for a collection.
+type Searcher struct {
+	regex bool
+}
+
+func NewSearcher(mode string) (Searcher, error) {
+	switch strings.ToLower(mode) {
+	case "", SearchText:
+		return Searcher{}, nil
+	case SearchRegex:
+		return Searcher{regex: true}, nil
+	}
+	return Searcher{}, errUnknownSearchMode
+}
+
+func (s Searcher) Regex() bool {
+	return s.regex
+}
+
+// Search is a parsed filter, the terms contain only letters and digits,
+// so they can not carry any operator of the $text or $regex syntax.
+type Search struct {
+	regex bool
+	Terms []string
+}
+
+func (s Searcher) Parse(filter string) Search {
+	return Search{regex: s.regex, Terms: clientcommon.SplitSearchTerms(filter)}
+}
+
+func (s Search) Empty() bool {
+	return len(s.Terms) == 0
+}
+
+// Filter adds to filters the condition to match all the terms in one of the keys
+// (in text mode, the keys are the ones of the text index).
+func (s Search) Filter(filters bson.D, keys ...string) bson.D {
+	if s.Empty() {
+		return filters
+	}
+
+	if !s.regex {
+		// quoted terms are all mandatory
+		quoted := make([]string, 0, len(s.Terms))
+		for _, term := range s.Terms {
+			quoted = append(quoted, "\""+term+"\"")
+		}
+		return append(filters, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: strings.Join(quoted, " ")}}})
+	}
+
+	termConditions := make(bson.A, 0, len(s.Terms))
+	for _, term := range s.Terms {
+		keyConditions := make(bson.A, 0, len(keys))
+		for _, key := range keys {
+			keyConditions = append(keyConditions, bson.D{{Key: key, Value: bson.D{
+				{Key: "$regex", Value: regexp.QuoteMeta(term)}, {Key: "$options", Value: "i"},
+			}}})
+		}
+		termConditions = append(termConditions, bson.D{{Key: "$or", Value: keyConditions}})
+	}
+	return append(filters, bson.E{Key: "$and", Value: termConditions})
+}
+
+// Sort puts the relevance before the sort (only sort is used in regex mode).
+func (s Search) Sort(sort bson.D) bson.D {
+	if s.Empty() || s.regex {
+		return sort
+	}
+	return append(bson.D{{Key: textScoreKey, Value: bson.D{{Key: "$meta", Value: "textScore"}}}}, sort...)
+}
+
+// Snippet returns an html extract of text with the terms highlighted (empty without terms).
+func (s Search) Snippet(text string) string {
+	return clientcommon.BuildSnippet(text, s.Terms)
+}
@@ -0,0 +1,109 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mongoclient
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	countersCollection = "counters"
+	counterValueKey    = "value"
+	// concurrent upserts of a new counter can collide on _id
+	maxUpsertRetry = 3
+)
+
+var optsIncrementCounter = options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(
+	bson.D{{Key: counterValueKey, Value: true}},
+)
+
+// Sequence allocates increasing ids in scopes (like the posts of a blog),
+// each scope has its counter document in the counters collection.
+type Sequence struct {
+	counters *mongo.Collection
+	name     string
+}
+
+func NewSequence(database *mongo.Database, name string) Sequence {
+	return Sequence{counters: database.Collection(countersCollection), name: name}
+}
+
+func (s Sequence) counterId(scope uint64) string {
+	return s.name + ":" + strconv.FormatUint(scope, 10)
+}
+
+// Next returns a new id, unique in the scope (the first one is 1).
+func (s Sequence) Next(ctx context.Context, scope uint64) (uint64, error) {
+	filter := bson.D{{Key: documentIdKey, Value: s.counterId(scope)}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: counterValueKey, Value: int64(1)}}}}
+
+	var err error
+	for i := 0; i < maxUpsertRetry; i++ {
+		var result bson.M
+		if err = s.counters.FindOneAndUpdate(ctx, filter, update, optsIncrementCounter).Decode(&result); err == nil {
+			return ExtractUint64(result[counterValueKey]), nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	return 0, err
+}
+
+// Seed raises the counters to the greatest id already used in each scope of collection,
+// it never lowers a counter so it can be applied again safely.
+func (s Sequence) Seed(ctx context.Context, collection *mongo.Collection, scopeKey string, idKey string) error {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{{{Key: "$group", Value: bson.D{
+		{Key: documentIdKey, Value: "$" + scopeKey}, {Key: "maxId", Value: bson.D{{Key: "$max", Value: "$" + idKey}}},
+	}}}})
+	if err != nil {
+		return err
+	}
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return err
+	}
+
+	optsUpsert := options.Update().SetUpsert(true)
+	for _, result := range results {
+		maxId := int64(ExtractUint64(result["maxId"]))
+		_, err = s.counters.UpdateOne(ctx, bson.D{{Key: documentIdKey, Value: s.counterId(ExtractUint64(result[documentIdKey]))}},
+			bson.D{{Key: "$max", Value: bson.D{{Key: counterValueKey, Value: maxId}}}}, optsUpsert,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drop removes all the counters of the sequence.
+func (s Sequence) Drop(ctx context.Context) error {
+	_, err := s.counters.DeleteMany(ctx, bson.D{{Key: documentIdKey, Value: bson.D{
+		{Key: "$regex", Value: "^" + regexp.QuoteMeta(s.name+":")},
+	}}})
+	return err
+}
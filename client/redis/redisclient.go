@@ -19,14 +19,96 @@
 package redisclient
 
 import (
+	"errors"
 	"log/slog"
+	"strings"
+	"time"
 
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-func New(logger *slog.Logger, options *redis.Options) (*redis.Client, error) {
-	rdb := redis.NewClient(options)
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+var (
+	errUnknownMode     = errors.New("unknown redis mode")
+	errMissingAddress  = errors.New("missing redis address")
+	errMissingMaster   = errors.New("sentinel mode needs the name of the master")
+	errClusterDatabase = errors.New("cluster mode only supports the database 0")
+)
+
+// Options completes the address (zero values keep the go-redis defaults).
+type Options struct {
+	// "standalone" (default), "sentinel" or "cluster"
+	Mode string
+	// other sentinels or cluster nodes, the main address is always used
+	Addresses []string
+	// master monitored by the sentinels
+	MasterName       string
+	SentinelUser     string
+	SentinelPassword string
+	// cluster only, read only commands are sent to the nearest node (replicas included)
+	ReplicaReads bool
+	PoolSize     int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// New returns a client for the mode chosen in conf, with the tracing and the metrics instrumentation,
+// certificates is nil without TLS.
+func New(logger *slog.Logger, address string, user string, password string, dbNum int, conf Options, certificates *tlsclient.Certificates) (redis.UniversalClient, error) {
+	addresses := make([]string, 0, len(conf.Addresses)+1)
+	if address != "" {
+		addresses = append(addresses, address)
+	}
+	addresses = append(addresses, conf.Addresses...)
+	if len(addresses) == 0 {
+		return nil, errMissingAddress
+	}
+
+	// the name sent by the driver is the host of each address
+	tlsConfig := certificates.ClientConfig("")
+
+	mode := strings.ToLower(conf.Mode)
+	if mode == "" {
+		mode = ModeStandalone
+	}
+
+	var rdb redis.UniversalClient
+	switch mode {
+	case ModeStandalone:
+		rdb = redis.NewClient(&redis.Options{
+			Addr: addresses[0], Username: user, Password: password, DB: dbNum, TLSConfig: tlsConfig,
+			PoolSize: conf.PoolSize, DialTimeout: conf.DialTimeout, ReadTimeout: conf.ReadTimeout, WriteTimeout: conf.WriteTimeout,
+		})
+	case ModeSentinel:
+		if conf.MasterName == "" {
+			return nil, errMissingMaster
+		}
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName: conf.MasterName, SentinelAddrs: addresses, SentinelUsername: conf.SentinelUser,
+			SentinelPassword: conf.SentinelPassword, Username: user, Password: password, DB: dbNum, TLSConfig: tlsConfig,
+			PoolSize: conf.PoolSize, DialTimeout: conf.DialTimeout, ReadTimeout: conf.ReadTimeout, WriteTimeout: conf.WriteTimeout,
+		})
+	case ModeCluster:
+		if dbNum != 0 {
+			return nil, errClusterDatabase
+		}
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs: addresses, Username: user, Password: password, TLSConfig: tlsConfig,
+			ReadOnly: conf.ReplicaReads, RouteByLatency: conf.ReplicaReads,
+			PoolSize: conf.PoolSize, DialTimeout: conf.DialTimeout, ReadTimeout: conf.ReadTimeout, WriteTimeout: conf.WriteTimeout,
+		})
+	default:
+		return nil, errUnknownMode
+	}
+	logger.Info("Redis client created", "mode", mode, "addresses", addresses)
 
 	// Enable tracing instrumentation.
 	if err := redisotel.InstrumentTracing(rdb); err != nil {
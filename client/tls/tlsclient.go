@@ -0,0 +1,254 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package tlsclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	fsclient "github.com/dvaumoron/puzzleweaver/client/fs"
+	"github.com/spf13/afero"
+)
+
+var (
+	errInvalidCA         = errors.New("no certificate found in the CA file")
+	errMissingKeyPair    = errors.New("client certificate and key files must be given together")
+	errMissingServerName = errors.New("no server name to verify the certificate, set ServerName")
+	errNoPeerCertificate = errors.New("server sent no certificate")
+)
+
+// Conf is the TLS block shared by the backing stores.
+type Conf struct {
+	// TLS with the system authorities, implied by the other fields
+	Enable bool
+	// where the files are read (local when Kind is empty)
+	Fs fsclient.FsConf
+	// PEM bundle of the trusted authorities (default to the system ones)
+	CAFile string
+	// PEM client certificate and key (mutual TLS)
+	CertFile string
+	KeyFile  string
+	// name checked in the server certificate (default to the host of the address,
+	// needed when the address is an IP with some stores)
+	ServerName string
+	// polling of the files to follow their rotation, zero disables it
+	ReloadInterval time.Duration
+}
+
+func (c Conf) Enabled() bool {
+	return c.Enable || c.CAFile != "" || c.CertFile != "" || c.ServerName != ""
+}
+
+type certificates struct {
+	roots      *x509.CertPool
+	clientCert *tls.Certificate
+}
+
+// Certificates provides the client configurations, their handshakes use the last loaded files.
+type Certificates struct {
+	serverName string
+	current    atomic.Pointer[certificates]
+	// guarded by loadedMutex
+	key       string
+	users     int
+	stopWatch context.CancelFunc
+}
+
+var (
+	loadedMutex sync.Mutex
+	// shared by identical confs, so a file is watched once and clients can be compared
+	loaded = map[string]*Certificates{}
+)
+
+// Load returns nil when the conf is not enabled. Each call returning a non nil value
+// must be paired with a call to Release (which stops the polling of the files with the last one).
+func Load(logger *slog.Logger, conf Conf) (*Certificates, error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+
+	key, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	loadedMutex.Lock()
+	defer loadedMutex.Unlock()
+
+	if res, ok := loaded[string(key)]; ok {
+		res.users++
+		return res, nil
+	}
+
+	var fileSystem afero.Fs = afero.NewOsFs()
+	if conf.Fs.Kind != "" {
+		if fileSystem, err = fsclient.New(conf.Fs); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Certificates{serverName: conf.ServerName, key: string(key), users: 1}
+	reload := func() error {
+		loadedCerts, err := readCertificates(fileSystem, conf)
+		if err == nil {
+			res.current.Store(&loadedCerts)
+		}
+		return err
+	}
+	if err = reload(); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range []string{conf.CAFile, conf.CertFile, conf.KeyFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	fsclient.Watch(watchCtx, logger, fileSystem, conf.ReloadInterval, paths, reload)
+	res.stopWatch = stopWatch
+
+	loaded[string(key)] = res
+	return res, nil
+}
+
+// Release does nothing on a nil receiver, the last release stops the polling of the files
+// (the configurations already given keep the last loaded files).
+func Release(c *Certificates) {
+	if c == nil {
+		return
+	}
+
+	loadedMutex.Lock()
+	defer loadedMutex.Unlock()
+
+	if c.users--; c.users > 0 {
+		return
+	}
+	c.stopWatch()
+	// already removed by ReleaseAll
+	if loaded[c.key] == c {
+		delete(loaded, c.key)
+	}
+}
+
+// ReleaseAll stops the polling of all the loaded files, for the commands of the binary.
+func ReleaseAll() {
+	loadedMutex.Lock()
+	defer loadedMutex.Unlock()
+
+	for key, c := range loaded {
+		c.stopWatch()
+		delete(loaded, key)
+	}
+}
+
+func readCertificates(fileSystem afero.Fs, conf Conf) (certificates, error) {
+	var res certificates
+	if conf.CAFile != "" {
+		data, err := afero.ReadFile(fileSystem, conf.CAFile)
+		if err != nil {
+			return certificates{}, err
+		}
+
+		res.roots = x509.NewCertPool()
+		if !res.roots.AppendCertsFromPEM(data) {
+			return certificates{}, errInvalidCA
+		}
+	}
+
+	if (conf.CertFile == "") != (conf.KeyFile == "") {
+		return certificates{}, errMissingKeyPair
+	}
+	if conf.CertFile != "" {
+		certData, err := afero.ReadFile(fileSystem, conf.CertFile)
+		if err != nil {
+			return certificates{}, err
+		}
+		keyData, err := afero.ReadFile(fileSystem, conf.KeyFile)
+		if err != nil {
+			return certificates{}, err
+		}
+
+		clientCert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return certificates{}, err
+		}
+		res.clientCert = &clientCert
+	}
+	return res, nil
+}
+
+// ClientConfig returns nil on a nil receiver. host is used when the conf has no ServerName,
+// when both are empty, the name sent by the store driver (which is the host for most of them) is checked.
+func (c *Certificates) ClientConfig(host string) *tls.Config {
+	if c == nil {
+		return nil
+	}
+
+	serverName := c.serverName
+	if serverName == "" {
+		serverName = host
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// the standard verification can not follow a CA rotation, it is done by VerifyConnection
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return c.verify(state, serverName)
+		},
+		GetClientCertificate: c.clientCertificate,
+	}
+}
+
+func (c *Certificates) verify(state tls.ConnectionState, serverName string) error {
+	if serverName == "" {
+		if serverName = state.ServerName; serverName == "" {
+			return errMissingServerName
+		}
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errNoPeerCertificate
+	}
+
+	opts := x509.VerifyOptions{Roots: c.current.Load().roots, DNSName: serverName, Intermediates: x509.NewCertPool()}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+func (c *Certificates) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if clientCert := c.current.Load().clientCert; clientCert != nil {
+		return clientCert, nil
+	}
+	// no certificate is sent
+	return &tls.Certificate{}, nil
+}
@@ -0,0 +1,82 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// environment variable also read by "weaver single deploy"
+const configEnvName = "SERVICEWEAVER_CONFIG"
+
+var errNoConfig = errors.New("no configuration file, use -config flag or SERVICEWEAVER_CONFIG environment variable")
+
+// Run handles the administration modes of the binary (executed without weaver),
+// it returns false when args does not select one of them.
+func Run(ctx context.Context, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "migrate":
+		return true, runMigrate(ctx, args[1:])
+	case "indexes":
+		return true, runIndexes(ctx, args[1:])
+	case "salts":
+		return true, runSalts(ctx, args[1:])
+	case "lockouts":
+		return true, runLockouts(ctx, args[1:])
+	}
+	return false, nil
+}
+
+type configFile struct {
+	metaData toml.MetaData
+	sections map[string]toml.Primitive
+}
+
+func loadConfigFile(path string) (configFile, error) {
+	if path == "" {
+		return configFile{}, errNoConfig
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return configFile{}, err
+	}
+
+	var sections map[string]toml.Primitive
+	metaData, err := toml.Decode(string(data), &sections)
+	return configFile{metaData: metaData, sections: sections}, err
+}
+
+// decode the section of a component (by its full weaver name) in dst,
+// unlike weaver, fields unknown by dst are ignored, return false when the section is missing
+func (c configFile) decodeSection(componentName string, dst any) (bool, error) {
+	section, ok := c.sections[componentName]
+	if !ok {
+		return false, nil
+	}
+	return true, c.metaData.PrimitiveDecode(section, dst)
+}
@@ -0,0 +1,193 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	blogimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/blog"
+	galleryimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/gallery/service/impl"
+	profileimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/profile"
+	saltimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/salt"
+	settingsimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/settings"
+	wikiimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/wiki"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const indexesUsage = "usage : puzzleweaver indexes [-config file] ensure|check [component...]"
+
+var errIndexesUsage = errors.New(indexesUsage)
+
+type mongoSection struct {
+	MongoAddress      string
+	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	SearchMode        string
+}
+
+// the widget component prefixes the names of its gallery configuration
+type galleryMongoSection struct {
+	GalleryMongoAddress      string
+	GalleryMongoDatabaseName string
+	GalleryMongoOptions      mongoclient.Options
+	GalleryMongoTLS          tlsclient.Conf
+}
+
+type indexTarget struct {
+	configKey string
+	component string
+	decode    func(configFile, string) (mongoSection, bool, error)
+	indexes   func(mongoSection) (mongoclient.Indexes, error)
+}
+
+var indexTargets = []indexTarget{{
+	configKey: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", component: blogimpl.Indexes.Component,
+	decode: decodeMongoSection, indexes: func(section mongoSection) (mongoclient.Indexes, error) {
+		return blogimpl.RequiredIndexes(section.SearchMode)
+	},
+}, {
+	configKey: "github.com/dvaumoron/puzzleweaver/serviceimpl/wiki/RemoteWikiService", component: wikiimpl.Indexes.Component,
+	decode: decodeMongoSection, indexes: staticIndexes(wikiimpl.Indexes),
+}, {
+	configKey: "github.com/dvaumoron/puzzleweaver/serviceimpl/profile/RemoteProfileService", component: profileimpl.Indexes.Component,
+	decode: decodeMongoSection, indexes: staticIndexes(profileimpl.Indexes),
+}, {
+	configKey: "github.com/dvaumoron/puzzleweaver/serviceimpl/settings/SettingsService", component: settingsimpl.Indexes.Component,
+	decode: decodeMongoSection, indexes: staticIndexes(settingsimpl.Indexes),
+}, {
+	configKey: "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/CustomWidgetService", component: galleryimpl.Indexes.Component,
+	decode: decodeGallerySection, indexes: staticIndexes(galleryimpl.Indexes),
+}, {
+	configKey: saltConfigKey, component: saltimpl.Indexes.Component,
+	decode: decodeMongoSection, indexes: staticIndexes(saltimpl.Indexes),
+}}
+
+func decodeMongoSection(config configFile, configKey string) (mongoSection, bool, error) {
+	var section mongoSection
+	found, err := config.decodeSection(configKey, &section)
+	return section, found, err
+}
+
+func decodeGallerySection(config configFile, configKey string) (mongoSection, bool, error) {
+	var section galleryMongoSection
+	found, err := config.decodeSection(configKey, &section)
+	return mongoSection{
+		MongoAddress: section.GalleryMongoAddress, MongoDatabaseName: section.GalleryMongoDatabaseName,
+		MongoOptions: section.GalleryMongoOptions, MongoTLS: section.GalleryMongoTLS,
+	}, found, err
+}
+
+func staticIndexes(indexes mongoclient.Indexes) func(mongoSection) (mongoclient.Indexes, error) {
+	return func(mongoSection) (mongoclient.Indexes, error) {
+		return indexes, nil
+	}
+}
+
+func connectMongo(ctx context.Context, section mongoSection) (*mongo.Client, error) {
+	certificates, err := tlsclient.Load(slog.Default(), section.MongoTLS)
+	if err != nil {
+		return nil, err
+	}
+	return mongoclient.Connect(ctx, slog.Default(), section.MongoAddress, section.MongoOptions, certificates)
+}
+
+func runIndexes(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("indexes", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv(configEnvName), "path of the weaver TOML configuration")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := flags.Args()
+	if len(remaining) == 0 {
+		return errIndexesUsage
+	}
+	action, components := remaining[0], remaining[1:]
+	if action != mongoclient.IndexEnsure && action != mongoclient.IndexCheck {
+		return errIndexesUsage
+	}
+
+	config, err := loadConfigFile(*configPath)
+	if err != nil {
+		return err
+	}
+	defer mongoclient.DisconnectAll(ctx, slog.Default())
+	defer tlsclient.ReleaseAll()
+
+	allPresent := true
+	for _, target := range indexTargets {
+		if len(components) != 0 && !slices.Contains(components, target.component) {
+			continue
+		}
+
+		section, found, err := target.decode(config, target.configKey)
+		if err != nil {
+			return err
+		}
+		if !found || section.MongoAddress == "" {
+			fmt.Println("No configuration for component", target.component)
+			continue
+		}
+
+		indexes, err := target.indexes(section)
+		if err != nil {
+			return err
+		}
+
+		client, err := connectMongo(ctx, section)
+		if err != nil {
+			return err
+		}
+		database := client.Database(section.MongoDatabaseName)
+
+		if action == mongoclient.IndexEnsure {
+			if err = indexes.Ensure(ctx, database); err != nil {
+				return err
+			}
+			fmt.Println("Component", target.component, "indexes ensured on collection", indexes.Collection)
+			continue
+		}
+
+		missing, err := indexes.Missing(ctx, database)
+		if err != nil {
+			return err
+		}
+		if len(missing) == 0 {
+			fmt.Println("Component", target.component, "has all its indexes on collection", indexes.Collection)
+		} else {
+			allPresent = false
+			fmt.Println("Component", target.component, "misses on collection", indexes.Collection, ":", strings.Join(missing, ", "))
+		}
+	}
+
+	if !allPresent {
+		return mongoclient.ErrMissingIndexes
+	}
+	return nil
+}
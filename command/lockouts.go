@@ -0,0 +1,99 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	loginimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/login"
+)
+
+const lockoutsUsage = "usage : puzzleweaver lockouts [-config file] list\n" +
+	"        puzzleweaver lockouts [-config file] clear target..."
+
+var errLockoutsUsage = errors.New(lockoutsUsage)
+
+func runLockouts(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("lockouts", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv(configEnvName), "path of the weaver TOML configuration")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := flags.Args()
+	if len(remaining) == 0 {
+		return errLockoutsUsage
+	}
+
+	config, err := loadConfigFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	defer tlsclient.ReleaseAll()
+
+	command, targets := remaining[0], remaining[1:]
+	switch {
+	case command == "list" && len(targets) == 0:
+		return listLockouts(ctx, config)
+	case command == "clear" && len(targets) != 0:
+		return clearLockouts(ctx, config, targets)
+	}
+	return errLockoutsUsage
+}
+
+func listLockouts(ctx context.Context, config configFile) error {
+	db, err := openLoginDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	lockouts, err := loginimpl.ListLockouts(ctx, db, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, lockout := range lockouts {
+		fmt.Println(
+			lockout.Target, "failures :", lockout.Failures,
+			"last failure :", time.Unix(lockout.LastFailure, 0).Format(time.RFC3339),
+			"locked until :", time.Unix(lockout.LockedUntil, 0).Format(time.RFC3339),
+		)
+	}
+	fmt.Println("Active lockouts :", len(lockouts))
+	return nil
+}
+
+func clearLockouts(ctx context.Context, config configFile, targets []string) error {
+	db, err := openLoginDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if err = loginimpl.ClearLockouts(ctx, db, targets...); err != nil {
+		return err
+	}
+	fmt.Println("Cleared targets :", targets)
+	return nil
+}
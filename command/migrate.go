@@ -0,0 +1,241 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	adminimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/admin"
+	blogimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/blog"
+	galleryimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/gallery/service/impl"
+	forumimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/forum"
+	loginimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/login"
+	saltimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/salt"
+	sessionimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/session"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "usage : puzzleweaver migrate [-config file] [-steps n] up|down|status [component...]"
+
+var errMigrateUsage = errors.New(migrateUsage)
+
+type databaseSection struct {
+	DatabaseKind    string
+	DatabaseAddress string
+	DatabaseTLS     tlsclient.Conf
+}
+
+// common view of the SQL and MongoDB migrations
+type migrator interface {
+	up() ([]uint64, error)
+	down(steps int) ([]uint64, error)
+	status() ([]dbclient.MigrationStatus, error)
+}
+
+type migrationTarget struct {
+	configKey string
+	component string
+	// false when the configuration has no section for the component
+	open func(context.Context, configFile, string) (migrator, bool, error)
+}
+
+var migrationTargets = []migrationTarget{
+	sqlMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", loginimpl.Migrations),
+	sqlMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", forumimpl.Migrations),
+	sqlMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/admin/AdminService", adminimpl.Migrations),
+	sqlMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", sessionimpl.Migrations),
+	sqlMigrationTarget(saltConfigKey, saltimpl.Migrations),
+	mongoMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", blogimpl.Migrations, decodeMongoSection),
+	mongoMigrationTarget("github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/CustomWidgetService", galleryimpl.Migrations, decodeGallerySection),
+}
+
+func sqlMigrationTarget(configKey string, migrations dbclient.Migrations) migrationTarget {
+	return migrationTarget{configKey: configKey, component: migrations.Component, open: func(ctx context.Context, config configFile, configKey string) (migrator, bool, error) {
+		var section databaseSection
+		found, err := config.decodeSection(configKey, &section)
+		// the session and salt components use a database only with their sql store
+		if err != nil || !found || section.DatabaseKind == "" {
+			return nil, false, err
+		}
+
+		certificates, err := tlsclient.Load(slog.Default(), section.DatabaseTLS)
+		if err != nil {
+			return nil, true, err
+		}
+
+		db, err := dbclient.New(section.DatabaseKind, section.DatabaseAddress, certificates)
+		if err != nil {
+			return nil, true, err
+		}
+		return sqlMigrator{migrations: migrations, db: db.WithContext(ctx)}, true, nil
+	}}
+}
+
+func mongoMigrationTarget(configKey string, migrations mongoclient.Migrations, decode func(configFile, string) (mongoSection, bool, error)) migrationTarget {
+	return migrationTarget{configKey: configKey, component: migrations.Component, open: func(ctx context.Context, config configFile, configKey string) (migrator, bool, error) {
+		section, found, err := decode(config, configKey)
+		if err != nil || !found || section.MongoAddress == "" {
+			return nil, false, err
+		}
+
+		client, err := connectMongo(ctx, section)
+		if err != nil {
+			return nil, true, err
+		}
+		return mongoMigrator{ctx: ctx, migrations: migrations, database: client.Database(section.MongoDatabaseName)}, true, nil
+	}}
+}
+
+type sqlMigrator struct {
+	migrations dbclient.Migrations
+	db         *gorm.DB
+}
+
+func (m sqlMigrator) up() ([]uint64, error) {
+	return m.migrations.Up(m.db)
+}
+
+func (m sqlMigrator) down(steps int) ([]uint64, error) {
+	return m.migrations.Down(m.db, steps)
+}
+
+func (m sqlMigrator) status() ([]dbclient.MigrationStatus, error) {
+	return m.migrations.Status(m.db)
+}
+
+type mongoMigrator struct {
+	ctx        context.Context
+	migrations mongoclient.Migrations
+	database   *mongo.Database
+}
+
+func (m mongoMigrator) up() ([]uint64, error) {
+	return m.migrations.Up(m.ctx, m.database)
+}
+
+func (m mongoMigrator) down(steps int) ([]uint64, error) {
+	return m.migrations.Down(m.ctx, m.database, steps)
+}
+
+func (m mongoMigrator) status() ([]dbclient.MigrationStatus, error) {
+	return m.migrations.Status(m.ctx, m.database)
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv(configEnvName), "path of the weaver TOML configuration")
+	steps := flags.Int("steps", 1, "number of migrations to revert by component with down")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := flags.Args()
+	if len(remaining) == 0 {
+		return errMigrateUsage
+	}
+	action, components := remaining[0], remaining[1:]
+
+	var apply func(string, migrator) error
+	switch action {
+	case "up":
+		apply = migrateUp
+	case "down":
+		apply = func(component string, m migrator) error {
+			return migrateDown(component, m, *steps)
+		}
+	case "status":
+		apply = migrateStatus
+	default:
+		return errMigrateUsage
+	}
+
+	config, err := loadConfigFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	defer mongoclient.DisconnectAll(ctx, slog.Default())
+	defer tlsclient.ReleaseAll()
+
+	for _, target := range migrationTargets {
+		if len(components) != 0 && !slices.Contains(components, target.component) {
+			continue
+		}
+
+		m, found, err := target.open(ctx, config, target.configKey)
+		if err != nil {
+			return err
+		}
+		if !found {
+			fmt.Println("No configuration for component", target.component)
+			continue
+		}
+
+		if err = apply(target.component, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateUp(component string, m migrator) error {
+	applied, err := m.up()
+	fmt.Println("Component", component, "applied versions :", applied)
+	return err
+}
+
+func migrateDown(component string, m migrator, steps int) error {
+	reverted, err := m.down(steps)
+	fmt.Println("Component", component, "reverted versions :", reverted)
+	return err
+}
+
+func migrateStatus(component string, m migrator) error {
+	statuses, err := m.status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Component", component)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+			if !status.Known {
+				state = "applied (unknown)"
+			}
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,237 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/dvaumoron/puzzleloginserver/model"
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	saltimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/salt"
+	"gorm.io/gorm"
+)
+
+const (
+	saltsUsage = "usage : puzzleweaver salts [-config file] [-dedicated-db] [-min-idle duration] [-dry-run] sweep\n" +
+		"        puzzleweaver salts [-config file] [-dedicated-db] -from file copy"
+
+	saltConfigKey  = "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService"
+	loginConfigKey = "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService"
+)
+
+var (
+	errSaltsUsage      = errors.New(saltsUsage)
+	errNoSaltSection   = errors.New("no configuration for the salt component")
+	errNoLoginDatabase = errors.New("no database configuration for the login component")
+	errSharedRedis     = errors.New("the salts have no RedisKeyPrefix, use -dedicated-db when their Redis database holds nothing else")
+)
+
+func runSalts(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("salts", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv(configEnvName), "path of the weaver TOML configuration")
+	minIdle := flags.Duration("min-idle", time.Hour, "salts used (Redis) or created (other stores) more recently are kept by sweep")
+	dryRun := flags.Bool("dry-run", false, "count the orphan salts without removing them")
+	fromPath := flags.String("from", "", "path of the weaver TOML configuration of the source store for copy")
+	dedicatedDB := flags.Bool("dedicated-db", false, "the Redis database of salts without RedisKeyPrefix holds nothing else, so all its string keys are salts")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	remaining := flags.Args()
+	if len(remaining) != 1 {
+		return errSaltsUsage
+	}
+
+	config, err := loadConfigFile(*configPath)
+	if err != nil {
+		return err
+	}
+	defer mongoclient.DisconnectAll(ctx, slog.Default())
+	defer tlsclient.ReleaseAll()
+
+	switch remaining[0] {
+	case "sweep":
+		return sweepSalts(ctx, config, *dedicatedDB, *minIdle, *dryRun)
+	case "copy":
+		if *fromPath == "" {
+			return errSaltsUsage
+		}
+
+		fromConfig, err := loadConfigFile(*fromPath)
+		if err != nil {
+			return err
+		}
+		return copySalts(ctx, fromConfig, config, *dedicatedDB)
+	}
+	return errSaltsUsage
+}
+
+// a scanned Redis store without key prefix would return the unrelated string keys of its database
+func openSaltStore(ctx context.Context, config configFile, scanned bool, dedicatedDB bool) (saltimpl.Store, error) {
+	var conf saltimpl.StoreConf
+	found, err := config.decodeSection(saltConfigKey, &conf)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNoSaltSection
+	}
+	if scanned && conf.SharedScan() && !dedicatedDB {
+		return nil, errSharedRedis
+	}
+	return saltimpl.OpenStore(ctx, slog.Default(), conf)
+}
+
+func openLoginDatabase(ctx context.Context, config configFile) (*gorm.DB, error) {
+	var loginSection databaseSection
+	found, err := config.decodeSection(loginConfigKey, &loginSection)
+	if err != nil {
+		return nil, err
+	}
+	if !found || loginSection.DatabaseKind == "" {
+		return nil, errNoLoginDatabase
+	}
+
+	certificates, err := tlsclient.Load(slog.Default(), loginSection.DatabaseTLS)
+	if err != nil {
+		return nil, err
+	}
+	db, err := dbclient.New(loginSection.DatabaseKind, loginSection.DatabaseAddress, certificates)
+	if err != nil {
+		return nil, err
+	}
+	return db.WithContext(ctx), nil
+}
+
+// remove the salts whose login does not exist in the login database
+func sweepSalts(ctx context.Context, config configFile, dedicatedDB bool, minIdle time.Duration, dryRun bool) error {
+	store, err := openSaltStore(ctx, config, true, dedicatedDB)
+	if err != nil {
+		return err
+	}
+
+	db, err := openLoginDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	scanned, orphanCount, unknownAgeCount := 0, 0, 0
+	err = store.Scan(ctx, func(entries []saltimpl.Entry) error {
+		scanned += len(entries)
+		for _, entry := range entries {
+			if entry.AgeUnknown {
+				unknownAgeCount++
+			}
+		}
+
+		orphans, err := orphanSalts(db, entries, minIdle)
+		if err != nil || len(orphans) == 0 {
+			return err
+		}
+
+		orphanCount += len(orphans)
+		if dryRun {
+			return nil
+		}
+		return store.Delete(ctx, orphans)
+	})
+
+	action := "removed"
+	if dryRun {
+		action = "to remove"
+	}
+	fmt.Println("Salts scanned :", scanned, ", orphans", action, ":", orphanCount)
+	if unknownAgeCount != 0 && minIdle != 0 {
+		// the idle time is not tracked by Redis with an LFU maxmemory-policy
+		fmt.Println("Salts kept without known age :", unknownAgeCount, ", use -min-idle 0 to check them too")
+	}
+	return err
+}
+
+func orphanSalts(db *gorm.DB, entries []saltimpl.Entry, minIdle time.Duration) ([]string, error) {
+	candidates := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// an unknown age is zero
+		if entry.Age >= minIdle {
+			candidates = append(candidates, entry.Login)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var existing []string
+	if err := db.Model(&model.User{}).Where("login IN ?", candidates).Pluck("login", &existing).Error; err != nil {
+		return nil, err
+	}
+
+	existingSet := make(map[string]struct{}, len(existing))
+	for _, login := range existing {
+		existingSet[login] = struct{}{}
+	}
+
+	orphans := make([]string, 0, len(candidates)-len(existing))
+	for _, login := range candidates {
+		if _, ok := existingSet[login]; !ok {
+			orphans = append(orphans, login)
+		}
+	}
+	return orphans, nil
+}
+
+// the salts already in the destination are kept, so the copy can be run again after the switch of store
+func copySalts(ctx context.Context, fromConfig configFile, toConfig configFile, dedicatedDB bool) error {
+	from, err := openSaltStore(ctx, fromConfig, true, dedicatedDB)
+	if err != nil {
+		return err
+	}
+	to, err := openSaltStore(ctx, toConfig, false, false)
+	if err != nil {
+		return err
+	}
+
+	copied, conflicts := 0, 0
+	err = from.Scan(ctx, func(entries []saltimpl.Entry) error {
+		for _, entry := range entries {
+			stored, err := to.Create(ctx, entry.Login, entry.Salt)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(stored, entry.Salt) {
+				copied++
+			} else {
+				conflicts++
+				fmt.Println("Login", entry.Login, "kept with the salt of the destination")
+			}
+		}
+		return nil
+	})
+
+	fmt.Println("Salts copied (or already identical) :", copied, ", kept with a different salt :", conflicts)
+	return err
+}
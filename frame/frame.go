@@ -37,8 +37,13 @@ import (
 	settingsimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/settings"
 	templatesimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/templates"
 	wikiimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/wiki"
+	"github.com/dvaumoron/puzzleweaver/web/clientaddr"
+	"github.com/dvaumoron/puzzleweaver/web/email"
 	"github.com/dvaumoron/puzzleweaver/web/globalconfig"
+	"github.com/dvaumoron/puzzleweaver/web/twofactor"
 	"github.com/dvaumoron/puzzleweb/common/build"
+	puzzleweb "github.com/dvaumoron/puzzleweb/core"
+	"github.com/dvaumoron/puzzleweb/locale"
 )
 
 var (
@@ -81,10 +86,12 @@ func NewFrameServe(version string) func(ctx context.Context, app *FrameApp) erro
 			return err
 		}
 
-		site, ok := build.BuildDefaultSite(globalConfig)
+		localesManager, ok := locale.NewManager(globalConfig.ExtractLocalesConfig())
 		if !ok {
 			return errSiteCreation
 		}
+		settingsManager := puzzleweb.NewSettingsManager(globalConfig.ExtractSettingsConfig())
+		site := puzzleweb.NewSite(globalConfig, localesManager, settingsManager)
 
 		for _, pageGroup := range globalConfig.StaticPages {
 			if !site.AddStaticPages(pageGroup) {
@@ -96,10 +103,14 @@ func NewFrameServe(version string) func(ctx context.Context, app *FrameApp) erro
 			return errSiteCreation
 		}
 
+		site.AddPage(twofactor.MakePage(globalConfig.LoginClient, globalConfig.LoginImpl, globalConfig.SessionClient, globalConfig.ClientAddr, settingsManager))
+		site.AddPage(email.MakePage(globalConfig.LoginClient, globalConfig.LoginImpl, globalConfig.ClientAddr))
+
 		siteConfig := globalConfig.ExtractSiteConfig()
 		// emptying data no longer useful for GC cleaning
 		globalConfig = nil
 
-		return site.RunListener(siteConfig, app.web)
+		// the client IP is used to throttle the failed logins
+		return site.RunListener(siteConfig, clientaddr.Listener(app.web))
 	}
 }
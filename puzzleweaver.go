@@ -21,8 +21,10 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 
 	"github.com/ServiceWeaver/weaver"
+	"github.com/dvaumoron/puzzleweaver/command"
 	"github.com/dvaumoron/puzzleweaver/frame"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	widgethelper "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/helper"
@@ -33,6 +35,13 @@ var version = "dev"
 
 func main() {
 	ctx := context.Background()
+	if handled, err := command.Run(ctx, os.Args[1:]); handled {
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	widgethelper.Registerers = append(widgethelper.Registerers, func(wm widgethelper.WidgetManager, conf map[string]string, lg servicecommon.LoggerGetter) error {
 		lg.Logger(ctx).Info("Custom widget initialization", "conf", conf)
 		return nil
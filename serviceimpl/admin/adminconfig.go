@@ -20,15 +20,24 @@ package adminimpl
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
-	"github.com/dvaumoron/puzzlerightserver/model"
 	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
 	fsclient "github.com/dvaumoron/puzzleweaver/client/fs"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/spf13/afero"
 	"gorm.io/gorm"
 )
 
+const rulePackage = "data.auth"
+
+var errWrongPackage = errors.New("OPA module does not declare the auth package")
+
 type permissionGroup struct {
 	Id   uint64
 	Name string
@@ -38,40 +47,68 @@ type adminConf struct {
 	PermissionGroups []permissionGroup
 	DatabaseKind     string
 	DatabaseAddress  string
+	DatabaseTLS      tlsclient.Conf
 	FsConf           fsclient.FsConf
 	OpaModulePath    string
+	// polling of the OPA module file, disabled when zero
+	ReloadInterval time.Duration
 }
 
 type initializedAdminConf struct {
 	db            *gorm.DB
-	query         rego.PreparedEvalQuery
+	query         *atomic.Pointer[rego.PreparedEvalQuery]
 	groupIdToName map[uint64]string
 	nameToGroupId map[string]uint64
 	groupIds      []uint64
+	certificates  *tlsclient.Certificates
+	stopWatch     context.CancelFunc
 }
 
-func initAdminConf(ctx context.Context, conf *adminConf) (initializedAdminConf, error) {
+func initAdminConf(ctx context.Context, logger *slog.Logger, conf *adminConf) (initializedAdminConf, error) {
 	fileSystem, err := fsclient.New(conf.FsConf)
 	if err != nil {
 		return initializedAdminConf{}, err
 	}
 
-	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress)
+	certificates, err := tlsclient.Load(logger, conf.DatabaseTLS)
+	if err != nil {
+		return initializedAdminConf{}, err
+	}
+
+	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress, certificates)
 	if err == nil {
-		err = db.AutoMigrate(&model.UserRoles{}, &model.Role{}, &model.RoleName{})
+		err = Migrations.Check(db)
 	}
 	if err != nil {
+		tlsclient.Release(certificates)
 		return initializedAdminConf{}, err
 	}
 
-	query, err := readRule(ctx, fileSystem, conf.OpaModulePath)
+	loadedQuery, err := readRule(ctx, fileSystem, conf.OpaModulePath)
 	if err != nil {
+		tlsclient.Release(certificates)
 		return initializedAdminConf{}, err
 	}
 
+	query := new(atomic.Pointer[rego.PreparedEvalQuery])
+	query.Store(&loadedQuery)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	fsclient.Watch(watchCtx, logger, fileSystem, conf.ReloadInterval, []string{conf.OpaModulePath}, func() error {
+		if err := checkRulePackage(fileSystem, conf.OpaModulePath); err != nil {
+			return err
+		}
+		// the compilation validate the new module
+		newQuery, err := readRule(watchCtx, fileSystem, conf.OpaModulePath)
+		if err == nil {
+			query.Store(&newQuery)
+		}
+		return err
+	})
+
 	groupIdToName, nameToGroupId, groupIds := initMapping(conf.PermissionGroups)
 	return initializedAdminConf{
 		db: db, query: query, groupIdToName: groupIdToName, nameToGroupId: nameToGroupId, groupIds: groupIds,
+		certificates: certificates, stopWatch: stopWatch,
 	}, nil
 }
 
@@ -88,6 +125,23 @@ func readRule(ctx context.Context, fileSystem afero.Fs, modulePath string) (rego
 	return rule.PrepareForEval(ctx)
 }
 
+// a module without the queried package would deny everything
+func checkRulePackage(fileSystem afero.Fs, modulePath string) error {
+	data, err := afero.ReadFile(fileSystem, modulePath)
+	if err != nil {
+		return err
+	}
+
+	module, err := ast.ParseModule(modulePath, string(data))
+	if err != nil {
+		return err
+	}
+	if module == nil || module.Package.Path.String() != rulePackage {
+		return errWrongPackage
+	}
+	return nil
+}
+
 func initMapping(permissionGroups []permissionGroup) (map[uint64]string, map[string]uint64, []uint64) {
 	groupIdToName := map[uint64]string{
 		PublicGroupId: PublicName, AdminGroupId: AdminName,
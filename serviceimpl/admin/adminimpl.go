@@ -25,6 +25,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	"github.com/dvaumoron/puzzlerightserver/model"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"github.com/open-policy-agent/opa/rego"
@@ -47,11 +48,23 @@ type adminImpl struct {
 }
 
 func (impl *adminImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initAdminConf(ctx, impl.Config())
+	impl.initializedConf, err = initAdminConf(ctx, impl.Logger(ctx), impl.Config())
 	impl.idToName = map[uint64]string{}
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// stops the polling of the watched files and releases the TLS certificates
+func (impl *adminImpl) Shutdown(ctx context.Context) error {
+	if stopWatch := impl.initializedConf.stopWatch; stopWatch != nil {
+		stopWatch()
+	}
+	tlsclient.Release(impl.initializedConf.certificates)
+	return nil
+}
+
 func (impl *adminImpl) AuthQuery(ctx context.Context, userId uint64, groupId uint64, action string) error {
 	db := impl.initializedConf.db.WithContext(ctx)
 	return impl.innerAuthQuery(ctx, db, userId, groupId, convertActionToFlag(action))
@@ -287,7 +300,7 @@ func (impl *adminImpl) evalOPA(ctx context.Context, userId uint64, groupId uint6
 	input := map[string]any{
 		"userId": userId, "objectId": groupId, "actionFlag": actionFlag, "userRoles": userRoles,
 	}
-	results, err := impl.initializedConf.query.Eval(ctx, rego.EvalInput(input))
+	results, err := impl.initializedConf.query.Load().Eval(ctx, rego.EvalInput(input))
 	if err != nil {
 		impl.Logger(ctx).Error("OPA evaluation failed", common.ErrorKey, err)
 		return servicecommon.ErrInternal
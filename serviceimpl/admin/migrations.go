@@ -0,0 +1,61 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package adminimpl
+
+import dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+
+// applied with the migrate mode of the binary, checked at component start,
+// the steps use copies of the models at their version, so they do not follow the later changes
+var Migrations = dbclient.Migrations{Component: "admin", Steps: []dbclient.Migration{{
+	Version: 1, Name: "create roles tables",
+	Up:   dbclient.AdoptTables(&userRolesV1{}, &rolesV1{}, &roleNamesV1{}),
+	Down: dbclient.DropTables(&userRolesV1{}, &rolesV1{}, &roleNamesV1{}),
+}}}
+
+// model.UserRoles of puzzlerightserver
+type userRolesV1 struct {
+	ID     uint64
+	UserId uint64
+	RoleId uint64
+}
+
+func (userRolesV1) TableName() string {
+	return "user_roles"
+}
+
+// model.Role of puzzlerightserver
+type rolesV1 struct {
+	ID          uint64
+	NameId      uint64
+	ObjectId    uint64
+	ActionFlags uint8
+}
+
+func (rolesV1) TableName() string {
+	return "roles"
+}
+
+// model.RoleName of puzzlerightserver
+type roleNamesV1 struct {
+	ID   uint64
+	Name string
+}
+
+func (roleNamesV1) TableName() string {
+	return "role_names"
+}
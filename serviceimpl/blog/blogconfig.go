@@ -19,19 +19,94 @@
 package blogimpl
 
 import (
+	"context"
+	"log/slog"
+
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	postIdsSequence  = "postIds"
+	postsUniqueIndex = "posts_blog_post"
+	textIndexName    = "posts_text"
 )
 
+// ensured (or checked) at component start and by the indexes mode of the binary,
+// the unique index is also used by the pagination
+var Indexes = mongoclient.Indexes{Component: "blog", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(postsUniqueIndex, blogIdKey, postIdKey),
+}}
+
+// applied with the migrate mode of the binary, checked at component start
+var Migrations = mongoclient.Migrations{Component: "blog", Steps: []mongoclient.Migration{{
+	Version: 1, Name: "seed post id counters from existing posts",
+	Up:   mongoclient.SeedSequence(postIdsSequence, collectionName, blogIdKey, postIdKey),
+	Down: mongoclient.DropSequence(postIdsSequence),
+}}}
+
+// RequiredIndexes adds the text index when the search mode need it.
+func RequiredIndexes(searchMode string) (mongoclient.Indexes, error) {
+	searcher, err := mongoclient.NewSearcher(searchMode)
+	if err != nil || searcher.Regex() {
+		return Indexes, err
+	}
+	return Indexes.With(mongoclient.TextIndex(textIndexName, titleKey, textKey)), nil
+}
+
 type blogConf struct {
 	MongoAddress      string
 	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	// "text" (default) or "regex"
+	SearchMode string
+	// "ensure" (default) or "check"
+	IndexMode string
 }
 
 type initializedBlogConf struct {
-	clientOptions *options.ClientOptions
+	client       *mongo.Client
+	certificates *tlsclient.Certificates
+	collection   *mongo.Collection
+	postIds      mongoclient.Sequence
+	searcher     mongoclient.Searcher
 }
 
-func initBlogConf(conf *blogConf) initializedBlogConf {
-	return initializedBlogConf{clientOptions: mongoclient.New(conf.MongoAddress)}
+func initBlogConf(ctx context.Context, logger *slog.Logger, conf *blogConf) (initializedBlogConf, error) {
+	searcher, err := mongoclient.NewSearcher(conf.SearchMode)
+	if err != nil {
+		return initializedBlogConf{}, err
+	}
+
+	indexes, err := RequiredIndexes(conf.SearchMode)
+	if err != nil {
+		return initializedBlogConf{}, err
+	}
+
+	certificates, err := tlsclient.Load(logger, conf.MongoTLS)
+	if err != nil {
+		return initializedBlogConf{}, err
+	}
+
+	client, err := mongoclient.Connect(ctx, logger, conf.MongoAddress, conf.MongoOptions, certificates)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedBlogConf{}, err
+	}
+
+	database := client.Database(conf.MongoDatabaseName)
+	if err = indexes.Apply(ctx, database, conf.IndexMode); err == nil {
+		err = Migrations.Check(ctx, database)
+	}
+	if err != nil {
+		mongoclient.Release(ctx, client)
+		tlsclient.Release(certificates)
+		return initializedBlogConf{}, err
+	}
+	return initializedBlogConf{
+		client: client, certificates: certificates, collection: database.Collection(collectionName),
+		postIds: mongoclient.NewSequence(database, postIdsSequence), searcher: searcher,
+	}, nil
 }
@@ -20,10 +20,11 @@ package blogimpl
 
 import (
 	"context"
-	"strings"
 
 	"github.com/ServiceWeaver/weaver"
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"go.mongodb.org/mongo-driver/bson"
@@ -39,61 +40,41 @@ const userIdKey = "userId"
 const titleKey = "title"
 const textKey = "text"
 
-var optsMaxPostId = options.FindOne().SetSort(bson.D{{Key: postIdKey, Value: -1}}).SetProjection(bson.D{{Key: postIdKey, Value: true}})
-
 type remoteBlogImpl struct {
 	weaver.Implements[RemoteBlogService]
 	weaver.WithConfig[blogConf]
 	initializedConf initializedBlogConf
 }
 
-func (impl *remoteBlogImpl) Init(ctx context.Context) error {
-	impl.initializedConf = initBlogConf(impl.Config())
-	return nil
+func (impl *remoteBlogImpl) Init(ctx context.Context) (err error) {
+	impl.initializedConf, err = initBlogConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
+	return
+}
+
+// releases the shared MongoDB client and its TLS certificates
+func (impl *remoteBlogImpl) Shutdown(ctx context.Context) error {
+	err := mongoclient.Release(ctx, impl.initializedConf.client)
+	tlsclient.Release(impl.initializedConf.certificates)
+	return err
 }
 
 func (impl *remoteBlogImpl) CreatePost(ctx context.Context, blogId uint64, userId uint64, title string, content string) (uint64, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
+	collection := impl.initializedConf.collection
+
+	newPostId, err := impl.initializedConf.postIds.Next(ctx, blogId)
 	if err != nil {
 		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
 		return 0, servicecommon.ErrInternal
 	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
 
-	filter := bson.D{{Key: blogIdKey, Value: blogId}}
 	post := bson.M{
-		blogIdKey: blogId, userIdKey: userId, titleKey: title, textKey: content,
+		blogIdKey: blogId, postIdKey: newPostId, userIdKey: userId, titleKey: title, textKey: content,
 	}
-
-	// rely on the mongo server to ensure there will be no duplicate
-	newPostId := uint64(1)
-
-GeneratePostIdStep:
-	var result bson.D
-	err = collection.FindOne(ctx, filter, optsMaxPostId).Decode(&result)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			goto CreatePostStep
-		}
-
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return 0, servicecommon.ErrInternal
-	}
-
-	// call [1] to get postId because result has only the id and one field
-	newPostId = mongoclient.ExtractUint64(result[1].Value) + 1
-
-CreatePostStep:
-	post[postIdKey] = newPostId
 	if _, err = collection.InsertOne(ctx, post); err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			// retry
-			goto GeneratePostIdStep
-		}
-
 		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
 		return 0, servicecommon.ErrInternal
 	}
@@ -102,17 +83,10 @@ CreatePostStep:
 
 func (impl *remoteBlogImpl) GetPost(ctx context.Context, blogId uint64, postId uint64) (RawBlogPost, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return RawBlogPost{}, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
 	var result bson.M
-	err = collection.FindOne(
+	err := collection.FindOne(
 		ctx, bson.D{{Key: blogIdKey, Value: blogId}, {Key: postIdKey, Value: postId}},
 	).Decode(&result)
 	if err != nil {
@@ -128,19 +102,10 @@ func (impl *remoteBlogImpl) GetPost(ctx context.Context, blogId uint64, postId u
 
 func (impl *remoteBlogImpl) GetPosts(ctx context.Context, blogId uint64, start uint64, end uint64, filter string) (uint64, []RawBlogPost, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return 0, nil, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
-	filters := bson.D{{Key: blogIdKey, Value: blogId}}
-	if filter != "" {
-		filters = append(filters, bson.E{Key: titleKey, Value: buildRegexFilter(filter)})
-	}
+	search := impl.initializedConf.searcher.Parse(filter)
+	filters := search.Filter(bson.D{{Key: blogIdKey, Value: blogId}}, titleKey, textKey)
 
 	total, err := collection.CountDocuments(ctx, filters)
 	if err != nil {
@@ -148,7 +113,7 @@ func (impl *remoteBlogImpl) GetPosts(ctx context.Context, blogId uint64, start u
 		return 0, nil, servicecommon.ErrInternal
 	}
 
-	paginate := options.Find().SetSort(bson.D{{Key: postIdKey, Value: -1}})
+	paginate := options.Find().SetSort(search.Sort(bson.D{{Key: postIdKey, Value: -1}}))
 	paginate.SetSkip(int64(start)).SetLimit(int64(end - start))
 
 	cursor, err := collection.Find(ctx, filters, paginate)
@@ -162,21 +127,44 @@ func (impl *remoteBlogImpl) GetPosts(ctx context.Context, blogId uint64, start u
 		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
 		return 0, nil, servicecommon.ErrInternal
 	}
-	return uint64(total), servicecommon.ConvertSlice(results, convertToPost), nil
+	return uint64(total), addSnippets(servicecommon.ConvertSlice(results, convertToPost), search), nil
 }
 
-func (impl *remoteBlogImpl) Delete(ctx context.Context, blogId uint64, postId uint64) error {
+func (impl *remoteBlogImpl) GetPostsPage(ctx context.Context, blogId uint64, cursor string, size uint64, filter string) ([]RawBlogPost, string, string, error) {
+	position, err := clientcommon.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", "", err
+	}
+
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
+	collection := impl.initializedConf.collection
+
+	// the keyset order is kept, the search only filter
+	search := impl.initializedConf.searcher.Parse(filter)
+	filters := search.Filter(bson.D{{Key: blogIdKey, Value: blogId}}, titleKey, textKey)
+	filters, paginate := mongoclient.KeysetPaginate(filters, postIdKey, position, size, true)
+
+	mongoCursor, err := collection.Find(ctx, filters, paginate)
 	if err != nil {
 		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
+		return nil, "", "", servicecommon.ErrInternal
 	}
-	defer mongoclient.Disconnect(client, ctx, logger)
 
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	var results []bson.M
+	if err = mongoCursor.All(ctx, &results); err != nil {
+		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
+		return nil, "", "", servicecommon.ErrInternal
+	}
+
+	results, next, prev := clientcommon.BuildPage(results, position, size, postPosition)
+	return addSnippets(servicecommon.ConvertSlice(results, convertToPost), search), next, prev, nil
+}
+
+func (impl *remoteBlogImpl) Delete(ctx context.Context, blogId uint64, postId uint64) error {
+	logger := impl.Logger(ctx)
+	collection := impl.initializedConf.collection
 
-	_, err = collection.DeleteMany(
+	_, err := collection.DeleteMany(
 		ctx, bson.D{{Key: blogIdKey, Value: blogId}, {Key: postIdKey, Value: postId}},
 	)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -186,6 +174,11 @@ func (impl *remoteBlogImpl) Delete(ctx context.Context, blogId uint64, postId ui
 	return nil
 }
 
+// only the id, the pages stay in one scope of its sequence (see mongoclient.KeysetPaginate)
+func postPosition(post bson.M) clientcommon.Cursor {
+	return clientcommon.Cursor{Id: mongoclient.ExtractUint64(post[postIdKey])}
+}
+
 func convertToPost(post bson.M) RawBlogPost {
 	title, _ := post[titleKey].(string)
 	text, _ := post[textKey].(string)
@@ -195,15 +188,11 @@ func convertToPost(post bson.M) RawBlogPost {
 	}
 }
 
-func buildRegexFilter(filter string) bson.D {
-	filter = strings.ReplaceAll(filter, "%", ".*")
-	var regexBuilder strings.Builder
-	if strings.Index(filter, ".*") != 0 {
-		regexBuilder.WriteString(".*")
-	}
-	regexBuilder.WriteString(filter)
-	if strings.LastIndex(filter, ".*") != len(filter)-2 {
-		regexBuilder.WriteString(".*")
+func addSnippets(posts []RawBlogPost, search mongoclient.Search) []RawBlogPost {
+	if !search.Empty() {
+		for i, post := range posts {
+			posts[i].Snippet = search.Snippet(post.Title + "\n" + post.Content)
+		}
 	}
-	return bson.D{{Key: "$regex", Value: regexBuilder.String()}}
+	return posts
 }
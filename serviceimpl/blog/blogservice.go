@@ -31,12 +31,16 @@ type RawBlogPost struct {
 	CreatedAt int64
 	Title     string
 	Content   string
+	// html extract of Title and Content with the filter terms highlighted, empty without filter
+	Snippet string
 }
 
 type RemoteBlogService interface {
 	CreatePost(ctx context.Context, blogId uint64, userId uint64, title string, content string) (uint64, error)
 	GetPost(ctx context.Context, blogId uint64, postId uint64) (RawBlogPost, error)
 	GetPosts(ctx context.Context, blogId uint64, start uint64, end uint64, filter string) (uint64, []RawBlogPost, error)
+	// keyset variant of GetPosts, return the posts, then next and previous cursors
+	GetPostsPage(ctx context.Context, blogId uint64, cursor string, size uint64, filter string) ([]RawBlogPost, string, string, error)
 	Delete(ctx context.Context, blogId uint64, postId uint64) error
 }
 
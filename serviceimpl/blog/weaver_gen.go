@@ -20,10 +20,10 @@ func init() {
 		Iface: reflect.TypeOf((*RemoteBlogService)(nil)).Elem(),
 		Impl:  reflect.TypeOf(remoteBlogImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return remoteBlogService_local_stub{impl: impl.(RemoteBlogService), tracer: tracer, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "CreatePost", Remote: false}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "Delete", Remote: false}), getPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPost", Remote: false}), getPostsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPosts", Remote: false})}
+			return remoteBlogService_local_stub{impl: impl.(RemoteBlogService), tracer: tracer, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "CreatePost", Remote: false}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "Delete", Remote: false}), getPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPost", Remote: false}), getPostsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPosts", Remote: false}), getPostsPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPostsPage", Remote: false})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return remoteBlogService_client_stub{stub: stub, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "CreatePost", Remote: true}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "Delete", Remote: true}), getPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPost", Remote: true}), getPostsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPosts", Remote: true})}
+			return remoteBlogService_client_stub{stub: stub, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "CreatePost", Remote: true}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "Delete", Remote: true}), getPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPost", Remote: true}), getPostsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPosts", Remote: true}), getPostsPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/blog/RemoteBlogService", Method: "GetPostsPage", Remote: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return remoteBlogService_server_stub{impl: impl.(RemoteBlogService), addLoad: addLoad}
@@ -44,12 +44,13 @@ var _ weaver.Unrouted = (*remoteBlogImpl)(nil)
 // Local stub implementations.
 
 type remoteBlogService_local_stub struct {
-	impl              RemoteBlogService
-	tracer            trace.Tracer
-	createPostMetrics *codegen.MethodMetrics
-	deleteMetrics     *codegen.MethodMetrics
-	getPostMetrics    *codegen.MethodMetrics
-	getPostsMetrics   *codegen.MethodMetrics
+	impl                RemoteBlogService
+	tracer              trace.Tracer
+	createPostMetrics   *codegen.MethodMetrics
+	deleteMetrics       *codegen.MethodMetrics
+	getPostMetrics      *codegen.MethodMetrics
+	getPostsMetrics     *codegen.MethodMetrics
+	getPostsPageMetrics *codegen.MethodMetrics
 }
 
 // Check that remoteBlogService_local_stub implements the RemoteBlogService interface.
@@ -135,14 +136,35 @@ func (s remoteBlogService_local_stub) GetPosts(ctx context.Context, a0 uint64, a
 	return s.impl.GetPosts(ctx, a0, a1, a2, a3)
 }
 
+func (s remoteBlogService_local_stub) GetPostsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawBlogPost, r1 string, r2 string, err error) {
+	// Update metrics.
+	begin := s.getPostsPageMetrics.Begin()
+	defer func() { s.getPostsPageMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "blogimpl.RemoteBlogService.GetPostsPage", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetPostsPage(ctx, a0, a1, a2, a3)
+}
+
 // Client stub implementations.
 
 type remoteBlogService_client_stub struct {
-	stub              codegen.Stub
-	createPostMetrics *codegen.MethodMetrics
-	deleteMetrics     *codegen.MethodMetrics
-	getPostMetrics    *codegen.MethodMetrics
-	getPostsMetrics   *codegen.MethodMetrics
+	stub                codegen.Stub
+	createPostMetrics   *codegen.MethodMetrics
+	deleteMetrics       *codegen.MethodMetrics
+	getPostMetrics      *codegen.MethodMetrics
+	getPostsMetrics     *codegen.MethodMetrics
+	getPostsPageMetrics *codegen.MethodMetrics
 }
 
 // Check that remoteBlogService_client_stub implements the RemoteBlogService interface.
@@ -388,6 +410,70 @@ func (s remoteBlogService_client_stub) GetPosts(ctx context.Context, a0 uint64,
 	return
 }
 
+func (s remoteBlogService_client_stub) GetPostsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawBlogPost, r1 string, r2 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getPostsPageMetrics.Begin()
+	defer func() { s.getPostsPageMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "blogimpl.RemoteBlogService.GetPostsPage", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += (4 + len(a1))
+	size += 8
+	size += (4 + len(a3))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.String(a1)
+	enc.Uint64(a2)
+	enc.String(a3)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_RawBlogPost_43e1e8b0(dec)
+	r1 = dec.String()
+	r2 = dec.String()
+	err = dec.Error()
+	return
+}
+
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
@@ -432,6 +518,8 @@ func (s remoteBlogService_server_stub) GetStubFn(method string) func(ctx context
 		return s.getPost
 	case "GetPosts":
 		return s.getPosts
+	case "GetPostsPage":
+		return s.getPostsPage
 	default:
 		return nil
 	}
@@ -553,6 +641,39 @@ func (s remoteBlogService_server_stub) getPosts(ctx context.Context, args []byte
 	return enc.Data(), nil
 }
 
+func (s remoteBlogService_server_stub) getPostsPage(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+	var a2 uint64
+	a2 = dec.Uint64()
+	var a3 string
+	a3 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, r2, appErr := s.impl.GetPostsPage(ctx, a0, a1, a2, a3)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_RawBlogPost_43e1e8b0(enc, r0)
+	enc.String(r1)
+	enc.String(r2)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 // Reflect stub implementations.
 
 type remoteBlogService_reflect_stub struct {
@@ -582,6 +703,11 @@ func (s remoteBlogService_reflect_stub) GetPosts(ctx context.Context, a0 uint64,
 	return
 }
 
+func (s remoteBlogService_reflect_stub) GetPostsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawBlogPost, r1 string, r2 string, err error) {
+	err = s.caller("GetPostsPage", ctx, []any{a0, a1, a2, a3}, []any{&r0, &r1, &r2})
+	return
+}
+
 // AutoMarshal implementations.
 
 var _ codegen.AutoMarshal = (*RawBlogPost)(nil)
@@ -593,6 +719,7 @@ type __is_RawBlogPost[T ~struct {
 	CreatedAt int64
 	Title     string
 	Content   string
+	Snippet   string
 }] struct{}
 
 var _ __is_RawBlogPost[RawBlogPost]
@@ -606,6 +733,7 @@ func (x *RawBlogPost) WeaverMarshal(enc *codegen.Encoder) {
 	enc.Int64(x.CreatedAt)
 	enc.String(x.Title)
 	enc.String(x.Content)
+	enc.String(x.Snippet)
 }
 
 func (x *RawBlogPost) WeaverUnmarshal(dec *codegen.Decoder) {
@@ -617,6 +745,7 @@ func (x *RawBlogPost) WeaverUnmarshal(dec *codegen.Decoder) {
 	x.CreatedAt = dec.Int64()
 	x.Title = dec.String()
 	x.Content = dec.String()
+	x.Snippet = dec.String()
 }
 
 var _ codegen.AutoMarshal = (*RawForumContent)(nil)
@@ -22,26 +22,63 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/dvaumoron/puzzleweb/common"
 )
 
 const (
-	DBAccessMsg  = "Failed to access database"
-	MongoCallMsg = "Failed during MongoDB call"
-	RedisCallMsg = "Failed during Redis call"
+	DBAccessMsg     = "Failed to access database"
+	MongoCallMsg    = "Failed during MongoDB call"
+	RedisCallMsg    = "Failed during Redis call"
+	SessionStoreMsg = "Failed during session store call"
+	EncryptionMsg   = "Failed to encrypt or decrypt data"
 
 	LangPlaceHolder = "{{lang}}"
+
+	shutdownTimeout = 10 * time.Second
 )
 
 var (
 	ErrInternal        = errors.New("internal service error")
 	ErrNolocales       = errors.New("no locales declared")
 	ErrPictureNotFound = errors.New("picture not found")
+	// displayed on the login pages, their messages are locale keys
+	ErrLocked            = errors.New("AccountLocked")
+	ErrLoginPageRequired = errors.New("LoginPageRequired")
+	ErrTotpEnabled       = errors.New("TotpEnabled")
+	ErrWrongTotp         = errors.New("WrongTotpCode")
+
+	ErrEmailDisabled     = errors.New("EmailDisabled")
+	ErrEmailAlreadySent  = errors.New("EmailAlreadySent")
+	ErrExistingEmail     = errors.New("ExistingEmail")
+	ErrExpiredEmailToken = errors.New("ExpiredEmailToken")
+	ErrWrongEmail        = errors.New("WrongEmail")
+	ErrWrongEmailToken   = errors.New("WrongEmailToken")
 )
 
 type LoggerGetter interface {
 	Logger(context.Context) *slog.Logger
 }
 
+type Shutdowner interface {
+	Shutdown(context.Context) error
+}
+
+// CallShutdownOnExit arranges the call of the Shutdown of a component (to stop its background
+// goroutines and close its clients) when the process receives an exit signal, the weaver runtime does not call it.
+func CallShutdownOnExit(logger *slog.Logger, component Shutdowner) {
+	runtime.OnExitSignal(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := component.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shut down component", common.ErrorKey, err)
+		}
+	})
+}
+
 func ConvertSlice[T any, R any](docs []T, converter func(T) R) []R {
 	resSlice := make([]R, 0, len(docs))
 	for _, doc := range docs {
@@ -28,6 +28,8 @@ type GalleryImage struct {
 
 type GalleryService interface {
 	GetImages(ctx context.Context, galleryId uint64, start uint64, end uint64) (uint64, []GalleryImage, error)
+	// keyset variant of GetImages, return the images, then next and previous cursors
+	GetImagesPage(ctx context.Context, galleryId uint64, cursor string, size uint64) ([]GalleryImage, string, string, error)
 	GetImage(ctx context.Context, imageId uint64) (GalleryImage, error)
 	GetImageData(ctx context.Context, imageId uint64) ([]byte, error)
 	UpdateImage(ctx context.Context, galleryId uint64, info GalleryImage, data []byte) (uint64, error)
@@ -19,8 +19,11 @@ package galleryimpl
 
 import (
 	"context"
+	"log/slog"
 
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	galleryservice "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/gallery/service"
 
@@ -40,35 +43,58 @@ const (
 	titleKey     = "title"
 	descKey      = "desc"
 	imageKey     = "imageData"
+
+	imageIdsSequence  = "imageIds"
+	imagesUniqueIndex = "images_gallery_image"
 )
 
+// ensured (or checked) when creating the service and by the indexes mode of the binary
+var Indexes = mongoclient.Indexes{Component: "gallery", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(imagesUniqueIndex, galleryIdKey, imageIdKey),
+}}
+
 var (
 	optsCreateUnexisting     = options.Update().SetUpsert(true)
-	optsMaxImageId           = options.FindOne().SetSort(bson.D{{Key: imageIdKey, Value: -1}}).SetProjection(bson.D{{Key: imageIdKey, Value: true}})
 	optsOnlyImageField       = options.FindOne().SetProjection(bson.D{{Key: imageKey, Value: true}})
 	optsOneExcludeImageField = options.FindOne().SetProjection(bson.D{{Key: imageKey, Value: false}})
 )
 
+// applied with the migrate mode of the binary, checked when creating the service
+var Migrations = mongoclient.Migrations{Component: "gallery", Steps: []mongoclient.Migration{{
+	Version: 1, Name: "seed image id counters from existing images",
+	Up:   mongoclient.SeedSequence(imageIdsSequence, collectionName, galleryIdKey, imageIdKey),
+	Down: mongoclient.DropSequence(imageIdsSequence),
+}}}
+
 type galleryImpl struct {
-	clientOptions *options.ClientOptions
-	databaseName  string
-	loggerGetter  servicecommon.LoggerGetter
+	collection *mongo.Collection
+	imageIds   mongoclient.Sequence
 }
 
-func New(serverAddress string, databaseName string, loggerGetter servicecommon.LoggerGetter) galleryservice.GalleryService {
-	clientOptions := mongoclient.New(serverAddress)
-	return galleryImpl{clientOptions: clientOptions, databaseName: databaseName, loggerGetter: loggerGetter}
-}
+// indexMode is "ensure" (default) or "check", the returned function releases the shared MongoDB client
+func New(ctx context.Context, logger *slog.Logger, serverAddress string, databaseName string, mongoOptions mongoclient.Options, certificates *tlsclient.Certificates, indexMode string) (galleryservice.GalleryService, func(context.Context) error, error) {
+	client, err := mongoclient.Connect(ctx, logger, serverAddress, mongoOptions, certificates)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func (impl galleryImpl) GetImages(ctx context.Context, galleryId uint64, start uint64, end uint64) (uint64, []galleryservice.GalleryImage, error) {
-	logger := impl.loggerGetter.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.clientOptions)
+	database := client.Database(databaseName)
+	if err = Indexes.Apply(ctx, database, indexMode); err == nil {
+		err = Migrations.Check(ctx, database)
+	}
 	if err != nil {
-		return 0, nil, err
+		mongoclient.Release(ctx, client)
+		return nil, nil, err
 	}
-	defer mongoclient.Disconnect(client, ctx, logger)
 
-	collection := client.Database(impl.databaseName).Collection(collectionName)
+	release := func(ctx context.Context) error {
+		return mongoclient.Release(ctx, client)
+	}
+	return galleryImpl{collection: database.Collection(collectionName), imageIds: mongoclient.NewSequence(database, imageIdsSequence)}, release, nil
+}
+
+func (impl galleryImpl) GetImages(ctx context.Context, galleryId uint64, start uint64, end uint64) (uint64, []galleryservice.GalleryImage, error) {
+	collection := impl.collection
 	filter := bson.D{{Key: galleryIdKey, Value: galleryId}}
 
 	total, err := collection.CountDocuments(ctx, filter)
@@ -88,18 +114,34 @@ func (impl galleryImpl) GetImages(ctx context.Context, galleryId uint64, start u
 	return uint64(total), servicecommon.ConvertSlice(results, convertToImage), nil
 }
 
-func (impl galleryImpl) GetImage(ctx context.Context, imageId uint64) (galleryservice.GalleryImage, error) {
-	logger := impl.loggerGetter.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.clientOptions)
+func (impl galleryImpl) GetImagesPage(ctx context.Context, galleryId uint64, cursor string, size uint64) ([]galleryservice.GalleryImage, string, string, error) {
+	position, err := clientcommon.DecodeCursor(cursor)
 	if err != nil {
-		return galleryservice.GalleryImage{}, err
+		return nil, "", "", err
 	}
-	defer mongoclient.Disconnect(client, ctx, logger)
 
-	collection := client.Database(impl.databaseName).Collection(collectionName)
+	collection := impl.collection
+	filter, opts := mongoclient.KeysetPaginate(bson.D{{Key: galleryIdKey, Value: galleryId}}, imageIdKey, position, size, true)
+
+	mongoCursor, err := collection.Find(ctx, filter, opts.SetProjection(bson.D{{Key: imageKey, Value: false}}))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	var results []bson.M
+	if err = mongoCursor.All(ctx, &results); err != nil {
+		return nil, "", "", err
+	}
+
+	results, next, prev := clientcommon.BuildPage(results, position, size, imagePosition)
+	return servicecommon.ConvertSlice(results, convertToImage), next, prev, nil
+}
+
+func (impl galleryImpl) GetImage(ctx context.Context, imageId uint64) (galleryservice.GalleryImage, error) {
+	collection := impl.collection
 
 	var result bson.M
-	err = collection.FindOne(
+	err := collection.FindOne(
 		ctx, bson.D{{Key: imageIdKey, Value: imageId}}, optsOneExcludeImageField,
 	).Decode(&result)
 	if err != nil {
@@ -109,17 +151,10 @@ func (impl galleryImpl) GetImage(ctx context.Context, imageId uint64) (galleryse
 }
 
 func (impl galleryImpl) GetImageData(ctx context.Context, imageId uint64) ([]byte, error) {
-	logger := impl.loggerGetter.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.clientOptions)
-	if err != nil {
-		return nil, err
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.databaseName).Collection(collectionName)
+	collection := impl.collection
 
 	var result bson.D
-	err = collection.FindOne(
+	err := collection.FindOne(
 		ctx, bson.D{{Key: imageIdKey, Value: imageId}}, optsOnlyImageField,
 	).Decode(&result)
 	if err != nil {
@@ -131,14 +166,7 @@ func (impl galleryImpl) GetImageData(ctx context.Context, imageId uint64) ([]byt
 }
 
 func (impl galleryImpl) UpdateImage(ctx context.Context, galleryId uint64, info galleryservice.GalleryImage, data []byte) (uint64, error) {
-	logger := impl.loggerGetter.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.clientOptions)
-	if err != nil {
-		return 0, err
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.databaseName).Collection(collectionName)
+	collection := impl.collection
 
 	imageId := info.ImageId
 	image := bson.M{galleryIdKey: galleryId, imageIdKey: imageId, userIdKey: info.CreatorId, titleKey: info.Title, descKey: info.Desc}
@@ -147,22 +175,15 @@ func (impl galleryImpl) UpdateImage(ctx context.Context, galleryId uint64, info
 	}
 
 	if imageId == 0 {
-		return createImage(collection, ctx, image)
+		return createImage(collection, impl.imageIds, ctx, galleryId, image)
 	}
 	return imageId, updateImage(collection, ctx, image)
 }
 
 func (impl galleryImpl) DeleteImage(ctx context.Context, imageId uint64) error {
-	logger := impl.loggerGetter.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.clientOptions)
-	if err != nil {
-		return err
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.databaseName).Collection(collectionName)
+	collection := impl.collection
 
-	_, err = collection.DeleteMany(
+	_, err := collection.DeleteMany(
 		ctx, bson.D{{Key: imageIdKey, Value: imageId}},
 	)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -171,34 +192,14 @@ func (impl galleryImpl) DeleteImage(ctx context.Context, imageId uint64) error {
 	return nil
 }
 
-func createImage(collection *mongo.Collection, ctx context.Context, image bson.M) (uint64, error) {
-	// rely on the mongo server to ensure there will be no duplicate
-	imageId := uint64(1)
-
-	var err error
-	var result bson.D
-GenerateImageIdStep:
-	err = collection.FindOne(ctx, bson.D{{Key: galleryIdKey, Value: image[galleryIdKey]}}, optsMaxImageId).Decode(&result)
+func createImage(collection *mongo.Collection, imageIds mongoclient.Sequence, ctx context.Context, galleryId uint64, image bson.M) (uint64, error) {
+	imageId, err := imageIds.Next(ctx, galleryId)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			goto CreateImageStep
-		}
-
 		return 0, err
 	}
 
-	// call [1] to get imageId because result has only the id and one field
-	imageId = mongoclient.ExtractUint64(result[1].Value) + 1
-
-CreateImageStep:
 	image[imageIdKey] = imageId
-	_, err = collection.InsertOne(ctx, image)
-	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			// retry
-			goto GenerateImageIdStep
-		}
-
+	if _, err = collection.InsertOne(ctx, image); err != nil {
 		return 0, err
 	}
 	return imageId, nil
@@ -218,6 +219,11 @@ func initPaginationOpts(start uint64, end uint64) *options.FindOptions {
 	return opts.SetSkip(castedStart).SetLimit(int64(end) - castedStart)
 }
 
+// only the id, the pages stay in one scope of its sequence (see mongoclient.KeysetPaginate)
+func imagePosition(image bson.M) clientcommon.Cursor {
+	return clientcommon.Cursor{Id: mongoclient.ExtractUint64(image[imageIdKey])}
+}
+
 func convertToImage(image bson.M) galleryservice.GalleryImage {
 	title, _ := image[titleKey].(string)
 	desc, _ := image[descKey].(string)
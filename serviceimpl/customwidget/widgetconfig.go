@@ -19,8 +19,11 @@
 package customwidgetimpl
 
 import (
+	"context"
 	"log/slog"
 
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	gallerywidget "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/gallery"
 	galleryimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/gallery/service/impl"
@@ -30,23 +33,39 @@ import (
 type widgetConf struct {
 	GalleryMongoAddress      string
 	GalleryMongoDatabaseName string
+	GalleryMongoOptions      mongoclient.Options
+	GalleryMongoTLS          tlsclient.Conf
+	GalleryIndexMode         string
 	DefaultPageSize          uint64
 	KeyToValues              map[string]string
 }
 
 type initializedWidgetConf struct {
-	widgets widgethelper.WidgetManager
+	widgets        widgethelper.WidgetManager
+	releaseGallery func(context.Context) error
+	certificates   *tlsclient.Certificates
 }
 
-func initWidgetConf(loggerGetter servicecommon.LoggerGetter, logger *slog.Logger, conf *widgetConf) (initializedWidgetConf, error) {
-	galleryService := galleryimpl.New(conf.GalleryMongoAddress, conf.GalleryMongoDatabaseName, loggerGetter)
+func initWidgetConf(ctx context.Context, loggerGetter servicecommon.LoggerGetter, logger *slog.Logger, conf *widgetConf) (initializedWidgetConf, error) {
+	certificates, err := tlsclient.Load(logger, conf.GalleryMongoTLS)
+	if err != nil {
+		return initializedWidgetConf{}, err
+	}
+
+	galleryService, releaseGallery, err := galleryimpl.New(ctx, logger, conf.GalleryMongoAddress, conf.GalleryMongoDatabaseName, conf.GalleryMongoOptions, certificates, conf.GalleryIndexMode)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedWidgetConf{}, err
+	}
 
 	widgets := widgethelper.NewManager()
 	gallerywidget.InitWidget(widgets, logger, galleryService, conf.DefaultPageSize)
 	for _, registerer := range widgethelper.Registerers {
 		if err := registerer(widgets, conf.KeyToValues, loggerGetter); err != nil {
+			releaseGallery(ctx)
+			tlsclient.Release(certificates)
 			return initializedWidgetConf{}, err
 		}
 	}
-	return initializedWidgetConf{widgets: widgets}, nil
+	return initializedWidgetConf{widgets: widgets, releaseGallery: releaseGallery, certificates: certificates}, nil
 }
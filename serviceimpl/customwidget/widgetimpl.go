@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 
 	"github.com/ServiceWeaver/weaver"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	widgethelper "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/helper"
 	customwidgetservice "github.com/dvaumoron/puzzleweaver/serviceimpl/customwidget/service"
@@ -45,10 +46,20 @@ type remoteWidgetImpl struct {
 }
 
 func (impl *remoteWidgetImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initWidgetConf(impl, impl.Logger(ctx), impl.Config())
+	impl.initializedConf, err = initWidgetConf(ctx, impl, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// releases the MongoDB client of the gallery and its TLS certificates
+func (impl *remoteWidgetImpl) Shutdown(ctx context.Context) error {
+	err := impl.initializedConf.releaseGallery(ctx)
+	tlsclient.Release(impl.initializedConf.certificates)
+	return err
+}
+
 func (impl *remoteWidgetImpl) GetDesc(ctx context.Context, widgetName string) ([]customwidgetservice.RawWidgetAction, error) {
 	widget, ok := impl.initializedConf.widgets[widgetName]
 	if !ok {
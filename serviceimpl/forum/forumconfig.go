@@ -20,25 +20,44 @@ package forumimpl
 
 import (
 	"context"
+	"log/slog"
 
-	"github.com/dvaumoron/puzzleforumserver/model"
 	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	"gorm.io/gorm"
 )
 
 type forumConf struct {
 	DatabaseKind    string
 	DatabaseAddress string
+	DatabaseTLS     tlsclient.Conf
+	// "native" (default) or "like"
+	SearchMode string
 }
 
 type initializedForumConf struct {
-	db *gorm.DB
+	db           *gorm.DB
+	searcher     dbclient.Searcher
+	certificates *tlsclient.Certificates
 }
 
-func initForumConf(ctx context.Context, conf *forumConf) (initializedForumConf, error) {
-	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress)
+func initForumConf(ctx context.Context, logger *slog.Logger, conf *forumConf) (initializedForumConf, error) {
+	certificates, err := tlsclient.Load(logger, conf.DatabaseTLS)
+	if err != nil {
+		return initializedForumConf{}, err
+	}
+
+	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress, certificates)
 	if err == nil {
-		err = db.AutoMigrate(&model.Thread{}, &model.Message{})
+		err = Migrations.Check(db)
+	}
+	var searcher dbclient.Searcher
+	if err == nil {
+		searcher, err = dbclient.NewSearcher(db, conf.SearchMode)
+	}
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedForumConf{}, err
 	}
-	return initializedForumConf{db: db}, err
+	return initializedForumConf{db: db, searcher: searcher, certificates: certificates}, nil
 }
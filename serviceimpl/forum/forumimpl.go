@@ -23,12 +23,19 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	"github.com/dvaumoron/puzzleforumserver/model"
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
 	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"gorm.io/gorm"
 )
 
+const (
+	titleColumn = "title"
+	textColumn  = "text"
+)
+
 type remoteForumImpl struct {
 	weaver.Implements[RemoteForumService]
 	weaver.WithConfig[forumConf]
@@ -36,10 +43,19 @@ type remoteForumImpl struct {
 }
 
 func (impl *remoteForumImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initForumConf(ctx, impl.Config())
+	impl.initializedConf, err = initForumConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// releases the TLS certificates
+func (impl *remoteForumImpl) Shutdown(ctx context.Context) error {
+	tlsclient.Release(impl.initializedConf.certificates)
+	return nil
+}
+
 func (impl *remoteForumImpl) CreateThread(ctx context.Context, objectId uint64, userId uint64, title string, message string) (uint64, error) {
 	db := impl.initializedConf.db.WithContext(ctx)
 	thread := model.Thread{
@@ -67,53 +83,33 @@ func (impl *remoteForumImpl) CreateMessage(ctx context.Context, objectId uint64,
 
 func (impl *remoteForumImpl) GetThread(ctx context.Context, objectId uint64, threadId uint64, start uint64, end uint64, filter string) (uint64, RawForumContent, []RawForumContent, error) {
 	db := impl.initializedConf.db.WithContext(ctx)
-
-	paginate := func(tx *gorm.DB) *gorm.DB {
-		return dbclient.Paginate(tx, start, end).Order("created_at asc")
-	}
-
-	preloadFilter := paginate
-	messageRequest := db.Model(&model.Message{})
-	if filter == "" {
-		messageRequest.Where("thread_id = ?", threadId)
-	} else {
-		filter = dbclient.BuildLikeFilter(filter)
-		messageRequest.Where("thread_id = ? AND text LIKE ?", threadId, filter)
-
-		preloadFilter = func(tx *gorm.DB) *gorm.DB {
-			return paginate(tx).Where("text LIKE ?", filter)
-		}
-	}
+	search := impl.initializedConf.searcher.Parse(filter)
 
 	var total int64
-	err := messageRequest.Count(&total).Error
+	err := search.Where(db.Model(&model.Message{}).Where("thread_id = ?", threadId), textColumn).Count(&total).Error
 	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return 0, RawForumContent{}, nil, servicecommon.ErrInternal
 	}
 
+	preloadFilter := func(tx *gorm.DB) *gorm.DB {
+		return search.Order(dbclient.Paginate(search.Where(tx, textColumn), start, end), textColumn, "created_at asc")
+	}
+
 	var thread model.Thread
 	if err := db.Preload("Messages", preloadFilter).First(&thread, threadId).Error; err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return 0, RawForumContent{}, nil, servicecommon.ErrInternal
 	}
-	return uint64(total), convertThreadFromModel(thread), convertMessagesFromModel(thread.Messages), nil
+	return uint64(total), convertThreadFromModel(thread), addSnippets(convertMessagesFromModel(thread.Messages), search), nil
 }
 
 func (impl *remoteForumImpl) GetThreads(ctx context.Context, objectId uint64, start uint64, end uint64, filter string) (uint64, []RawForumContent, error) {
 	db := impl.initializedConf.db.WithContext(ctx)
-	noFilter := filter == ""
-
-	threadRequest := db.Model(&model.Thread{})
-	if noFilter {
-		threadRequest.Where("object_id = ?", objectId)
-	} else {
-		filter = dbclient.BuildLikeFilter(filter)
-		threadRequest.Where("object_id = ? AND title LIKE ?", objectId, filter)
-	}
+	search := impl.initializedConf.searcher.Parse(filter)
 
 	var total int64
-	err := threadRequest.Count(&total).Error
+	err := search.Where(db.Model(&model.Thread{}).Where("object_id = ?", objectId), titleColumn).Count(&total).Error
 	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return 0, nil, servicecommon.ErrInternal
@@ -123,18 +119,61 @@ func (impl *remoteForumImpl) GetThreads(ctx context.Context, objectId uint64, st
 	}
 
 	var threads []model.Thread
-	page := dbclient.Paginate(db, start, end).Order("created_at desc")
-	if noFilter {
-		err = page.Find(&threads, "object_id = ?", objectId).Error
-	} else {
-		err = page.Find(&threads, "object_id = ? AND title LIKE ?", objectId, filter).Error
+	page := search.Order(dbclient.Paginate(search.Where(db, titleColumn), start, end), titleColumn, "created_at desc")
+	if err = page.Find(&threads, "object_id = ?", objectId).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return 0, nil, servicecommon.ErrInternal
 	}
+	return uint64(total), addSnippets(convertThreadsFromModel(threads), search), nil
+}
 
+// keyset variants keep the (created_at, id) order, the search only filter
+func (impl *remoteForumImpl) GetThreadPage(ctx context.Context, objectId uint64, threadId uint64, cursor string, size uint64, filter string) (RawForumContent, []RawForumContent, string, string, error) {
+	position, err := clientcommon.DecodeCursor(cursor)
 	if err != nil {
+		return RawForumContent{}, nil, "", "", err
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	var thread model.Thread
+	if err = db.First(&thread, threadId).Error; err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
-		return 0, nil, servicecommon.ErrInternal
+		return RawForumContent{}, nil, "", "", servicecommon.ErrInternal
 	}
-	return uint64(total), convertThreadsFromModel(threads), nil
+
+	search := impl.initializedConf.searcher.Parse(filter)
+	messageRequest := dbclient.KeysetPaginate(db, position, size, false).Where("thread_id = ?", threadId)
+	messageRequest = search.Where(messageRequest, textColumn)
+
+	var messages []model.Message
+	if err = messageRequest.Find(&messages).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return RawForumContent{}, nil, "", "", servicecommon.ErrInternal
+	}
+
+	messages, next, prev := clientcommon.BuildPage(messages, position, size, messagePosition)
+	return convertThreadFromModel(thread), addSnippets(convertMessagesFromModel(messages), search), next, prev, nil
+}
+
+func (impl *remoteForumImpl) GetThreadsPage(ctx context.Context, objectId uint64, cursor string, size uint64, filter string) ([]RawForumContent, string, string, error) {
+	position, err := clientcommon.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	search := impl.initializedConf.searcher.Parse(filter)
+	threadRequest := dbclient.KeysetPaginate(db, position, size, true).Where("object_id = ?", objectId)
+	threadRequest = search.Where(threadRequest, titleColumn)
+
+	var threads []model.Thread
+	if err = threadRequest.Find(&threads).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return nil, "", "", servicecommon.ErrInternal
+	}
+
+	threads, next, prev := clientcommon.BuildPage(threads, position, size, threadPosition)
+	return addSnippets(convertThreadsFromModel(threads), search), next, prev, nil
 }
 
 func (impl *remoteForumImpl) DeleteThread(ctx context.Context, containerId uint64, id uint64) error {
@@ -155,6 +194,14 @@ func (impl *remoteForumImpl) DeleteMessage(ctx context.Context, containerId uint
 	return nil
 }
 
+func threadPosition(thread model.Thread) clientcommon.Cursor {
+	return clientcommon.Cursor{CreatedAt: thread.CreatedAt, Id: thread.ID}
+}
+
+func messagePosition(message model.Message) clientcommon.Cursor {
+	return clientcommon.Cursor{CreatedAt: message.CreatedAt, Id: message.ID}
+}
+
 func convertThreadFromModel(thread model.Thread) RawForumContent {
 	return RawForumContent{
 		Id: thread.ID, CreatedAt: thread.CreatedAt.Unix(), CreatorId: thread.UserId, Text: thread.Title,
@@ -178,3 +225,12 @@ func convertMessagesFromModel(messages []model.Message) []RawForumContent {
 	}
 	return resMessages
 }
+
+func addSnippets(contents []RawForumContent, search dbclient.Search) []RawForumContent {
+	if !search.Empty() {
+		for i, content := range contents {
+			contents[i].Snippet = search.Snippet(content.Text)
+		}
+	}
+	return contents
+}
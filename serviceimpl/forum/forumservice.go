@@ -30,6 +30,8 @@ type RawForumContent struct {
 	CreatorId uint64
 	CreatedAt int64
 	Text      string
+	// html extract of Text with the filter terms highlighted, empty without filter
+	Snippet string
 }
 
 type RemoteForumService interface {
@@ -37,6 +39,9 @@ type RemoteForumService interface {
 	CreateMessage(ctx context.Context, objectId uint64, userId uint64, threadId uint64, message string) error
 	GetThread(ctx context.Context, objectId uint64, threadId uint64, start uint64, end uint64, filter string) (uint64, RawForumContent, []RawForumContent, error)
 	GetThreads(ctx context.Context, objectId uint64, start uint64, end uint64, filter string) (uint64, []RawForumContent, error)
+	// keyset variants of GetThread and GetThreads, return the contents, then next and previous cursors
+	GetThreadPage(ctx context.Context, objectId uint64, threadId uint64, cursor string, size uint64, filter string) (RawForumContent, []RawForumContent, string, string, error)
+	GetThreadsPage(ctx context.Context, objectId uint64, cursor string, size uint64, filter string) ([]RawForumContent, string, string, error)
 	DeleteThread(ctx context.Context, containerId uint64, id uint64) error
 	DeleteMessage(ctx context.Context, containerId uint64, id uint64) error
 }
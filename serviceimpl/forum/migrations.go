@@ -0,0 +1,95 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package forumimpl
+
+import (
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	"gorm.io/gorm"
+)
+
+// applied with the migrate mode of the binary, checked at component start,
+// the steps use copies of the models at their version, so they do not follow the later changes
+var Migrations = dbclient.Migrations{Component: "forum", Steps: []dbclient.Migration{{
+	Version: 1, Name: "create threads and messages tables",
+	Up: dbclient.AdoptTables(&threadsV1{}, &messagesV1{}), Down: dbclient.DropTables(&messagesV1{}, &threadsV1{}),
+}, {
+	Version: 2, Name: "index threads and messages for keyset pagination",
+	Up: func(tx *gorm.DB) error {
+		err := dbclient.CreateIndex(&threadsV1{}, threadsKeysetIndex, "object_id", "created_at", "id")(tx)
+		if err != nil {
+			return err
+		}
+		return dbclient.CreateIndex(&messagesV1{}, messagesKeysetIndex, "thread_id", "created_at", "id")(tx)
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dbclient.DropIndex(&messagesV1{}, messagesKeysetIndex)(tx); err != nil {
+			return err
+		}
+		return dbclient.DropIndex(&threadsV1{}, threadsKeysetIndex)(tx)
+	},
+}, {
+	Version: 3, Name: "full text search indexes on thread titles and message texts",
+	Up: func(tx *gorm.DB) error {
+		if err := dbclient.CreateSearchIndex(&threadsV1{}, threadsSearchIndex, titleColumn)(tx); err != nil {
+			return err
+		}
+		return dbclient.CreateSearchIndex(&messagesV1{}, messagesSearchIndex, textColumn)(tx)
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dbclient.DropSearchIndex(&messagesV1{}, messagesSearchIndex)(tx); err != nil {
+			return err
+		}
+		return dbclient.DropSearchIndex(&threadsV1{}, threadsSearchIndex)(tx)
+	},
+}}}
+
+const (
+	threadsKeysetIndex  = "idx_threads_object_id_created_at_id"
+	messagesKeysetIndex = "idx_messages_thread_id_created_at_id"
+	threadsSearchIndex  = "idx_threads_title_search"
+	messagesSearchIndex = "idx_messages_text_search"
+)
+
+// model.Thread of puzzleforumserver
+type threadsV1 struct {
+	ID        uint64
+	CreatedAt time.Time
+	ObjectId  uint64
+	UserId    uint64
+	Title     string
+	Messages  []messagesV1 `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE;"`
+}
+
+func (threadsV1) TableName() string {
+	return "threads"
+}
+
+// model.Message of puzzleforumserver
+type messagesV1 struct {
+	ID        uint64
+	CreatedAt time.Time
+	ThreadID  uint64
+	UserId    uint64
+	Text      string
+}
+
+func (messagesV1) TableName() string {
+	return "messages"
+}
@@ -20,10 +20,10 @@ func init() {
 		Iface: reflect.TypeOf((*RemoteForumService)(nil)).Elem(),
 		Impl:  reflect.TypeOf(remoteForumImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return remoteForumService_local_stub{impl: impl.(RemoteForumService), tracer: tracer, createMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateMessage", Remote: false}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateThread", Remote: false}), deleteMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteMessage", Remote: false}), deleteThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteThread", Remote: false}), getThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThread", Remote: false}), getThreadsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreads", Remote: false})}
+			return remoteForumService_local_stub{impl: impl.(RemoteForumService), tracer: tracer, createMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateMessage", Remote: false}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateThread", Remote: false}), deleteMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteMessage", Remote: false}), deleteThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteThread", Remote: false}), getThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThread", Remote: false}), getThreadPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreadPage", Remote: false}), getThreadsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreads", Remote: false}), getThreadsPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreadsPage", Remote: false})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return remoteForumService_client_stub{stub: stub, createMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateMessage", Remote: true}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateThread", Remote: true}), deleteMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteMessage", Remote: true}), deleteThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteThread", Remote: true}), getThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThread", Remote: true}), getThreadsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreads", Remote: true})}
+			return remoteForumService_client_stub{stub: stub, createMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateMessage", Remote: true}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "CreateThread", Remote: true}), deleteMessageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteMessage", Remote: true}), deleteThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "DeleteThread", Remote: true}), getThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThread", Remote: true}), getThreadPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreadPage", Remote: true}), getThreadsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreads", Remote: true}), getThreadsPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/forum/RemoteForumService", Method: "GetThreadsPage", Remote: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return remoteForumService_server_stub{impl: impl.(RemoteForumService), addLoad: addLoad}
@@ -44,14 +44,16 @@ var _ weaver.Unrouted = (*remoteForumImpl)(nil)
 // Local stub implementations.
 
 type remoteForumService_local_stub struct {
-	impl                 RemoteForumService
-	tracer               trace.Tracer
-	createMessageMetrics *codegen.MethodMetrics
-	createThreadMetrics  *codegen.MethodMetrics
-	deleteMessageMetrics *codegen.MethodMetrics
-	deleteThreadMetrics  *codegen.MethodMetrics
-	getThreadMetrics     *codegen.MethodMetrics
-	getThreadsMetrics    *codegen.MethodMetrics
+	impl                  RemoteForumService
+	tracer                trace.Tracer
+	createMessageMetrics  *codegen.MethodMetrics
+	createThreadMetrics   *codegen.MethodMetrics
+	deleteMessageMetrics  *codegen.MethodMetrics
+	deleteThreadMetrics   *codegen.MethodMetrics
+	getThreadMetrics      *codegen.MethodMetrics
+	getThreadPageMetrics  *codegen.MethodMetrics
+	getThreadsMetrics     *codegen.MethodMetrics
+	getThreadsPageMetrics *codegen.MethodMetrics
 }
 
 // Check that remoteForumService_local_stub implements the RemoteForumService interface.
@@ -157,6 +159,26 @@ func (s remoteForumService_local_stub) GetThread(ctx context.Context, a0 uint64,
 	return s.impl.GetThread(ctx, a0, a1, a2, a3, a4)
 }
 
+func (s remoteForumService_local_stub) GetThreadPage(ctx context.Context, a0 uint64, a1 uint64, a2 string, a3 uint64, a4 string) (r0 RawForumContent, r1 []RawForumContent, r2 string, r3 string, err error) {
+	// Update metrics.
+	begin := s.getThreadPageMetrics.Begin()
+	defer func() { s.getThreadPageMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "forumimpl.RemoteForumService.GetThreadPage", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetThreadPage(ctx, a0, a1, a2, a3, a4)
+}
+
 func (s remoteForumService_local_stub) GetThreads(ctx context.Context, a0 uint64, a1 uint64, a2 uint64, a3 string) (r0 uint64, r1 []RawForumContent, err error) {
 	// Update metrics.
 	begin := s.getThreadsMetrics.Begin()
@@ -177,16 +199,38 @@ func (s remoteForumService_local_stub) GetThreads(ctx context.Context, a0 uint64
 	return s.impl.GetThreads(ctx, a0, a1, a2, a3)
 }
 
+func (s remoteForumService_local_stub) GetThreadsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawForumContent, r1 string, r2 string, err error) {
+	// Update metrics.
+	begin := s.getThreadsPageMetrics.Begin()
+	defer func() { s.getThreadsPageMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "forumimpl.RemoteForumService.GetThreadsPage", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetThreadsPage(ctx, a0, a1, a2, a3)
+}
+
 // Client stub implementations.
 
 type remoteForumService_client_stub struct {
-	stub                 codegen.Stub
-	createMessageMetrics *codegen.MethodMetrics
-	createThreadMetrics  *codegen.MethodMetrics
-	deleteMessageMetrics *codegen.MethodMetrics
-	deleteThreadMetrics  *codegen.MethodMetrics
-	getThreadMetrics     *codegen.MethodMetrics
-	getThreadsMetrics    *codegen.MethodMetrics
+	stub                  codegen.Stub
+	createMessageMetrics  *codegen.MethodMetrics
+	createThreadMetrics   *codegen.MethodMetrics
+	deleteMessageMetrics  *codegen.MethodMetrics
+	deleteThreadMetrics   *codegen.MethodMetrics
+	getThreadMetrics      *codegen.MethodMetrics
+	getThreadPageMetrics  *codegen.MethodMetrics
+	getThreadsMetrics     *codegen.MethodMetrics
+	getThreadsPageMetrics *codegen.MethodMetrics
 }
 
 // Check that remoteForumService_client_stub implements the RemoteForumService interface.
@@ -495,6 +539,73 @@ func (s remoteForumService_client_stub) GetThread(ctx context.Context, a0 uint64
 	return
 }
 
+func (s remoteForumService_client_stub) GetThreadPage(ctx context.Context, a0 uint64, a1 uint64, a2 string, a3 uint64, a4 string) (r0 RawForumContent, r1 []RawForumContent, r2 string, r3 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getThreadPageMetrics.Begin()
+	defer func() { s.getThreadPageMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "forumimpl.RemoteForumService.GetThreadPage", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += 8
+	size += (4 + len(a2))
+	size += 8
+	size += (4 + len(a4))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.Uint64(a1)
+	enc.String(a2)
+	enc.Uint64(a3)
+	enc.String(a4)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	(&r0).WeaverUnmarshal(dec)
+	r1 = serviceweaver_dec_slice_RawForumContent_d2322a32(dec)
+	r2 = dec.String()
+	r3 = dec.String()
+	err = dec.Error()
+	return
+}
+
 func (s remoteForumService_client_stub) GetThreads(ctx context.Context, a0 uint64, a1 uint64, a2 uint64, a3 string) (r0 uint64, r1 []RawForumContent, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -543,7 +654,7 @@ func (s remoteForumService_client_stub) GetThreads(ctx context.Context, a0 uint6
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -558,6 +669,70 @@ func (s remoteForumService_client_stub) GetThreads(ctx context.Context, a0 uint6
 	return
 }
 
+func (s remoteForumService_client_stub) GetThreadsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawForumContent, r1 string, r2 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getThreadsPageMetrics.Begin()
+	defer func() { s.getThreadsPageMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "forumimpl.RemoteForumService.GetThreadsPage", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += (4 + len(a1))
+	size += 8
+	size += (4 + len(a3))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.String(a1)
+	enc.Uint64(a2)
+	enc.String(a3)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_RawForumContent_d2322a32(dec)
+	r1 = dec.String()
+	r2 = dec.String()
+	err = dec.Error()
+	return
+}
+
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
@@ -604,8 +779,12 @@ func (s remoteForumService_server_stub) GetStubFn(method string) func(ctx contex
 		return s.deleteThread
 	case "GetThread":
 		return s.getThread
+	case "GetThreadPage":
+		return s.getThreadPage
 	case "GetThreads":
 		return s.getThreads
+	case "GetThreadsPage":
+		return s.getThreadsPage
 	default:
 		return nil
 	}
@@ -759,6 +938,42 @@ func (s remoteForumService_server_stub) getThread(ctx context.Context, args []by
 	return enc.Data(), nil
 }
 
+func (s remoteForumService_server_stub) getThreadPage(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 uint64
+	a1 = dec.Uint64()
+	var a2 string
+	a2 = dec.String()
+	var a3 uint64
+	a3 = dec.Uint64()
+	var a4 string
+	a4 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, r2, r3, appErr := s.impl.GetThreadPage(ctx, a0, a1, a2, a3, a4)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	(r0).WeaverMarshal(enc)
+	serviceweaver_enc_slice_RawForumContent_d2322a32(enc, r1)
+	enc.String(r2)
+	enc.String(r3)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s remoteForumService_server_stub) getThreads(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -791,6 +1006,39 @@ func (s remoteForumService_server_stub) getThreads(ctx context.Context, args []b
 	return enc.Data(), nil
 }
 
+func (s remoteForumService_server_stub) getThreadsPage(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+	var a2 uint64
+	a2 = dec.Uint64()
+	var a3 string
+	a3 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, r2, appErr := s.impl.GetThreadsPage(ctx, a0, a1, a2, a3)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_RawForumContent_d2322a32(enc, r0)
+	enc.String(r1)
+	enc.String(r2)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 // Reflect stub implementations.
 
 type remoteForumService_reflect_stub struct {
@@ -825,11 +1073,21 @@ func (s remoteForumService_reflect_stub) GetThread(ctx context.Context, a0 uint6
 	return
 }
 
+func (s remoteForumService_reflect_stub) GetThreadPage(ctx context.Context, a0 uint64, a1 uint64, a2 string, a3 uint64, a4 string) (r0 RawForumContent, r1 []RawForumContent, r2 string, r3 string, err error) {
+	err = s.caller("GetThreadPage", ctx, []any{a0, a1, a2, a3, a4}, []any{&r0, &r1, &r2, &r3})
+	return
+}
+
 func (s remoteForumService_reflect_stub) GetThreads(ctx context.Context, a0 uint64, a1 uint64, a2 uint64, a3 string) (r0 uint64, r1 []RawForumContent, err error) {
 	err = s.caller("GetThreads", ctx, []any{a0, a1, a2, a3}, []any{&r0, &r1})
 	return
 }
 
+func (s remoteForumService_reflect_stub) GetThreadsPage(ctx context.Context, a0 uint64, a1 string, a2 uint64, a3 string) (r0 []RawForumContent, r1 string, r2 string, err error) {
+	err = s.caller("GetThreadsPage", ctx, []any{a0, a1, a2, a3}, []any{&r0, &r1, &r2})
+	return
+}
+
 // AutoMarshal implementations.
 
 var _ codegen.AutoMarshal = (*RawForumContent)(nil)
@@ -840,6 +1098,7 @@ type __is_RawForumContent[T ~struct {
 	CreatorId uint64
 	CreatedAt int64
 	Text      string
+	Snippet   string
 }] struct{}
 
 var _ __is_RawForumContent[RawForumContent]
@@ -852,6 +1111,7 @@ func (x *RawForumContent) WeaverMarshal(enc *codegen.Encoder) {
 	enc.Uint64(x.CreatorId)
 	enc.Int64(x.CreatedAt)
 	enc.String(x.Text)
+	enc.String(x.Snippet)
 }
 
 func (x *RawForumContent) WeaverUnmarshal(dec *codegen.Decoder) {
@@ -862,6 +1122,7 @@ func (x *RawForumContent) WeaverUnmarshal(dec *codegen.Decoder) {
 	x.CreatorId = dec.Uint64()
 	x.CreatedAt = dec.Int64()
 	x.Text = dec.String()
+	x.Snippet = dec.String()
 }
 
 // Encoding/decoding implementations.
@@ -0,0 +1,392 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvaumoron/puzzleloginserver/model"
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	mailclient "github.com/dvaumoron/puzzleweaver/client/mail"
+	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
+	"github.com/dvaumoron/puzzleweb/common"
+	"gorm.io/gorm"
+)
+
+const (
+	// RFC 5321 limit of a path
+	maxEmailSize = 254
+
+	emailTokenPurpose         = "email"
+	emailTokenName            = "token"
+	emailLinkPlaceholder      = "{link}"
+	defaultEmailTokenDuration = 24 * time.Hour
+	// between two verification messages for the same address
+	emailResendDelay = time.Minute
+
+	defaultEmailSubject = "Verification of your email address"
+	defaultEmailBody    = "Open this link to verify your email address :\n\n{link}\n\nIgnore this message if you did not ask for it.\n"
+)
+
+var errEmailVerifyConf = errors.New("the email verification needs EmailToken and EmailVerifyUrl")
+
+type userEmail struct {
+	UserId uint64 `gorm:"primaryKey;autoIncrement:false"`
+	// normalized, waiting for its verification while it differs from VerifiedEmail
+	Email string `gorm:"size:254;index"`
+	// the last verified address, used to log in,
+	// unique when not NULL (the index is created by the migration, not the tags)
+	VerifiedEmail *string `gorm:"size:254"`
+	// of the last verification message
+	SentAt *time.Time
+}
+
+func (userEmail) TableName() string {
+	return "user_emails"
+}
+
+func (e userEmail) verified() bool {
+	return e.VerifiedEmail != nil && *e.VerifiedEmail == e.Email
+}
+
+// sends the signed links of verification, disabled when mailer is nil
+type emailVerifier struct {
+	mailer        mailclient.Mailer
+	signer        *cryptoclient.Signer
+	tokenDuration time.Duration
+	verifyUrl     string
+	subject       string
+	body          string
+}
+
+func newEmailVerifier(logger *slog.Logger, conf *loginConf) (emailVerifier, error) {
+	mailer, err := mailclient.New(logger, conf.Mail)
+	if err != nil || mailer == nil {
+		return emailVerifier{}, err
+	}
+
+	signer, err := cryptoclient.LoadSigner(logger, conf.EmailToken)
+	if err == nil && (signer == nil || conf.EmailVerifyUrl == "") {
+		err = errEmailVerifyConf
+	}
+	if err != nil {
+		mailer.Close()
+		return emailVerifier{}, err
+	}
+
+	v := emailVerifier{
+		mailer: mailer, signer: signer, tokenDuration: conf.EmailTokenDuration,
+		verifyUrl: conf.EmailVerifyUrl, subject: conf.EmailSubject, body: conf.EmailBody,
+	}
+	if v.tokenDuration <= 0 {
+		v.tokenDuration = defaultEmailTokenDuration
+	}
+	if v.subject == "" {
+		v.subject = defaultEmailSubject
+	}
+	if v.body == "" {
+		v.body = defaultEmailBody
+	}
+	return v, nil
+}
+
+func (v emailVerifier) enabled() bool {
+	return v.mailer != nil
+}
+
+func (v emailVerifier) close() {
+	if v.mailer != nil {
+		v.mailer.Close()
+	}
+}
+
+// the token is bound to the address, so it can not verify a later one
+func (v emailVerifier) send(ctx context.Context, userId uint64, email string, now time.Time) error {
+	token := v.signer.Sign(emailTokenPurpose, strconv.FormatUint(userId, 10)+":"+email, now.Add(v.tokenDuration))
+	separator := "?"
+	if strings.Contains(v.verifyUrl, "?") {
+		separator = "&"
+	}
+	link := v.verifyUrl + separator + emailTokenName + "=" + url.QueryEscape(token)
+	return v.mailer.Send(ctx, email, v.subject, strings.ReplaceAll(v.body, emailLinkPlaceholder, link))
+}
+
+func (v emailVerifier) open(token string, now time.Time) (uint64, string, error) {
+	value, err := v.signer.Open(emailTokenPurpose, token, now)
+	if err != nil {
+		if err == cryptoclient.ErrExpiredToken {
+			return 0, "", servicecommon.ErrExpiredEmailToken
+		}
+		return 0, "", servicecommon.ErrWrongEmailToken
+	}
+
+	rawUserId, email, _ := strings.Cut(value, ":")
+	userId, err := strconv.ParseUint(rawUserId, 10, 64)
+	if err != nil {
+		return 0, "", servicecommon.ErrWrongEmailToken
+	}
+	return userId, email, nil
+}
+
+func (impl *loginImpl) ResolveLogin(ctx context.Context, identifier string) (string, error) {
+	if !strings.Contains(identifier, "@") {
+		return identifier, nil
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	// an existing login has the priority
+	var count int64
+	if err := db.Model(&model.User{}).Where("login = ?", identifier).Count(&count).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
+	}
+	if count != 0 {
+		return identifier, nil
+	}
+
+	var logins []string
+	err := db.Model(&model.User{}).Joins("JOIN user_emails ON user_emails.user_id = users.id").Where(
+		"user_emails.verified_email = ?", strings.ToLower(strings.TrimSpace(identifier)),
+	).Limit(1).Pluck("users.login", &logins).Error
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
+	}
+	if len(logins) == 0 {
+		// same answer as an unknown login
+		return identifier, nil
+	}
+	return logins[0], nil
+}
+
+func (impl *loginImpl) GetEmail(ctx context.Context, userId uint64) (string, bool, error) {
+	var email userEmail
+	result := impl.initializedConf.db.WithContext(ctx).Limit(1).Find(&email, userId)
+	if result.Error != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, result.Error)
+		return "", false, servicecommon.ErrInternal
+	}
+	return email.Email, email.verified(), nil
+}
+
+func (impl *loginImpl) ChangeEmail(ctx context.Context, userId uint64, email string, salted string, clientIp string) error {
+	logger := impl.Logger(ctx)
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	var user model.User
+	if err = db.First(&user, "id = ?", userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrWrongLogin
+		}
+
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+
+	// the password is checked like in Verify
+	throttler := impl.initializedConf.throttler
+	targets := throttler.targets(user.Login, clientIp)
+	now := time.Now()
+	locked, err := throttler.locked(ctx, targets, now)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if locked {
+		return servicecommon.ErrLocked
+	}
+	if match, _ := impl.checkPassword(salted, user.Password); !match {
+		if err = throttler.fail(ctx, targets, now); err != nil {
+			logger.Error("Failed to count login failures", common.ErrorKey, err)
+		}
+		return common.ErrWrongLogin
+	}
+
+	if email == "" {
+		if err = deleteEmail(db, userId); err != nil {
+			logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+			return common.ErrUpdate
+		}
+		return nil
+	}
+
+	verifier := impl.initializedConf.emailVerifier
+	if !verifier.enabled() {
+		return servicecommon.ErrEmailDisabled
+	}
+
+	var current userEmail
+	if err = db.Limit(1).Find(&current, userId).Error; err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if current.Email == email {
+		return nil
+	}
+
+	if err = impl.checkEmailFree(ctx, email, userId); err != nil {
+		return err
+	}
+
+	// the previous verified address stays usable to log in until the verification of the new one
+	current.UserId, current.Email, current.SentAt = userId, email, nil
+	if err = db.Save(&current).Error; err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return common.ErrUpdate
+	}
+	if current.verified() {
+		return nil
+	}
+	return impl.sendVerification(ctx, userId, email)
+}
+
+func (impl *loginImpl) SendEmailVerification(ctx context.Context, userId uint64) error {
+	if !impl.initializedConf.emailVerifier.enabled() {
+		return servicecommon.ErrEmailDisabled
+	}
+
+	var email userEmail
+	if err := impl.initializedConf.db.WithContext(ctx).Limit(1).Find(&email, userId).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if email.Email == "" {
+		return servicecommon.ErrWrongEmail
+	}
+	if email.verified() {
+		return nil
+	}
+	return impl.sendVerification(ctx, userId, email.Email)
+}
+
+func (impl *loginImpl) VerifyEmail(ctx context.Context, token string) (uint64, error) {
+	logger := impl.Logger(ctx)
+	verifier := impl.initializedConf.emailVerifier
+	if !verifier.enabled() {
+		return 0, servicecommon.ErrEmailDisabled
+	}
+
+	userId, email, err := verifier.open(token, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	var current userEmail
+	if err = db.Limit(1).Find(&current, userId).Error; err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return 0, servicecommon.ErrInternal
+	}
+	// the address has been changed since the sending of the token
+	if current.Email != email {
+		return 0, servicecommon.ErrWrongEmailToken
+	}
+	if current.verified() {
+		return userId, nil
+	}
+
+	if err = impl.checkEmailFree(ctx, email, userId); err != nil {
+		return 0, err
+	}
+
+	result := db.Model(&userEmail{}).Where("user_id = ? AND email = ?", userId, email).Update("verified_email", email)
+	if result.Error != nil {
+		// verified by another user since the check
+		if dbclient.IsDuplicateKey(db, result.Error) {
+			return 0, servicecommon.ErrExistingEmail
+		}
+
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, result.Error)
+		return 0, common.ErrUpdate
+	}
+	if result.RowsAffected == 0 {
+		return 0, servicecommon.ErrWrongEmailToken
+	}
+	return userId, nil
+}
+
+// a verified address is unique and can not be the login of another user (ResolveLogin would be ambiguous)
+func (impl *loginImpl) checkEmailFree(ctx context.Context, email string, userId uint64) error {
+	db := impl.initializedConf.db.WithContext(ctx)
+	var count int64
+	err := db.Model(&userEmail{}).Where("verified_email = ? AND user_id <> ?", email, userId).Count(&count).Error
+	if err == nil && count == 0 {
+		err = db.Model(&model.User{}).Where("login = ? AND id <> ?", email, userId).Count(&count).Error
+	}
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if count != 0 {
+		return servicecommon.ErrExistingEmail
+	}
+	return nil
+}
+
+// the delay between two messages is checked and reserved at once
+func (impl *loginImpl) sendVerification(ctx context.Context, userId uint64, email string) error {
+	logger := impl.Logger(ctx)
+	now := time.Now()
+	result := impl.initializedConf.db.WithContext(ctx).Model(&userEmail{}).Where(
+		"user_id = ? AND email = ? AND (sent_at IS NULL OR sent_at < ?)", userId, email, now.Add(-emailResendDelay),
+	).Update("sent_at", now)
+	if result.Error != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, result.Error)
+		return servicecommon.ErrInternal
+	}
+	if result.RowsAffected == 0 {
+		return servicecommon.ErrEmailAlreadySent
+	}
+
+	if err := impl.initializedConf.emailVerifier.send(ctx, userId, email, now); err != nil {
+		logger.Error("Failed to send the email verification", common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	return nil
+}
+
+func deleteEmail(db *gorm.DB, userId uint64) error {
+	return db.Delete(&userEmail{}, userId).Error
+}
+
+// empty stays empty (no address)
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+
+	// only a bare address, without display name
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxEmailSize {
+		return "", servicecommon.ErrWrongEmail
+	}
+	return strings.ToLower(email), nil
+}
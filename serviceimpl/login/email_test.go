@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
+)
+
+func testEmailVerifier(t *testing.T, activeKey string, ids ...string) emailVerifier {
+	t.Helper()
+	signer, err := cryptoclient.LoadSigner(testLogger, testKeyConf(t, activeKey, ids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return emailVerifier{signer: signer, tokenDuration: defaultEmailTokenDuration}
+}
+
+// the token of send, without the mailer
+func (v emailVerifier) testToken(userId uint64, email string, now time.Time) string {
+	return v.signer.Sign(emailTokenPurpose, strconv.FormatUint(userId, 10)+":"+email, now.Add(v.tokenDuration))
+}
+
+func TestEmailVerifierOpen(t *testing.T) {
+	verifier := testEmailVerifier(t, "k1", "k1")
+	// the same key id with another key
+	otherConf := testKeyConf(t, "x1", "x1")
+	otherConf.KeyFiles, otherConf.ActiveKey = map[string]string{"k1": otherConf.KeyFiles["x1"]}, "k1"
+	otherSigner, err := cryptoclient.LoadSigner(testLogger, otherConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := verifier.testToken(42, "alice@example.com", testNow)
+	tests := []struct {
+		name      string
+		token     string
+		now       time.Time
+		wantId    uint64
+		wantEmail string
+		wantErr   error
+	}{
+		{name: "round trip", token: token, now: testNow, wantId: 42, wantEmail: "alice@example.com"},
+		{name: "end of validity", token: token, now: testNow.Add(defaultEmailTokenDuration), wantId: 42, wantEmail: "alice@example.com"},
+		{name: "expired", token: token, now: testNow.Add(defaultEmailTokenDuration + time.Second), wantErr: servicecommon.ErrExpiredEmailToken},
+		{name: "tampered", token: token[:len(token)-1], now: testNow, wantErr: servicecommon.ErrWrongEmailToken},
+		{name: "other signer", token: otherSigner.Sign(emailTokenPurpose, "42:alice@example.com", testNow.Add(time.Hour)), now: testNow, wantErr: servicecommon.ErrWrongEmailToken},
+		{name: "other purpose", token: verifier.signer.Sign("reset", "42:alice@example.com", testNow.Add(time.Hour)), now: testNow, wantErr: servicecommon.ErrWrongEmailToken},
+		{name: "no user", token: verifier.signer.Sign(emailTokenPurpose, "alice@example.com", testNow.Add(time.Hour)), now: testNow, wantErr: servicecommon.ErrWrongEmailToken},
+		{name: "empty", token: "", now: testNow, wantErr: servicecommon.ErrWrongEmailToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userId, email, err := verifier.open(test.token, test.now)
+			if err != test.wantErr {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if userId != test.wantId || email != test.wantEmail {
+				t.Errorf("got (%d, %q), want (%d, %q)", userId, email, test.wantId, test.wantEmail)
+			}
+		})
+	}
+}
+
+func TestEmailVerifierRotation(t *testing.T) {
+	token := testEmailVerifier(t, "k1", "k1").testToken(42, "alice@example.com", testNow)
+	tests := []struct {
+		name    string
+		ids     []string
+		wantErr error
+	}{
+		{name: "old key kept", ids: []string{"k1", "k2"}},
+		{name: "old key removed", ids: []string{"k2"}, wantErr: servicecommon.ErrWrongEmailToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := testEmailVerifier(t, "k2", test.ids...).open(token, testNow); err != test.wantErr {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email   string
+		want    string
+		wantErr bool
+	}{
+		{email: "alice@example.com", want: "alice@example.com"},
+		{email: " Alice@Example.COM ", want: "alice@example.com"},
+		{email: "", want: ""},
+		{email: "   ", want: ""},
+		{email: "alice", wantErr: true},
+		{email: "Alice <alice@example.com>", wantErr: true},
+		{email: "alice@example.com, bob@example.com", wantErr: true},
+		{email: strings.Repeat("a", maxEmailSize-len("@example.com")) + "@example.com", want: strings.Repeat("a", maxEmailSize-len("@example.com")) + "@example.com"},
+		{email: strings.Repeat("a", maxEmailSize-len("@example.com")+1) + "@example.com", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := normalizeEmail(test.email)
+		if test.wantErr {
+			if err != servicecommon.ErrWrongEmail {
+				t.Errorf("%q : got error %v, want %v", test.email, err, servicecommon.ErrWrongEmail)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%q : got (%q, %v), want %q", test.email, got, err, test.want)
+		}
+	}
+}
+
+// several users without verified address, but a verified address only once
+func TestVerifiedEmailIndex(t *testing.T) {
+	db := testDB(t)
+	verified := "alice@example.com"
+	tests := []struct {
+		name          string
+		email         userEmail
+		wantDuplicate bool
+	}{
+		{name: "not verified", email: userEmail{UserId: 1, Email: "bob@example.com"}},
+		{name: "another not verified", email: userEmail{UserId: 2, Email: "bob@example.com"}},
+		{name: "verified", email: userEmail{UserId: 3, Email: verified, VerifiedEmail: &verified}},
+		{name: "verified again", email: userEmail{UserId: 4, Email: verified, VerifiedEmail: &verified}, wantDuplicate: true},
+	}
+	for _, test := range tests {
+		err := db.Create(&test.email).Error
+		if test.wantDuplicate {
+			if !dbclient.IsDuplicateKey(db, err) {
+				t.Errorf("%s : got error %v, want a duplicate key", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s : %v", test.name, err)
+		}
+	}
+}
@@ -19,24 +19,105 @@
 package loginimpl
 
 import (
-	"github.com/dvaumoron/puzzleloginserver/model"
+	"log/slog"
+	"time"
+
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
 	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	mailclient "github.com/dvaumoron/puzzleweaver/client/mail"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	"gorm.io/gorm"
 )
 
 type loginConf struct {
 	DatabaseKind    string
 	DatabaseAddress string
+	DatabaseTLS     tlsclient.Conf
+	// "native" (default) or "like"
+	SearchMode string
+	// optional HMAC keys applied to the salted passwords
+	Pepper cryptoclient.Conf
+	// failed verifications before a lockout of the login or of the client IP, disabled when zero
+	MaxLoginFailures int
+	MaxIpFailures    int
+	// first lockout (default 1m), doubled by each new failure up to MaxLockDuration (default 1h)
+	LockDuration    time.Duration
+	MaxLockDuration time.Duration
+	// the failures are forgotten after this delay without a new one (default 24h)
+	FailureWindow time.Duration
+	// failed second factor checks before a lockout of the user (default 5)
+	MaxTotpFailures int
+	// displayed by the authenticator applications (default "PuzzleWeaver")
+	TotpIssuer string
+	// optional key ring for the TOTP secrets
+	Encryption cryptoclient.Conf
+	// sends the verification of the email addresses, which are disabled without it
+	Mail mailclient.Conf
+	// keys signing the verification links, needed with Mail
+	EmailToken cryptoclient.Conf
+	// validity of a verification link (default 24h)
+	EmailTokenDuration time.Duration
+	// page receiving the token query parameter, needed with Mail
+	EmailVerifyUrl string
+	EmailSubject   string
+	// "{link}" is replaced by the verification link
+	EmailBody string
 }
 
 type initializedLoginConf struct {
-	db *gorm.DB
+	db            *gorm.DB
+	searcher      dbclient.Searcher
+	pepper        *cryptoclient.Pepper
+	keyRing       *cryptoclient.KeyRing
+	throttler     throttler
+	totpIssuer    string
+	emailVerifier emailVerifier
+	certificates  *tlsclient.Certificates
 }
 
-func initLoginConf(conf *loginConf) (initializedLoginConf, error) {
-	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress)
+func initLoginConf(logger *slog.Logger, conf *loginConf) (initializedLoginConf, error) {
+	pepper, err := cryptoclient.LoadPepper(logger, conf.Pepper)
+	if err != nil {
+		return initializedLoginConf{}, err
+	}
+
+	keyRing, err := cryptoclient.Load(logger, conf.Encryption)
+	if err != nil {
+		return initializedLoginConf{}, err
+	}
+
+	emailVerifier, err := newEmailVerifier(logger, conf)
+	if err != nil {
+		return initializedLoginConf{}, err
+	}
+
+	certificates, err := tlsclient.Load(logger, conf.DatabaseTLS)
+	if err != nil {
+		emailVerifier.close()
+		return initializedLoginConf{}, err
+	}
+
+	db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress, certificates)
+	if err == nil {
+		err = Migrations.Check(db)
+	}
+	var searcher dbclient.Searcher
 	if err == nil {
-		err = db.AutoMigrate(&model.User{})
+		searcher, err = dbclient.NewSearcher(db, conf.SearchMode)
+	}
+	if err != nil {
+		tlsclient.Release(certificates)
+		emailVerifier.close()
+		return initializedLoginConf{}, err
+	}
+
+	totpIssuer := conf.TotpIssuer
+	if totpIssuer == "" {
+		totpIssuer = defaultTotpIssuer
 	}
-	return initializedLoginConf{db: db}, err
+	return initializedLoginConf{
+		db: db, searcher: searcher, pepper: pepper, keyRing: keyRing,
+		throttler: newThrottler(logger, db, conf), totpIssuer: totpIssuer, emailVerifier: emailVerifier,
+		certificates: certificates,
+	}, nil
 }
@@ -21,15 +21,21 @@ package loginimpl
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/ServiceWeaver/weaver"
 	"github.com/dvaumoron/puzzleloginserver/model"
+	clientcommon "github.com/dvaumoron/puzzleweaver/client/common"
 	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"gorm.io/gorm"
 )
 
+const loginColumn = "login"
+
 type loginImpl struct {
 	weaver.Implements[RemoteLoginService]
 	weaver.WithConfig[loginConf]
@@ -37,11 +43,54 @@ type loginImpl struct {
 }
 
 func (impl *loginImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initLoginConf(impl.Config())
+	impl.initializedConf, err = initLoginConf(impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
-func (impl *loginImpl) Verify(ctx context.Context, login string, salted string) (uint64, error) {
+// stops the sweep of the login failures, closes the database and releases the TLS certificates
+func (impl *loginImpl) Shutdown(ctx context.Context) error {
+	impl.initializedConf.throttler.close()
+	impl.initializedConf.emailVerifier.close()
+	defer tlsclient.Release(impl.initializedConf.certificates)
+	sqlDB, err := impl.initializedConf.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (impl *loginImpl) Verify(ctx context.Context, login string, salted string, clientIp string) (uint64, error) {
+	logger := impl.Logger(ctx)
+	throttler := impl.initializedConf.throttler
+	targets := throttler.targets(login, clientIp)
+	now := time.Now()
+	locked, err := throttler.locked(ctx, targets, now)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return 0, servicecommon.ErrInternal
+	}
+	if locked {
+		return 0, servicecommon.ErrLocked
+	}
+
+	userId, verifyErr := impl.verify(ctx, login, salted)
+	switch verifyErr {
+	case nil:
+		err = throttler.succeed(ctx, login)
+	case common.ErrWrongLogin:
+		// unknown logins are counted too, their lockout does not reveal anything
+		err = throttler.fail(ctx, targets, now)
+	}
+	if err != nil {
+		logger.Error("Failed to count login failures", common.ErrorKey, err)
+	}
+	return userId, verifyErr
+}
+
+func (impl *loginImpl) verify(ctx context.Context, login string, salted string) (uint64, error) {
 	var user model.User
 	if err := impl.initializedConf.db.First(&user, "login = ?", login).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -52,37 +101,82 @@ func (impl *loginImpl) Verify(ctx context.Context, login string, salted string)
 		return 0, servicecommon.ErrInternal
 	}
 
-	if salted != user.Password {
+	match, outdated := impl.checkPassword(salted, user.Password)
+	if !match {
 		return 0, common.ErrWrongLogin
 	}
+	if outdated {
+		// with the active key of the pepper, a failure does not prevent the connection
+		if err := impl.initializedConf.db.Model(&user).Update("password", impl.storedPassword(salted)).Error; err != nil {
+			impl.Logger(ctx).Warn("Failed to pepper the password again", common.ErrorKey, err)
+		}
+	}
 	return user.ID, nil
 }
 
-func (impl *loginImpl) Register(ctx context.Context, login string, salted string) (uint64, error) {
+func (impl *loginImpl) Register(ctx context.Context, login string, email string, salted string) (uint64, error) {
 	if login == "" {
 		return 0, common.ErrEmptyLogin
 	}
 
-	var user model.User
-	err := impl.initializedConf.db.First(&user, "login = ?", login).Error
-	if err == nil {
-		return 0, common.ErrExistingLogin
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return 0, err
 	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		// some technical error, send it
-		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
-		return 0, servicecommon.ErrInternal
+	if email != "" && !impl.initializedConf.emailVerifier.enabled() {
+		return 0, servicecommon.ErrEmailDisabled
+	}
+
+	if err = impl.checkLoginFree(ctx, login, 0); err != nil {
+		return 0, err
+	}
+	if email != "" {
+		if err = impl.checkEmailFree(ctx, email, 0); err != nil {
+			return 0, err
+		}
 	}
 
 	// unknown user, create new
-	user = model.User{Login: login, Password: salted}
-	if err = impl.initializedConf.db.Create(&user).Error; err != nil {
+	user := model.User{Login: login, Password: impl.storedPassword(salted)}
+	err = impl.initializedConf.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil || email == "" {
+			return err
+		}
+		return tx.Create(&userEmail{UserId: user.ID, Email: email}).Error
+	})
+	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return 0, servicecommon.ErrInternal
 	}
+
+	if email != "" {
+		// a failure is logged and does not cancel the registration, the message can be asked again
+		impl.sendVerification(ctx, user.ID, email)
+	}
 	return user.ID, nil
 }
 
+// a login can not be the verified address of another user (ResolveLogin would be ambiguous)
+func (impl *loginImpl) checkLoginFree(ctx context.Context, login string, userId uint64) error {
+	db := impl.initializedConf.db.WithContext(ctx)
+	var count int64
+	err := db.Model(&model.User{}).Where("login = ? AND id <> ?", login, userId).Count(&count).Error
+	if err == nil && count == 0 && strings.Contains(login, "@") {
+		err = db.Model(&userEmail{}).Where(
+			"verified_email = ? AND user_id <> ?", strings.ToLower(login), userId,
+		).Count(&count).Error
+	}
+	if err != nil {
+		// some technical error, send it
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if count != 0 {
+		return common.ErrExistingLogin
+	}
+	return nil
+}
+
 func (impl *loginImpl) GetUsers(ctx context.Context, userIds []uint64) (map[uint64]RawUser, error) {
 	var users []model.User
 	if err := impl.initializedConf.db.Find(&users, "id IN ?", userIds).Error; err != nil {
@@ -108,21 +202,16 @@ func (impl *loginImpl) ChangeLogin(ctx context.Context, userId uint64, newLogin
 		return servicecommon.ErrInternal
 	}
 
-	if oldSalted != user.Password {
+	if match, _ := impl.checkPassword(oldSalted, user.Password); !match {
 		return common.ErrWrongLogin
 	}
 
-	err = impl.initializedConf.db.First(&user, "login = ?", newLogin).Error
-	if err == nil {
-		return common.ErrExistingLogin
-	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
+	if err = impl.checkLoginFree(ctx, newLogin, userId); err != nil {
+		return err
 	}
 
 	err = impl.initializedConf.db.Model(&user).Updates(map[string]any{
-		"login": newLogin, "password": newSalted,
+		"login": newLogin, "password": impl.storedPassword(newSalted),
 	}).Error
 	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
@@ -143,26 +232,65 @@ func (impl *loginImpl) ChangePassword(ctx context.Context, userId uint64, oldSal
 		return servicecommon.ErrInternal
 	}
 
-	if oldSalted != user.Password {
+	if match, _ := impl.checkPassword(oldSalted, user.Password); !match {
 		return common.ErrWrongLogin
 	}
-	if err = impl.initializedConf.db.Model(&user).Update("password", newSalted).Error; err != nil {
+	if err = impl.initializedConf.db.Model(&user).Update("password", impl.storedPassword(newSalted)).Error; err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return common.ErrUpdate
 	}
 	return nil
 }
 
-func (impl *loginImpl) ListUsers(ctx context.Context, start uint64, end uint64, filter string) (uint64, []RawUser, error) {
-	noFilter := filter == ""
+func (impl *loginImpl) GetHashParams(ctx context.Context, login string, currentParams string, clientIp string) (string, error) {
+	// checked before the hash on the web side, whether the login exists or not
+	throttler := impl.initializedConf.throttler
+	locked, err := throttler.locked(ctx, throttler.targets(login, clientIp), time.Now())
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
+	}
+	if locked {
+		return "", servicecommon.ErrLocked
+	}
 
-	userRequest := impl.initializedConf.db.Model(&model.User{})
-	if !noFilter {
-		filter = dbclient.BuildLikeFilter(filter)
-		userRequest.Where("login LIKE ?", filter)
+	var user model.User
+	if err := impl.initializedConf.db.Select("password").First(&user, "login = ?", login).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// same answer as a hash stored with the current parameters
+			return currentParams, nil
+		}
+
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
 	}
+
+	params, _ := splitHash(user.Password)
+	return params, nil
+}
+
+func (impl *loginImpl) ListLockouts(ctx context.Context) ([]Lockout, error) {
+	lockouts, err := ListLockouts(ctx, impl.initializedConf.db, time.Now())
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+	return lockouts, nil
+}
+
+func (impl *loginImpl) ClearLockout(ctx context.Context, target string) error {
+	if err := ClearLockouts(ctx, impl.initializedConf.db, target); err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return common.ErrUpdate
+	}
+	return nil
+}
+
+func (impl *loginImpl) ListUsers(ctx context.Context, start uint64, end uint64, filter string) (uint64, []RawUser, error) {
+	search := impl.initializedConf.searcher.Parse(filter)
+
 	var total int64
-	err := userRequest.Count(&total).Error
+	err := search.Where(impl.initializedConf.db.Model(&model.User{}), loginColumn).Count(&total).Error
 	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return 0, nil, servicecommon.ErrInternal
@@ -172,28 +300,74 @@ func (impl *loginImpl) ListUsers(ctx context.Context, start uint64, end uint64,
 	}
 
 	var users []model.User
-	page := dbclient.Paginate(impl.initializedConf.db, start, end).Order("login asc")
-	if noFilter {
-		err = page.Find(&users).Error
-	} else {
-		err = page.Find(&users, "login LIKE ?", filter).Error
+	page := dbclient.Paginate(search.Where(impl.initializedConf.db, loginColumn), start, end)
+	if err = search.Order(page, loginColumn, "login asc").Find(&users).Error; err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return 0, nil, servicecommon.ErrInternal
 	}
+	return uint64(total), addSnippets(convertUsersFromModel(users), search), nil
+}
 
+func (impl *loginImpl) ListUsersPage(ctx context.Context, cursor string, size uint64, filter string) ([]RawUser, string, string, error) {
+	position, err := clientcommon.DecodeCursor(cursor)
 	if err != nil {
+		return nil, "", "", err
+	}
+
+	search := impl.initializedConf.searcher.Parse(filter)
+	userRequest := dbclient.KeysetPaginate(impl.initializedConf.db.WithContext(ctx), position, size, false)
+	userRequest = search.Where(userRequest, loginColumn)
+
+	var users []model.User
+	if err = userRequest.Find(&users).Error; err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
-		return 0, nil, servicecommon.ErrInternal
+		return nil, "", "", servicecommon.ErrInternal
 	}
-	return uint64(total), convertUsersFromModel(users), nil
+
+	users, next, prev := clientcommon.BuildPage(users, position, size, userPosition)
+	return addSnippets(convertUsersFromModel(users), search), next, prev, nil
 }
 
 func (impl *loginImpl) Delete(ctx context.Context, userId uint64) error {
-	if err := impl.initializedConf.db.Delete(&model.User{}, userId).Error; err != nil {
+	err := impl.initializedConf.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteTotp(tx, userId); err != nil {
+			return err
+		}
+		if err := deleteEmail(tx, userId); err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, userId).Error
+	})
+	if err != nil {
 		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
 		return common.ErrUpdate
 	}
 	return nil
 }
 
+// the parameters of the hash stay readable, only the hash goes through the pepper
+func (impl *loginImpl) storedPassword(salted string) string {
+	params, hash := splitHash(salted)
+	return params + impl.initializedConf.pepper.Hash(hash)
+}
+
+func (impl *loginImpl) checkPassword(salted string, stored string) (bool, bool) {
+	params, hash := splitHash(salted)
+	storedParams, storedHash := splitHash(stored)
+	match, outdated := impl.initializedConf.pepper.Verify(hash, storedHash)
+	return match && params == storedParams, outdated
+}
+
+// split after the last '$', the parameters are empty for the legacy format
+func splitHash(value string) (string, string) {
+	index := strings.LastIndexByte(value, '$') + 1
+	return value[:index], value[index:]
+}
+
+func userPosition(user model.User) clientcommon.Cursor {
+	return clientcommon.Cursor{CreatedAt: user.CreatedAt, Id: user.ID}
+}
+
 func convertUsersFromModel(users []model.User) []RawUser {
 	resUsers := make([]RawUser, 0, len(users))
 	for _, user := range users {
@@ -209,3 +383,12 @@ func convertUsersMapFromModel(users []model.User) map[uint64]RawUser {
 	}
 	return resUsers
 }
+
+func addSnippets(users []RawUser, search dbclient.Search) []RawUser {
+	if !search.Empty() {
+		for i, user := range users {
+			users[i].Snippet = search.Snippet(user.Login)
+		}
+	}
+	return users
+}
@@ -0,0 +1,153 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dvaumoron/puzzleloginserver/model"
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// writes a key file by id (the key is derived from the id, so two confs share their keys)
+func testKeyConf(t *testing.T, activeKey string, ids ...string) cryptoclient.Conf {
+	t.Helper()
+	dir := t.TempDir()
+	keyFiles := make(map[string]string, len(ids))
+	for _, id := range ids {
+		path := filepath.Join(dir, id)
+		if err := os.WriteFile(path, bytes.Repeat([]byte(id), 32)[:32], 0o600); err != nil {
+			t.Fatal(err)
+		}
+		keyFiles[id] = path
+	}
+	return cryptoclient.Conf{KeyFiles: keyFiles, ActiveKey: activeKey}
+}
+
+func testPepper(t *testing.T, activeKey string, ids ...string) *cryptoclient.Pepper {
+	t.Helper()
+	pepper, err := cryptoclient.LoadPepper(testLogger, testKeyConf(t, activeKey, ids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pepper
+}
+
+func TestCheckPassword(t *testing.T) {
+	const salted = "$argon2id$v=19$m=65536,t=3,p=4$hash"
+	const legacySalted = "hash"
+
+	oldImpl := &loginImpl{initializedConf: initializedLoginConf{pepper: testPepper(t, "k1", "k1")}}
+	rotatedImpl := &loginImpl{initializedConf: initializedLoginConf{pepper: testPepper(t, "k2", "k1", "k2")}}
+	noPepperImpl := &loginImpl{}
+
+	stored := oldImpl.storedPassword(salted)
+	tests := []struct {
+		name         string
+		impl         *loginImpl
+		salted       string
+		stored       string
+		wantMatch    bool
+		wantOutdated bool
+	}{
+		{name: "round trip", impl: oldImpl, salted: salted, stored: stored, wantMatch: true},
+		{name: "legacy round trip", impl: oldImpl, salted: legacySalted, stored: oldImpl.storedPassword(legacySalted), wantMatch: true},
+		{name: "wrong hash", impl: oldImpl, salted: "$argon2id$v=19$m=65536,t=3,p=4$other", stored: stored},
+		{name: "other parameters", impl: oldImpl, salted: "$argon2id$v=19$m=65536,t=2,p=4$hash", stored: stored},
+		{name: "parameters removed", impl: oldImpl, salted: legacySalted, stored: stored},
+		{name: "rotated pepper", impl: rotatedImpl, salted: salted, stored: stored, wantMatch: true, wantOutdated: true},
+		{name: "rotated pepper wrong hash", impl: rotatedImpl, salted: "$argon2id$v=19$m=65536,t=3,p=4$other", stored: stored, wantOutdated: true},
+		{name: "peppered again", impl: rotatedImpl, salted: salted, stored: rotatedImpl.storedPassword(salted), wantMatch: true},
+		{name: "stored before the pepper", impl: oldImpl, salted: salted, stored: salted, wantMatch: true, wantOutdated: true},
+		{name: "pepper removed", impl: noPepperImpl, salted: salted, stored: stored},
+		{name: "no pepper", impl: noPepperImpl, salted: salted, stored: noPepperImpl.storedPassword(salted), wantMatch: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, outdated := test.impl.checkPassword(test.salted, test.stored)
+			if match != test.wantMatch || outdated != test.wantOutdated {
+				t.Errorf("got (%v, %v), want (%v, %v)", match, outdated, test.wantMatch, test.wantOutdated)
+			}
+		})
+	}
+}
+
+func TestStoredPassword(t *testing.T) {
+	impl := &loginImpl{initializedConf: initializedLoginConf{pepper: testPepper(t, "k1", "k1")}}
+	tests := []struct {
+		name       string
+		salted     string
+		wantParams string
+	}{
+		{name: "with parameters", salted: "$argon2id$v=19$m=65536,t=3,p=4$hash", wantParams: "$argon2id$v=19$m=65536,t=3,p=4$"},
+		{name: "legacy format", salted: "hash", wantParams: ""},
+		{name: "scrypt", salted: "$scrypt$ln=16,r=8,p=1$hash", wantParams: "$scrypt$ln=16,r=8,p=1$"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, hash := splitHash(impl.storedPassword(test.salted))
+			if params != test.wantParams {
+				t.Errorf("got parameters %q, want %q", params, test.wantParams)
+			}
+			if _, saltedHash := splitHash(test.salted); hash == saltedHash {
+				t.Error("the hash did not go through the pepper")
+			}
+		})
+	}
+}
+
+func TestGetHashParams(t *testing.T) {
+	const currentParams = "$argon2id$v=19$m=65536,t=3,p=4$"
+	db := testDB(t)
+	throttler := newThrottler(testLogger, db, &loginConf{})
+	t.Cleanup(throttler.close)
+	impl := &loginImpl{initializedConf: initializedLoginConf{db: db, throttler: throttler}}
+
+	users := []model.User{{Login: "scrypt", Password: "$scrypt$ln=16,r=8,p=1$hash"}, {Login: "legacy", Password: "hash"}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		login      string
+		wantParams string
+	}{
+		{login: "scrypt", wantParams: "$scrypt$ln=16,r=8,p=1$"},
+		{login: "legacy", wantParams: ""},
+		{login: "unknown", wantParams: currentParams},
+	}
+	for _, test := range tests {
+		t.Run(test.login, func(t *testing.T) {
+			params, err := impl.GetHashParams(context.Background(), test.login, currentParams, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params != test.wantParams {
+				t.Errorf("got parameters %q, want %q", params, test.wantParams)
+			}
+		})
+	}
+}
@@ -29,14 +29,58 @@ type RawUser struct {
 	Id          uint64
 	Login       string
 	RegistredAt int64
+	// html extract of Login with the filter terms highlighted, empty without filter
+	Snippet string
+}
+
+type Lockout struct {
+	weaver.AutoMarshal
+	// "login:" or "ip:" followed by the locked value
+	Target      string
+	Failures    uint64
+	LastFailure int64
+	LockedUntil int64
 }
 
 type RemoteLoginService interface {
 	GetUsers(ctx context.Context, userIds []uint64) (map[uint64]RawUser, error)
 	ListUsers(ctx context.Context, start uint64, end uint64, filter string) (uint64, []RawUser, error)
+	// keyset variant of ListUsers (ordered by registration), return the users, then next and previous cursors
+	ListUsersPage(ctx context.Context, cursor string, size uint64, filter string) ([]RawUser, string, string, error)
 	Delete(ctx context.Context, userId uint64) error
-	Verify(ctx context.Context, login string, salted string) (uint64, error)
-	Register(ctx context.Context, login string, salted string) (uint64, error)
+	// clientIp is optional, return servicecommon.ErrLocked while the login or the client IP has too many failures
+	Verify(ctx context.Context, login string, salted string, clientIp string) (uint64, error)
+	// email is optional, its verification is sent at once
+	Register(ctx context.Context, login string, email string, salted string) (uint64, error)
 	ChangeLogin(ctx context.Context, userId uint64, newLogin string, oldSalted string, newSalted string) error
 	ChangePassword(ctx context.Context, userId uint64, oldSalted string, newSalted string) error
+	// return the parameters of the stored hash (algorithm and cost), empty for the legacy format,
+	// currentParams (those of the new hashes) for an unknown login,
+	// clientIp is optional, return servicecommon.ErrLocked like Verify
+	GetHashParams(ctx context.Context, login string, currentParams string, clientIp string) (string, error)
+	// no right check
+	ListLockouts(ctx context.Context) ([]Lockout, error)
+	// no right check
+	ClearLockout(ctx context.Context, target string) error
+	// second factor, true when a confirmed TOTP is enabled for the user
+	GetTotpStatus(ctx context.Context, userId uint64) (bool, error)
+	// return the provisioning URI of a new secret (the same until its confirmation)
+	StartTotp(ctx context.Context, userId uint64) (string, error)
+	// enable the TOTP with a first code, return the recovery codes
+	ConfirmTotp(ctx context.Context, userId uint64, code string) ([]string, error)
+	// accept a TOTP code or an unused recovery code
+	VerifyTotp(ctx context.Context, userId uint64, code string) error
+	DisableTotp(ctx context.Context, userId uint64, code string) error
+	// no right check
+	ResetTotp(ctx context.Context, userId uint64) error
+	// return the login of the user with this verified email, or the identifier unchanged
+	ResolveLogin(ctx context.Context, identifier string) (string, error)
+	// return the current address (empty without one) and its verification state
+	GetEmail(ctx context.Context, userId uint64) (string, bool, error)
+	// an empty email removes the address, the verification of a new one is sent at once,
+	// clientIp is optional, the wrong passwords are counted and return servicecommon.ErrLocked like Verify
+	ChangeEmail(ctx context.Context, userId uint64, email string, salted string, clientIp string) error
+	SendEmailVerification(ctx context.Context, userId uint64) error
+	// return the id of the user whose address is verified by the token
+	VerifyEmail(ctx context.Context, token string) (uint64, error)
 }
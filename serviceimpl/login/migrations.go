@@ -0,0 +1,117 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	"gorm.io/gorm"
+)
+
+// applied with the migrate mode of the binary, checked at component start,
+// the steps use copies of the models at their version, so they do not follow the later changes
+var Migrations = dbclient.Migrations{Component: "login", Steps: []dbclient.Migration{{
+	Version: 1, Name: "create users table",
+	Up: dbclient.AdoptTables(&usersV1{}), Down: dbclient.DropTables(&usersV1{}),
+}, {
+	Version: 2, Name: "index users for keyset pagination",
+	Up:   dbclient.CreateIndex(&usersV1{}, usersKeysetIndex, "created_at", "id"),
+	Down: dbclient.DropIndex(&usersV1{}, usersKeysetIndex),
+}, {
+	Version: 3, Name: "full text search index on logins",
+	Up:   dbclient.CreateSearchIndex(&usersV1{}, usersSearchIndex, loginColumn),
+	Down: dbclient.DropSearchIndex(&usersV1{}, usersSearchIndex),
+}, {
+	Version: 4, Name: "create login failures table",
+	Up: dbclient.CreateTables(&loginFailuresV4{}), Down: dbclient.DropTables(&loginFailuresV4{}),
+}, {
+	Version: 5, Name: "create totp tables",
+	Up:   dbclient.CreateTables(&userTotpsV5{}, &userRecoveryCodesV5{}),
+	Down: dbclient.DropTables(&userRecoveryCodesV5{}, &userTotpsV5{}),
+}, {
+	Version: 6, Name: "create user emails table",
+	Up: func(tx *gorm.DB) error {
+		if err := dbclient.CreateTables(&userEmailsV6{})(tx); err != nil {
+			return err
+		}
+		return dbclient.CreateNullableUniqueIndex(&userEmailsV6{}, userEmailsVerifiedIndex, "verified_email")(tx)
+	},
+	Down: dbclient.DropTables(&userEmailsV6{}),
+}}}
+
+const (
+	usersKeysetIndex = "idx_users_created_at_id"
+	usersSearchIndex = "idx_users_login_search"
+
+	userEmailsVerifiedIndex = "idx_user_emails_verified_email"
+)
+
+// model.User of puzzleloginserver
+type usersV1 struct {
+	ID        uint64
+	CreatedAt time.Time
+	Login     string
+	Password  string
+}
+
+func (usersV1) TableName() string {
+	return "users"
+}
+
+type loginFailuresV4 struct {
+	Target      string `gorm:"primaryKey;size:300"`
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time `gorm:"index"`
+}
+
+func (loginFailuresV4) TableName() string {
+	return "login_failures"
+}
+
+type userTotpsV5 struct {
+	UserId   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+func (userTotpsV5) TableName() string {
+	return "user_totps"
+}
+
+type userRecoveryCodesV5 struct {
+	UserId   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	CodeHash string `gorm:"primaryKey;size:64"`
+}
+
+func (userRecoveryCodesV5) TableName() string {
+	return "user_recovery_codes"
+}
+
+type userEmailsV6 struct {
+	UserId        uint64  `gorm:"primaryKey;autoIncrement:false"`
+	Email         string  `gorm:"size:254;index"`
+	VerifiedEmail *string `gorm:"size:254"`
+	SentAt        *time.Time
+}
+
+func (userEmailsV6) TableName() string {
+	return "user_emails"
+}
@@ -0,0 +1,60 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dvaumoron/puzzleloginserver/model"
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	"gorm.io/gorm/logger"
+)
+
+// the users table of an install prior to the migrations is kept with its rows
+func TestMigrationsAdoptExistingTables(t *testing.T) {
+	db, err := dbclient.New("sqlite", filepath.Join(t.TempDir(), "login.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if err = db.AutoMigrate(&model.User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Create(&model.User{Login: "alice", Password: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Migrations.Check(db); err == nil {
+		t.Fatal("the schema should be behind")
+	}
+	if _, err = Migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	if err = Migrations.Check(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err = db.Model(&model.User{}).Where("login = ?", "alice").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d users, want 1", count)
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/dvaumoron/puzzleweb/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	loginTargetPrefix = "login:"
+	ipTargetPrefix    = "ip:"
+
+	defaultMaxTotpFailures = 5
+	defaultLockDuration    = time.Minute
+	defaultMaxLockDuration = time.Hour
+	defaultFailureWindow   = 24 * time.Hour
+	failureSweepInterval   = 10 * time.Minute
+)
+
+type loginFailure struct {
+	// login or client IP with its prefix
+	Target      string `gorm:"primaryKey;size:300"`
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time `gorm:"index"`
+}
+
+func (loginFailure) TableName() string {
+	return "login_failures"
+}
+
+type throttleTarget struct {
+	name        string
+	maxFailures int
+}
+
+// counts the failed verifications by login, by client IP and by user for the second factor,
+// a target reaching its maximum is locked, each new failure after a lock doubles the duration of the next one
+type throttler struct {
+	db               *gorm.DB
+	maxLoginFailures int
+	maxIpFailures    int
+	maxTotpFailures  int
+	lockDuration     time.Duration
+	maxLockDuration  time.Duration
+	failureWindow    time.Duration
+	stop             chan struct{}
+}
+
+func newThrottler(logger *slog.Logger, db *gorm.DB, conf *loginConf) throttler {
+	t := throttler{
+		db: db, maxLoginFailures: conf.MaxLoginFailures, maxIpFailures: conf.MaxIpFailures,
+		maxTotpFailures: conf.MaxTotpFailures, lockDuration: conf.LockDuration,
+		maxLockDuration: conf.MaxLockDuration, failureWindow: conf.FailureWindow, stop: make(chan struct{}),
+	}
+	// the second factor is always protected, its codes are short
+	if t.maxTotpFailures <= 0 {
+		t.maxTotpFailures = defaultMaxTotpFailures
+	}
+	if t.lockDuration <= 0 {
+		t.lockDuration = defaultLockDuration
+	}
+	if t.maxLockDuration <= 0 {
+		t.maxLockDuration = defaultMaxLockDuration
+	}
+	if t.failureWindow <= 0 {
+		t.failureWindow = defaultFailureWindow
+	}
+
+	go func() {
+		ticker := time.NewTicker(failureSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case now := <-ticker.C:
+				if err := t.sweep(now); err != nil {
+					logger.Error("Failed to remove old login failures", common.ErrorKey, err)
+				}
+			}
+		}
+	}()
+	return t
+}
+
+func (t throttler) close() {
+	close(t.stop)
+}
+
+// an unknown clientIp is ignored, empty when the throttling is disabled
+func (t throttler) targets(login string, clientIp string) []throttleTarget {
+	var targets []throttleTarget
+	if t.maxLoginFailures > 0 {
+		targets = append(targets, throttleTarget{name: loginTargetPrefix + login, maxFailures: t.maxLoginFailures})
+	}
+	if t.maxIpFailures > 0 && clientIp != "" {
+		targets = append(targets, throttleTarget{name: ipTargetPrefix + clientIp, maxFailures: t.maxIpFailures})
+	}
+	return targets
+}
+
+func (t throttler) totpTargets(userId uint64) []throttleTarget {
+	return []throttleTarget{{name: totpTargetPrefix + strconv.FormatUint(userId, 10), maxFailures: t.maxTotpFailures}}
+}
+
+func (t throttler) locked(ctx context.Context, targets []throttleTarget, now time.Time) (bool, error) {
+	if len(targets) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := t.db.WithContext(ctx).Model(&loginFailure{}).Where(
+		"target IN ? AND locked_until > ?", targetNames(targets), now,
+	).Count(&count).Error
+	return count != 0, err
+}
+
+func (t throttler) fail(ctx context.Context, targets []throttleTarget, now time.Time) error {
+	db := t.db.WithContext(ctx)
+	for _, target := range targets {
+		// atomic increment, the failures older than the window are forgotten
+		err := db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "target"}},
+			DoUpdates: clause.Set{{
+				Column: clause.Column{Name: "failures"},
+				Value: gorm.Expr(
+					"CASE WHEN login_failures.last_failure <= ? THEN 1 ELSE login_failures.failures + 1 END",
+					now.Add(-t.failureWindow),
+				),
+			}, {Column: clause.Column{Name: "last_failure"}, Value: now}},
+		}).Create(&loginFailure{Target: target.name, Failures: 1, LastFailure: now, LockedUntil: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var failure loginFailure
+		if err = db.First(&failure, "target = ?", target.name).Error; err != nil {
+			return err
+		}
+
+		excess := failure.Failures - target.maxFailures
+		if excess < 0 {
+			continue
+		}
+
+		lockedUntil := now.Add(t.lockDurationFor(excess))
+		if err = db.Model(&failure).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t throttler) lockDurationFor(excess int) time.Duration {
+	duration := t.lockDuration
+	for ; excess > 0 && duration < t.maxLockDuration; excess-- {
+		duration *= 2
+	}
+	return min(duration, t.maxLockDuration)
+}
+
+// the failures of the client IP are kept, an attacker could reset them with its own account
+func (t throttler) succeed(ctx context.Context, login string) error {
+	if t.maxLoginFailures <= 0 {
+		return nil
+	}
+	return t.reset(ctx, []throttleTarget{{name: loginTargetPrefix + login}})
+}
+
+func (t throttler) reset(ctx context.Context, targets []throttleTarget) error {
+	return ClearLockouts(ctx, t.db, targetNames(targets)...)
+}
+
+func targetNames(targets []throttleTarget) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.name)
+	}
+	return names
+}
+
+func (t throttler) sweep(now time.Time) error {
+	return t.db.Where(
+		"last_failure <= ? AND locked_until <= ?", now.Add(-t.failureWindow), now,
+	).Delete(&loginFailure{}).Error
+}
+
+// ListLockouts returns the active lockouts, the most recent first.
+func ListLockouts(ctx context.Context, db *gorm.DB, now time.Time) ([]Lockout, error) {
+	var failures []loginFailure
+	err := db.WithContext(ctx).Where("locked_until > ?", now).Order("locked_until desc").Find(&failures).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := make([]Lockout, 0, len(failures))
+	for _, failure := range failures {
+		lockouts = append(lockouts, Lockout{
+			Target: failure.Target, Failures: uint64(failure.Failures),
+			LastFailure: failure.LastFailure.Unix(), LockedUntil: failure.LockedUntil.Unix(),
+		})
+	}
+	return lockouts, nil
+}
+
+// ClearLockouts removes the lockouts and the failure counts of the targets (like "login:name" or "ip:address").
+func ClearLockouts(ctx context.Context, db *gorm.DB, targets ...string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Delete(&loginFailure{}, "target IN ?", targets).Error
+}
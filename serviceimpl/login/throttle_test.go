@@ -0,0 +1,242 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testNow = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+// a sqlite file with the schema of the migrations
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := dbclient.New("sqlite", filepath.Join(t.TempDir(), "login.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the expected errors are not logged
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if _, err = Migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func testThrottler(t *testing.T, conf *loginConf) throttler {
+	t.Helper()
+	throttler := newThrottler(testLogger, testDB(t), conf)
+	t.Cleanup(throttler.close)
+	return throttler
+}
+
+func TestThrottlerTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     loginConf
+		clientIp string
+		want     []string
+	}{
+		{name: "disabled", conf: loginConf{}, clientIp: "10.0.0.1"},
+		{name: "login only", conf: loginConf{MaxLoginFailures: 3}, clientIp: "10.0.0.1", want: []string{"login:alice"}},
+		{name: "client IP only", conf: loginConf{MaxIpFailures: 10}, clientIp: "10.0.0.1", want: []string{"ip:10.0.0.1"}},
+		{name: "both", conf: loginConf{MaxLoginFailures: 3, MaxIpFailures: 10}, clientIp: "10.0.0.1", want: []string{"login:alice", "ip:10.0.0.1"}},
+		{name: "unknown client IP", conf: loginConf{MaxLoginFailures: 3, MaxIpFailures: 10}, want: []string{"login:alice"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttler := newThrottler(testLogger, nil, &test.conf)
+			defer throttler.close()
+
+			names := targetNames(throttler.targets("alice", test.clientIp))
+			if len(names) != len(test.want) {
+				t.Fatalf("got %v, want %v", names, test.want)
+			}
+			for index, name := range names {
+				if name != test.want[index] {
+					t.Errorf("got %v, want %v", names, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLockDurationFor(t *testing.T) {
+	throttler := throttler{lockDuration: time.Minute, maxLockDuration: time.Hour}
+	tests := []struct {
+		excess int
+		want   time.Duration
+	}{
+		{excess: 0, want: time.Minute},
+		{excess: 1, want: 2 * time.Minute},
+		{excess: 5, want: 32 * time.Minute},
+		{excess: 6, want: time.Hour},
+		{excess: 1000, want: time.Hour},
+	}
+	for _, test := range tests {
+		if got := throttler.lockDurationFor(test.excess); got != test.want {
+			t.Errorf("excess %d : got %v, want %v", test.excess, got, test.want)
+		}
+	}
+}
+
+func TestThrottlerLock(t *testing.T) {
+	ctx := context.Background()
+	conf := &loginConf{MaxLoginFailures: 3, LockDuration: time.Minute, MaxLockDuration: time.Hour, FailureWindow: time.Hour}
+
+	// each step adds failures at the given time, then checks the lock at the check time
+	type step struct {
+		failures   int
+		failAt     time.Duration
+		checkAt    time.Duration
+		wantLocked bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{name: "under the limit", steps: []step{{failures: 2, checkAt: 0}}},
+		{name: "at the limit", steps: []step{{failures: 3, checkAt: 0, wantLocked: true}}},
+		{name: "end of the lock", steps: []step{
+			{failures: 3, checkAt: time.Minute - time.Second, wantLocked: true},
+			{checkAt: time.Minute},
+		}},
+		{name: "doubled lock", steps: []step{
+			{failures: 3, wantLocked: true},
+			{failures: 1, failAt: time.Minute, checkAt: 3*time.Minute - time.Second, wantLocked: true},
+			{checkAt: 3 * time.Minute},
+		}},
+		{name: "failures out of the window", steps: []step{
+			{failures: 2},
+			{failures: 1, failAt: time.Hour, checkAt: time.Hour},
+			{failures: 1, failAt: time.Hour, checkAt: time.Hour},
+			{failures: 1, failAt: time.Hour, checkAt: time.Hour, wantLocked: true},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttler := testThrottler(t, conf)
+			targets := throttler.targets("alice", "")
+			for index, step := range test.steps {
+				for i := 0; i < step.failures; i++ {
+					if err := throttler.fail(ctx, targets, testNow.Add(step.failAt)); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				locked, err := throttler.locked(ctx, targets, testNow.Add(step.checkAt))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if locked != step.wantLocked {
+					t.Errorf("step %d : got locked %v, want %v", index, locked, step.wantLocked)
+				}
+			}
+		})
+	}
+}
+
+func TestThrottlerReset(t *testing.T) {
+	ctx := context.Background()
+	throttler := testThrottler(t, &loginConf{MaxLoginFailures: 1, MaxIpFailures: 1})
+	aliceTargets := throttler.targets("alice", "10.0.0.1")
+	if err := throttler.fail(ctx, aliceTargets, testNow); err != nil {
+		t.Fatal(err)
+	}
+
+	// an attacker can not unlock the client IP with its own account
+	if err := throttler.succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		targets    []throttleTarget
+		wantLocked bool
+	}{
+		{name: "login", targets: throttler.targets("alice", "")},
+		{name: "client IP", targets: throttler.targets("bob", "10.0.0.1"), wantLocked: true},
+		{name: "other client IP", targets: throttler.targets("bob", "10.0.0.2")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locked, err := throttler.locked(ctx, test.targets, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if locked != test.wantLocked {
+				t.Errorf("got locked %v, want %v", locked, test.wantLocked)
+			}
+		})
+	}
+
+	if err := ClearLockouts(ctx, throttler.db, "ip:10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := throttler.locked(ctx, aliceTargets, testNow); locked {
+		t.Error("still locked after the clear")
+	}
+}
+
+func TestThrottlerSweep(t *testing.T) {
+	ctx := context.Background()
+	throttler := testThrottler(t, &loginConf{
+		MaxLoginFailures: 1, LockDuration: 2 * time.Hour, MaxLockDuration: 2 * time.Hour, FailureWindow: time.Hour,
+	})
+	// locked beyond the window
+	if err := throttler.fail(ctx, throttler.targets("alice", ""), testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttler.fail(ctx, []throttleTarget{{name: "login:bob", maxFailures: 2}}, testNow); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sweepAt   time.Duration
+		wantCount int64
+	}{
+		{name: "inside the window", sweepAt: time.Hour - time.Second, wantCount: 2},
+		{name: "end of the window, one still locked", sweepAt: time.Hour, wantCount: 1},
+		{name: "end of the lock", sweepAt: 2 * time.Hour, wantCount: 0},
+	}
+	for _, test := range tests {
+		if err := throttler.sweep(testNow.Add(test.sweepAt)); err != nil {
+			t.Fatal(err)
+		}
+
+		var count int64
+		if err := throttler.db.Model(&loginFailure{}).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != test.wantCount {
+			t.Errorf("%s : got %d failures, want %d", test.name, count, test.wantCount)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvaumoron/puzzleloginserver/model"
+	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
+	"github.com/dvaumoron/puzzleweb/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RFC 6238 with the parameters supported by most applications
+const (
+	totpDigits     = 6
+	totpPeriod     = 30 // in seconds
+	totpSecretSize = 20
+	// steps accepted around the current one, for the clock drift
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10
+
+	totpTargetPrefix  = "totp:"
+	totpSecretName    = "totpSecret"
+	defaultTotpIssuer = "PuzzleWeaver"
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type userTotp struct {
+	UserId uint64 `gorm:"primaryKey;autoIncrement:false"`
+	// base32, encrypted with the key ring when there is one
+	Secret string
+	// false until the first code is confirmed
+	Enabled bool
+	// a step is accepted only once
+	LastStep int64
+}
+
+func (userTotp) TableName() string {
+	return "user_totps"
+}
+
+type recoveryCode struct {
+	UserId   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	CodeHash string `gorm:"primaryKey;size:64"`
+}
+
+func (recoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+func (impl *loginImpl) GetTotpStatus(ctx context.Context, userId uint64) (bool, error) {
+	totp, _, err := impl.loadTotp(ctx, userId)
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return false, servicecommon.ErrInternal
+	}
+	return totp.Enabled, nil
+}
+
+func (impl *loginImpl) StartTotp(ctx context.Context, userId uint64) (string, error) {
+	logger := impl.Logger(ctx)
+	db := impl.initializedConf.db.WithContext(ctx)
+
+	var user model.User
+	if err := db.First(&user, userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", common.ErrWrongLogin
+		}
+
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
+	}
+
+	totp, secret, err := impl.loadTotp(ctx, userId)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return "", servicecommon.ErrInternal
+	}
+	if totp.Enabled {
+		return "", servicecommon.ErrTotpEnabled
+	}
+
+	// the pending secret is kept until its confirmation, so the enrolment can be displayed again
+	if secret == nil {
+		secret = make([]byte, totpSecretSize)
+		if _, err = rand.Read(secret); err != nil {
+			logger.Error("Failed to generate a TOTP secret", common.ErrorKey, err)
+			return "", servicecommon.ErrInternal
+		}
+
+		storedSecret, err := impl.initializedConf.keyRing.Encrypt(strconv.FormatUint(userId, 10), totpSecretName, base32Encoding.EncodeToString(secret))
+		if err != nil {
+			logger.Error(servicecommon.EncryptionMsg, common.ErrorKey, err)
+			return "", servicecommon.ErrInternal
+		}
+
+		err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&userTotp{UserId: userId, Secret: storedSecret}).Error
+		if err == nil {
+			// the secret of a concurrent call could have been kept
+			totp, secret, err = impl.loadTotp(ctx, userId)
+		}
+		if err != nil {
+			logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+			return "", servicecommon.ErrInternal
+		}
+		if totp.Enabled {
+			return "", servicecommon.ErrTotpEnabled
+		}
+	}
+	return provisioningUri(impl.initializedConf.totpIssuer, user.Login, secret), nil
+}
+
+// return the recovery codes (only given here, replacing the previous ones)
+func (impl *loginImpl) ConfirmTotp(ctx context.Context, userId uint64, code string) ([]string, error) {
+	logger := impl.Logger(ctx)
+	totp, secret, err := impl.loadTotp(ctx, userId)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+	if totp.Enabled {
+		return nil, servicecommon.ErrTotpEnabled
+	}
+	if secret == nil {
+		return nil, servicecommon.ErrWrongTotp
+	}
+
+	step := matchTotp(secret, normalizeCode(code), time.Now())
+	if step == 0 {
+		return nil, servicecommon.ErrWrongTotp
+	}
+
+	codes, rows, err := newRecoveryCodes(userId)
+	if err != nil {
+		logger.Error("Failed to generate recovery codes", common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+
+	err = impl.initializedConf.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&userTotp{}).Where("user_id = ? AND enabled = ?", userId, false).Updates(map[string]any{
+			"enabled": true, "last_step": step,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return servicecommon.ErrTotpEnabled
+		}
+
+		if err := tx.Where("user_id = ?", userId).Delete(&recoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		if err == servicecommon.ErrTotpEnabled {
+			return nil, err
+		}
+
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+	return codes, nil
+}
+
+// accept a TOTP code or an unused recovery code (which is consumed)
+func (impl *loginImpl) VerifyTotp(ctx context.Context, userId uint64, code string) error {
+	logger := impl.Logger(ctx)
+	throttler := impl.initializedConf.throttler
+	targets := throttler.totpTargets(userId)
+	now := time.Now()
+	locked, err := throttler.locked(ctx, targets, now)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if locked {
+		return servicecommon.ErrLocked
+	}
+
+	ok, err := impl.checkTotp(ctx, userId, normalizeCode(code), now)
+	if err != nil {
+		logger.Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+
+	if ok {
+		err = throttler.reset(ctx, targets)
+	} else {
+		err = throttler.fail(ctx, targets, now)
+	}
+	if err != nil {
+		logger.Error("Failed to count login failures", common.ErrorKey, err)
+	}
+	if !ok {
+		return servicecommon.ErrWrongTotp
+	}
+	return nil
+}
+
+func (impl *loginImpl) DisableTotp(ctx context.Context, userId uint64, code string) error {
+	if err := impl.VerifyTotp(ctx, userId, code); err != nil {
+		return err
+	}
+	return impl.ResetTotp(ctx, userId)
+}
+
+func (impl *loginImpl) ResetTotp(ctx context.Context, userId uint64) error {
+	if err := deleteTotp(impl.initializedConf.db.WithContext(ctx), userId); err != nil {
+		impl.Logger(ctx).Error(servicecommon.DBAccessMsg, common.ErrorKey, err)
+		return common.ErrUpdate
+	}
+	return nil
+}
+
+func deleteTotp(db *gorm.DB, userId uint64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&recoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&userTotp{}, userId).Error
+	})
+}
+
+// the secret is nil when there is no TOTP for the user
+func (impl *loginImpl) loadTotp(ctx context.Context, userId uint64) (userTotp, []byte, error) {
+	var totp userTotp
+	if err := impl.initializedConf.db.WithContext(ctx).First(&totp, userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userTotp{}, nil, nil
+		}
+		return userTotp{}, nil, err
+	}
+
+	encodedSecret, err := impl.initializedConf.keyRing.Decrypt(strconv.FormatUint(userId, 10), totpSecretName, totp.Secret)
+	if err != nil {
+		return userTotp{}, nil, err
+	}
+	secret, err := base32Encoding.DecodeString(encodedSecret)
+	return totp, secret, err
+}
+
+func (impl *loginImpl) checkTotp(ctx context.Context, userId uint64, code string, now time.Time) (bool, error) {
+	totp, secret, err := impl.loadTotp(ctx, userId)
+	if err != nil || !totp.Enabled {
+		return false, err
+	}
+
+	db := impl.initializedConf.db.WithContext(ctx)
+	if len(code) == totpDigits {
+		step := matchTotp(secret, code, now)
+		if step == 0 {
+			return false, nil
+		}
+
+		// atomic, against the replay of a code
+		result := db.Model(&userTotp{}).Where("user_id = ? AND last_step < ?", userId, step).Update("last_step", step)
+		return result.RowsAffected != 0, result.Error
+	}
+
+	result := db.Where("user_id = ? AND code_hash = ?", userId, hashRecoveryCode(code)).Delete(&recoveryCode{})
+	return result.RowsAffected != 0, result.Error
+}
+
+func totpCode(secret []byte, step int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(step))
+	hasher := hmac.New(sha1.New, secret)
+	hasher.Write(message[:])
+	sum := hasher.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// return the matching step, zero when there is none
+func matchTotp(secret []byte, code string, now time.Time) int64 {
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step
+		}
+	}
+	return 0
+}
+
+// the otpauth URI displayed as a QR code by the enrolment page
+func provisioningUri(issuer string, login string, secret []byte) string {
+	query := url.Values{}
+	query.Set("secret", base32Encoding.EncodeToString(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(totpPeriod))
+
+	uri := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + issuer + ":" + login, RawQuery: query.Encode()}
+	return uri.String()
+}
+
+// return the codes to display ("xxxxx-xxxxx") and the rows to store
+func newRecoveryCodes(userId uint64) ([]string, []recoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]recoveryCode, 0, recoveryCodeCount)
+	buffer := make([]byte, 7)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buffer); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32Encoding.EncodeToString(buffer)[:recoveryCodeSize])
+		half := recoveryCodeSize / 2
+		codes = append(codes, code[:half]+"-"+code[half:])
+		rows = append(rows, recoveryCode{UserId: userId, CodeHash: hashRecoveryCode(code)})
+	}
+	return codes, rows, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -0,0 +1,213 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginimpl
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+)
+
+// secret of the test vectors of RFC 6238 (for SHA1)
+var rfcSecret = []byte("12345678901234567890")
+
+func TestTotpCode(t *testing.T) {
+	// the last six digits of the eight digits of the RFC
+	tests := []struct {
+		unixTime int64
+		want     string
+	}{
+		{unixTime: 59, want: "287082"},
+		{unixTime: 1111111109, want: "081804"},
+		{unixTime: 1111111111, want: "050471"},
+		{unixTime: 1234567890, want: "005924"},
+		{unixTime: 2000000000, want: "279037"},
+		{unixTime: 20000000000, want: "353130"},
+	}
+	for _, test := range tests {
+		if got := totpCode(rfcSecret, test.unixTime/totpPeriod); got != test.want {
+			t.Errorf("time %d : got %s, want %s", test.unixTime, got, test.want)
+		}
+	}
+}
+
+func TestMatchTotp(t *testing.T) {
+	current := testNow.Unix() / totpPeriod
+	tests := []struct {
+		name string
+		code string
+		want int64
+	}{
+		{name: "current step", code: totpCode(rfcSecret, current), want: current},
+		{name: "previous step", code: totpCode(rfcSecret, current-totpSkew), want: current - totpSkew},
+		{name: "next step", code: totpCode(rfcSecret, current+totpSkew), want: current + totpSkew},
+		{name: "too old", code: totpCode(rfcSecret, current-totpSkew-1)},
+		{name: "too early", code: totpCode(rfcSecret, current+totpSkew+1)},
+		{name: "other secret", code: totpCode([]byte("09876543210987654321"), current)},
+		{name: "empty", code: ""},
+		{name: "too short", code: totpCode(rfcSecret, current)[:totpDigits-1]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchTotp(rfcSecret, test.code, testNow); got != test.want {
+				t.Errorf("got step %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, rows, err := newRecoveryCodes(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(rows) != recoveryCodeCount {
+		t.Fatalf("got %d codes and %d rows, want %d", len(codes), len(rows), recoveryCodeCount)
+	}
+
+	format := regexp.MustCompile("^[a-z2-7]{5}-[a-z2-7]{5}$")
+	seen := map[string]bool{}
+	for index, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("unexpected format : %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicated code : %q", code)
+		}
+		seen[code] = true
+
+		// the code is typed back with another case or without the separator
+		if row := rows[index]; row.UserId != 42 || row.CodeHash != hashRecoveryCode(normalizeCode(code)) {
+			t.Errorf("row %v does not match %q", row, code)
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "abcde-fghij", want: "abcdefghij"},
+		{code: "ABCDE-FGHIJ", want: "abcdefghij"},
+		{code: " abcde fghij ", want: "abcdefghij"},
+		{code: "123 456", want: "123456"},
+		{code: "", want: ""},
+	}
+	for _, test := range tests {
+		if got := normalizeCode(test.code); got != test.want {
+			t.Errorf("%q : got %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+// an enabled TOTP stored like ConfirmTotp does, with its recovery codes
+func testTotpImpl(t *testing.T, keyRing *cryptoclient.KeyRing, userId uint64, enabled bool) (*loginImpl, []string) {
+	t.Helper()
+	impl := &loginImpl{initializedConf: initializedLoginConf{db: testDB(t), keyRing: keyRing}}
+
+	secret, err := keyRing.Encrypt(strconv.FormatUint(userId, 10), totpSecretName, base32Encoding.EncodeToString(rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = impl.initializedConf.db.Create(&userTotp{UserId: userId, Secret: secret, Enabled: enabled}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	codes, rows, err := newRecoveryCodes(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = impl.initializedConf.db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	return impl, codes
+}
+
+func TestCheckTotp(t *testing.T) {
+	ctx := context.Background()
+	current := testNow.Unix() / totpPeriod
+	keyRing, err := cryptoclient.Load(testLogger, testKeyConf(t, "k1", "k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impl, codes := testTotpImpl(t, keyRing, 42, true)
+	// each check follows the previous ones of the list
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{name: "current code", code: totpCode(rfcSecret, current), at: testNow, want: true},
+		{name: "replayed code", code: totpCode(rfcSecret, current), at: testNow},
+		{name: "older code", code: totpCode(rfcSecret, current-1), at: testNow},
+		{name: "next code", code: totpCode(rfcSecret, current+1), at: testNow, want: true},
+		{name: "wrong code", code: "000000", at: testNow.Add(time.Hour)},
+		{name: "recovery code", code: normalizeCode(codes[0]), at: testNow, want: true},
+		{name: "used recovery code", code: normalizeCode(codes[0]), at: testNow},
+		{name: "other recovery code", code: normalizeCode(codes[1]), at: testNow, want: true},
+		{name: "unknown recovery code", code: "abcdefghij", at: testNow},
+	}
+	for _, test := range tests {
+		ok, err := impl.checkTotp(ctx, 42, test.code, test.at)
+		if err != nil {
+			t.Fatalf("%s : %v", test.name, err)
+		}
+		if ok != test.want {
+			t.Errorf("%s : got %v, want %v", test.name, ok, test.want)
+		}
+	}
+
+	// the secret is bound to its user
+	if err = impl.initializedConf.db.Model(&userTotp{}).Where("user_id = ?", 42).Update("user_id", 43).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err = impl.checkTotp(ctx, 43, totpCode(rfcSecret, current+10), testNow.Add(10*totpPeriod*time.Second)); err == nil {
+		t.Error("secret of another user accepted")
+	}
+}
+
+func TestCheckTotpDisabled(t *testing.T) {
+	ctx := context.Background()
+	current := testNow.Unix() / totpPeriod
+	impl, codes := testTotpImpl(t, nil, 42, false)
+	tests := []struct {
+		name   string
+		userId uint64
+		code   string
+	}{
+		{name: "not confirmed", userId: 42, code: totpCode(rfcSecret, current)},
+		{name: "recovery code not confirmed", userId: 42, code: normalizeCode(codes[0])},
+		{name: "no TOTP", userId: 43, code: totpCode(rfcSecret, current)},
+	}
+	for _, test := range tests {
+		ok, err := impl.checkTotp(ctx, test.userId, test.code, testNow)
+		if err != nil {
+			t.Fatalf("%s : %v", test.name, err)
+		}
+		if ok {
+			t.Errorf("%s : code accepted", test.name)
+		}
+	}
+}
@@ -20,10 +20,10 @@ func init() {
 		Iface: reflect.TypeOf((*RemoteLoginService)(nil)).Elem(),
 		Impl:  reflect.TypeOf(loginImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return remoteLoginService_local_stub{impl: impl.(RemoteLoginService), tracer: tracer, changeLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeLogin", Remote: false}), changePasswordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangePassword", Remote: false}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Delete", Remote: false}), getUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetUsers", Remote: false}), listUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsers", Remote: false}), registerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Register", Remote: false}), verifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Verify", Remote: false})}
+			return remoteLoginService_local_stub{impl: impl.(RemoteLoginService), tracer: tracer, changeEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeEmail", Remote: false}), changeLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeLogin", Remote: false}), changePasswordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangePassword", Remote: false}), clearLockoutMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ClearLockout", Remote: false}), confirmTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ConfirmTotp", Remote: false}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Delete", Remote: false}), disableTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "DisableTotp", Remote: false}), getEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetEmail", Remote: false}), getHashParamsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetHashParams", Remote: false}), getTotpStatusMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetTotpStatus", Remote: false}), getUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetUsers", Remote: false}), listLockoutsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListLockouts", Remote: false}), listUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsers", Remote: false}), listUsersPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsersPage", Remote: false}), registerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Register", Remote: false}), resetTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ResetTotp", Remote: false}), resolveLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ResolveLogin", Remote: false}), sendEmailVerificationMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "SendEmailVerification", Remote: false}), startTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "StartTotp", Remote: false}), verifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Verify", Remote: false}), verifyEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "VerifyEmail", Remote: false}), verifyTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "VerifyTotp", Remote: false})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return remoteLoginService_client_stub{stub: stub, changeLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeLogin", Remote: true}), changePasswordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangePassword", Remote: true}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Delete", Remote: true}), getUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetUsers", Remote: true}), listUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsers", Remote: true}), registerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Register", Remote: true}), verifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Verify", Remote: true})}
+			return remoteLoginService_client_stub{stub: stub, changeEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeEmail", Remote: true}), changeLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangeLogin", Remote: true}), changePasswordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ChangePassword", Remote: true}), clearLockoutMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ClearLockout", Remote: true}), confirmTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ConfirmTotp", Remote: true}), deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Delete", Remote: true}), disableTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "DisableTotp", Remote: true}), getEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetEmail", Remote: true}), getHashParamsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetHashParams", Remote: true}), getTotpStatusMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetTotpStatus", Remote: true}), getUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "GetUsers", Remote: true}), listLockoutsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListLockouts", Remote: true}), listUsersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsers", Remote: true}), listUsersPageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ListUsersPage", Remote: true}), registerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Register", Remote: true}), resetTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ResetTotp", Remote: true}), resolveLoginMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "ResolveLogin", Remote: true}), sendEmailVerificationMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "SendEmailVerification", Remote: true}), startTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "StartTotp", Remote: true}), verifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "Verify", Remote: true}), verifyEmailMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "VerifyEmail", Remote: true}), verifyTotpMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/login/RemoteLoginService", Method: "VerifyTotp", Remote: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return remoteLoginService_server_stub{impl: impl.(RemoteLoginService), addLoad: addLoad}
@@ -44,20 +44,55 @@ var _ weaver.Unrouted = (*loginImpl)(nil)
 // Local stub implementations.
 
 type remoteLoginService_local_stub struct {
-	impl                  RemoteLoginService
-	tracer                trace.Tracer
-	changeLoginMetrics    *codegen.MethodMetrics
-	changePasswordMetrics *codegen.MethodMetrics
-	deleteMetrics         *codegen.MethodMetrics
-	getUsersMetrics       *codegen.MethodMetrics
-	listUsersMetrics      *codegen.MethodMetrics
-	registerMetrics       *codegen.MethodMetrics
-	verifyMetrics         *codegen.MethodMetrics
+	impl                         RemoteLoginService
+	tracer                       trace.Tracer
+	changeEmailMetrics           *codegen.MethodMetrics
+	changeLoginMetrics           *codegen.MethodMetrics
+	changePasswordMetrics        *codegen.MethodMetrics
+	clearLockoutMetrics          *codegen.MethodMetrics
+	confirmTotpMetrics           *codegen.MethodMetrics
+	deleteMetrics                *codegen.MethodMetrics
+	disableTotpMetrics           *codegen.MethodMetrics
+	getEmailMetrics              *codegen.MethodMetrics
+	getHashParamsMetrics         *codegen.MethodMetrics
+	getTotpStatusMetrics         *codegen.MethodMetrics
+	getUsersMetrics              *codegen.MethodMetrics
+	listLockoutsMetrics          *codegen.MethodMetrics
+	listUsersMetrics             *codegen.MethodMetrics
+	listUsersPageMetrics         *codegen.MethodMetrics
+	registerMetrics              *codegen.MethodMetrics
+	resetTotpMetrics             *codegen.MethodMetrics
+	resolveLoginMetrics          *codegen.MethodMetrics
+	sendEmailVerificationMetrics *codegen.MethodMetrics
+	startTotpMetrics             *codegen.MethodMetrics
+	verifyMetrics                *codegen.MethodMetrics
+	verifyEmailMetrics           *codegen.MethodMetrics
+	verifyTotpMetrics            *codegen.MethodMetrics
 }
 
 // Check that remoteLoginService_local_stub implements the RemoteLoginService interface.
 var _ RemoteLoginService = (*remoteLoginService_local_stub)(nil)
 
+func (s remoteLoginService_local_stub) ChangeEmail(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
+	// Update metrics.
+	begin := s.changeEmailMetrics.Begin()
+	defer func() { s.changeEmailMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ChangeEmail", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ChangeEmail(ctx, a0, a1, a2, a3)
+}
+
 func (s remoteLoginService_local_stub) ChangeLogin(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
 	// Update metrics.
 	begin := s.changeLoginMetrics.Begin()
@@ -98,6 +133,46 @@ func (s remoteLoginService_local_stub) ChangePassword(ctx context.Context, a0 ui
 	return s.impl.ChangePassword(ctx, a0, a1, a2)
 }
 
+func (s remoteLoginService_local_stub) ClearLockout(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	begin := s.clearLockoutMetrics.Begin()
+	defer func() { s.clearLockoutMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ClearLockout", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ClearLockout(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) ConfirmTotp(ctx context.Context, a0 uint64, a1 string) (r0 []string, err error) {
+	// Update metrics.
+	begin := s.confirmTotpMetrics.Begin()
+	defer func() { s.confirmTotpMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ConfirmTotp", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ConfirmTotp(ctx, a0, a1)
+}
+
 func (s remoteLoginService_local_stub) Delete(ctx context.Context, a0 uint64) (err error) {
 	// Update metrics.
 	begin := s.deleteMetrics.Begin()
@@ -118,6 +193,86 @@ func (s remoteLoginService_local_stub) Delete(ctx context.Context, a0 uint64) (e
 	return s.impl.Delete(ctx, a0)
 }
 
+func (s remoteLoginService_local_stub) DisableTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	// Update metrics.
+	begin := s.disableTotpMetrics.Begin()
+	defer func() { s.disableTotpMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.DisableTotp", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.DisableTotp(ctx, a0, a1)
+}
+
+func (s remoteLoginService_local_stub) GetEmail(ctx context.Context, a0 uint64) (r0 string, r1 bool, err error) {
+	// Update metrics.
+	begin := s.getEmailMetrics.Begin()
+	defer func() { s.getEmailMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.GetEmail", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetEmail(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) GetHashParams(ctx context.Context, a0 string, a1 string, a2 string) (r0 string, err error) {
+	// Update metrics.
+	begin := s.getHashParamsMetrics.Begin()
+	defer func() { s.getHashParamsMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.GetHashParams", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetHashParams(ctx, a0, a1, a2)
+}
+
+func (s remoteLoginService_local_stub) GetTotpStatus(ctx context.Context, a0 uint64) (r0 bool, err error) {
+	// Update metrics.
+	begin := s.getTotpStatusMetrics.Begin()
+	defer func() { s.getTotpStatusMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.GetTotpStatus", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetTotpStatus(ctx, a0)
+}
+
 func (s remoteLoginService_local_stub) GetUsers(ctx context.Context, a0 []uint64) (r0 map[uint64]RawUser, err error) {
 	// Update metrics.
 	begin := s.getUsersMetrics.Begin()
@@ -138,6 +293,26 @@ func (s remoteLoginService_local_stub) GetUsers(ctx context.Context, a0 []uint64
 	return s.impl.GetUsers(ctx, a0)
 }
 
+func (s remoteLoginService_local_stub) ListLockouts(ctx context.Context) (r0 []Lockout, err error) {
+	// Update metrics.
+	begin := s.listLockoutsMetrics.Begin()
+	defer func() { s.listLockoutsMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ListLockouts", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ListLockouts(ctx)
+}
+
 func (s remoteLoginService_local_stub) ListUsers(ctx context.Context, a0 uint64, a1 uint64, a2 string) (r0 uint64, r1 []RawUser, err error) {
 	// Update metrics.
 	begin := s.listUsersMetrics.Begin()
@@ -158,7 +333,27 @@ func (s remoteLoginService_local_stub) ListUsers(ctx context.Context, a0 uint64,
 	return s.impl.ListUsers(ctx, a0, a1, a2)
 }
 
-func (s remoteLoginService_local_stub) Register(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
+func (s remoteLoginService_local_stub) ListUsersPage(ctx context.Context, a0 string, a1 uint64, a2 string) (r0 []RawUser, r1 string, r2 string, err error) {
+	// Update metrics.
+	begin := s.listUsersPageMetrics.Begin()
+	defer func() { s.listUsersPageMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ListUsersPage", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ListUsersPage(ctx, a0, a1, a2)
+}
+
+func (s remoteLoginService_local_stub) Register(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
 	// Update metrics.
 	begin := s.registerMetrics.Begin()
 	defer func() { s.registerMetrics.End(begin, err != nil, 0, 0) }()
@@ -175,10 +370,90 @@ func (s remoteLoginService_local_stub) Register(ctx context.Context, a0 string,
 		}()
 	}
 
-	return s.impl.Register(ctx, a0, a1)
+	return s.impl.Register(ctx, a0, a1, a2)
+}
+
+func (s remoteLoginService_local_stub) ResetTotp(ctx context.Context, a0 uint64) (err error) {
+	// Update metrics.
+	begin := s.resetTotpMetrics.Begin()
+	defer func() { s.resetTotpMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ResetTotp", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ResetTotp(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) ResolveLogin(ctx context.Context, a0 string) (r0 string, err error) {
+	// Update metrics.
+	begin := s.resolveLoginMetrics.Begin()
+	defer func() { s.resolveLoginMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.ResolveLogin", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ResolveLogin(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) SendEmailVerification(ctx context.Context, a0 uint64) (err error) {
+	// Update metrics.
+	begin := s.sendEmailVerificationMetrics.Begin()
+	defer func() { s.sendEmailVerificationMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.SendEmailVerification", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.SendEmailVerification(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) StartTotp(ctx context.Context, a0 uint64) (r0 string, err error) {
+	// Update metrics.
+	begin := s.startTotpMetrics.Begin()
+	defer func() { s.startTotpMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.StartTotp", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.StartTotp(ctx, a0)
 }
 
-func (s remoteLoginService_local_stub) Verify(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
+func (s remoteLoginService_local_stub) Verify(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
 	// Update metrics.
 	begin := s.verifyMetrics.Begin()
 	defer func() { s.verifyMetrics.End(begin, err != nil, 0, 0) }()
@@ -195,35 +470,90 @@ func (s remoteLoginService_local_stub) Verify(ctx context.Context, a0 string, a1
 		}()
 	}
 
-	return s.impl.Verify(ctx, a0, a1)
+	return s.impl.Verify(ctx, a0, a1, a2)
+}
+
+func (s remoteLoginService_local_stub) VerifyEmail(ctx context.Context, a0 string) (r0 uint64, err error) {
+	// Update metrics.
+	begin := s.verifyEmailMetrics.Begin()
+	defer func() { s.verifyEmailMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.VerifyEmail", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.VerifyEmail(ctx, a0)
+}
+
+func (s remoteLoginService_local_stub) VerifyTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	// Update metrics.
+	begin := s.verifyTotpMetrics.Begin()
+	defer func() { s.verifyTotpMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "loginimpl.RemoteLoginService.VerifyTotp", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.VerifyTotp(ctx, a0, a1)
 }
 
 // Client stub implementations.
 
 type remoteLoginService_client_stub struct {
-	stub                  codegen.Stub
-	changeLoginMetrics    *codegen.MethodMetrics
-	changePasswordMetrics *codegen.MethodMetrics
-	deleteMetrics         *codegen.MethodMetrics
-	getUsersMetrics       *codegen.MethodMetrics
-	listUsersMetrics      *codegen.MethodMetrics
-	registerMetrics       *codegen.MethodMetrics
-	verifyMetrics         *codegen.MethodMetrics
+	stub                         codegen.Stub
+	changeEmailMetrics           *codegen.MethodMetrics
+	changeLoginMetrics           *codegen.MethodMetrics
+	changePasswordMetrics        *codegen.MethodMetrics
+	clearLockoutMetrics          *codegen.MethodMetrics
+	confirmTotpMetrics           *codegen.MethodMetrics
+	deleteMetrics                *codegen.MethodMetrics
+	disableTotpMetrics           *codegen.MethodMetrics
+	getEmailMetrics              *codegen.MethodMetrics
+	getHashParamsMetrics         *codegen.MethodMetrics
+	getTotpStatusMetrics         *codegen.MethodMetrics
+	getUsersMetrics              *codegen.MethodMetrics
+	listLockoutsMetrics          *codegen.MethodMetrics
+	listUsersMetrics             *codegen.MethodMetrics
+	listUsersPageMetrics         *codegen.MethodMetrics
+	registerMetrics              *codegen.MethodMetrics
+	resetTotpMetrics             *codegen.MethodMetrics
+	resolveLoginMetrics          *codegen.MethodMetrics
+	sendEmailVerificationMetrics *codegen.MethodMetrics
+	startTotpMetrics             *codegen.MethodMetrics
+	verifyMetrics                *codegen.MethodMetrics
+	verifyEmailMetrics           *codegen.MethodMetrics
+	verifyTotpMetrics            *codegen.MethodMetrics
 }
 
 // Check that remoteLoginService_client_stub implements the RemoteLoginService interface.
 var _ RemoteLoginService = (*remoteLoginService_client_stub)(nil)
 
-func (s remoteLoginService_client_stub) ChangeLogin(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
+func (s remoteLoginService_client_stub) ChangeEmail(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.changeLoginMetrics.Begin()
-	defer func() { s.changeLoginMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.changeEmailMetrics.Begin()
+	defer func() { s.changeEmailMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ChangeLogin", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ChangeEmail", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -275,16 +605,16 @@ func (s remoteLoginService_client_stub) ChangeLogin(ctx context.Context, a0 uint
 	return
 }
 
-func (s remoteLoginService_client_stub) ChangePassword(ctx context.Context, a0 uint64, a1 string, a2 string) (err error) {
+func (s remoteLoginService_client_stub) ChangeLogin(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.changePasswordMetrics.Begin()
-	defer func() { s.changePasswordMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.changeLoginMetrics.Begin()
+	defer func() { s.changeLoginMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ChangePassword", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ChangeLogin", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -309,6 +639,7 @@ func (s remoteLoginService_client_stub) ChangePassword(ctx context.Context, a0 u
 	size += 8
 	size += (4 + len(a1))
 	size += (4 + len(a2))
+	size += (4 + len(a3))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
@@ -316,6 +647,7 @@ func (s remoteLoginService_client_stub) ChangePassword(ctx context.Context, a0 u
 	enc.Uint64(a0)
 	enc.String(a1)
 	enc.String(a2)
+	enc.String(a3)
 	var shardKey uint64
 
 	// Call the remote method.
@@ -334,16 +666,16 @@ func (s remoteLoginService_client_stub) ChangePassword(ctx context.Context, a0 u
 	return
 }
 
-func (s remoteLoginService_client_stub) Delete(ctx context.Context, a0 uint64) (err error) {
+func (s remoteLoginService_client_stub) ChangePassword(ctx context.Context, a0 uint64, a1 string, a2 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.deleteMetrics.Begin()
-	defer func() { s.deleteMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.changePasswordMetrics.Begin()
+	defer func() { s.changePasswordMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Delete", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ChangePassword", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -366,11 +698,15 @@ func (s remoteLoginService_client_stub) Delete(ctx context.Context, a0 uint64) (
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
+	size += (4 + len(a1))
+	size += (4 + len(a2))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
 	enc.Uint64(a0)
+	enc.String(a1)
+	enc.String(a2)
 	var shardKey uint64
 
 	// Call the remote method.
@@ -389,16 +725,16 @@ func (s remoteLoginService_client_stub) Delete(ctx context.Context, a0 uint64) (
 	return
 }
 
-func (s remoteLoginService_client_stub) GetUsers(ctx context.Context, a0 []uint64) (r0 map[uint64]RawUser, err error) {
+func (s remoteLoginService_client_stub) ClearLockout(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.getUsersMetrics.Begin()
-	defer func() { s.getUsersMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.clearLockoutMetrics.Begin()
+	defer func() { s.clearLockoutMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.GetUsers", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ClearLockout", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -420,12 +756,12 @@ func (s remoteLoginService_client_stub) GetUsers(ctx context.Context, a0 []uint6
 
 	// Preallocate a buffer of the right size.
 	size := 0
-	size += (4 + (len(a0) * 8))
+	size += (4 + len(a0))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
-	serviceweaver_enc_slice_uint64_489cb07a(enc, a0)
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
@@ -440,21 +776,20 @@ func (s remoteLoginService_client_stub) GetUsers(ctx context.Context, a0 []uint6
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_map_uint64_RawUser_53d8d616(dec)
 	err = dec.Error()
 	return
 }
 
-func (s remoteLoginService_client_stub) ListUsers(ctx context.Context, a0 uint64, a1 uint64, a2 string) (r0 uint64, r1 []RawUser, err error) {
+func (s remoteLoginService_client_stub) ConfirmTotp(ctx context.Context, a0 uint64, a1 string) (r0 []string, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.listUsersMetrics.Begin()
-	defer func() { s.listUsersMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.confirmTotpMetrics.Begin()
+	defer func() { s.confirmTotpMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ListUsers", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ConfirmTotp", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -477,15 +812,13 @@ func (s remoteLoginService_client_stub) ListUsers(ctx context.Context, a0 uint64
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	size += 8
-	size += (4 + len(a2))
+	size += (4 + len(a1))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
 	enc.Uint64(a0)
-	enc.Uint64(a1)
-	enc.String(a2)
+	enc.String(a1)
 	var shardKey uint64
 
 	// Call the remote method.
@@ -500,22 +833,21 @@ func (s remoteLoginService_client_stub) ListUsers(ctx context.Context, a0 uint64
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = dec.Uint64()
-	r1 = serviceweaver_dec_slice_RawUser_9050e128(dec)
+	r0 = serviceweaver_dec_slice_string_4af10117(dec)
 	err = dec.Error()
 	return
 }
 
-func (s remoteLoginService_client_stub) Register(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
+func (s remoteLoginService_client_stub) Delete(ctx context.Context, a0 uint64) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.registerMetrics.Begin()
-	defer func() { s.registerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Register", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Delete", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -537,20 +869,75 @@ func (s remoteLoginService_client_stub) Register(ctx context.Context, a0 string,
 
 	// Preallocate a buffer of the right size.
 	size := 0
-	size += (4 + len(a0))
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) DisableTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.disableTotpMetrics.Begin()
+	defer func() { s.disableTotpMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.DisableTotp", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
 	size += (4 + len(a1))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
-	enc.String(a0)
+	enc.Uint64(a0)
 	enc.String(a1)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -559,21 +946,77 @@ func (s remoteLoginService_client_stub) Register(ctx context.Context, a0 string,
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = dec.Uint64()
 	err = dec.Error()
 	return
 }
 
-func (s remoteLoginService_client_stub) Verify(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
+func (s remoteLoginService_client_stub) GetEmail(ctx context.Context, a0 uint64) (r0 string, r1 bool, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.verifyMetrics.Begin()
-	defer func() { s.verifyMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.getEmailMetrics.Begin()
+	defer func() { s.getEmailMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Verify", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.GetEmail", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	r1 = dec.Bool()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) GetHashParams(ctx context.Context, a0 string, a1 string, a2 string) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getHashParamsMetrics.Begin()
+	defer func() { s.getHashParamsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.GetHashParams", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -597,18 +1040,20 @@ func (s remoteLoginService_client_stub) Verify(ctx context.Context, a0 string, a
 	size := 0
 	size += (4 + len(a0))
 	size += (4 + len(a1))
+	size += (4 + len(a2))
 	enc := codegen.NewEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
 	enc.String(a0)
 	enc.String(a1)
+	enc.String(a2)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 8, enc.Data(), shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -617,67 +1062,1209 @@ func (s remoteLoginService_client_stub) Verify(ctx context.Context, a0 string, a
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = dec.Uint64()
+	r0 = dec.String()
 	err = dec.Error()
 	return
 }
 
-// Note that "weaver generate" will always generate the error message below.
-// Everything is okay. The error message is only relevant if you see it when
-// you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][20]struct{}](`
+func (s remoteLoginService_client_stub) GetTotpStatus(ctx context.Context, a0 uint64) (r0 bool, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getTotpStatusMetrics.Begin()
+	defer func() { s.getTotpStatusMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.GetTotpStatus", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 9, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Bool()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) GetUsers(ctx context.Context, a0 []uint64) (r0 map[uint64]RawUser, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getUsersMetrics.Begin()
+	defer func() { s.getUsersMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.GetUsers", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + (len(a0) * 8))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	serviceweaver_enc_slice_uint64_489cb07a(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 10, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_map_uint64_RawUser_53d8d616(dec)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) ListLockouts(ctx context.Context) (r0 []Lockout, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.listLockoutsMetrics.Begin()
+	defer func() { s.listLockoutsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ListLockouts", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 11, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_Lockout_d0fa8d52(dec)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) ListUsers(ctx context.Context, a0 uint64, a1 uint64, a2 string) (r0 uint64, r1 []RawUser, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.listUsersMetrics.Begin()
+	defer func() { s.listUsersMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ListUsers", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += 8
+	size += (4 + len(a2))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.Uint64(a1)
+	enc.String(a2)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 12, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Uint64()
+	r1 = serviceweaver_dec_slice_RawUser_9050e128(dec)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) ListUsersPage(ctx context.Context, a0 string, a1 uint64, a2 string) (r0 []RawUser, r1 string, r2 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.listUsersPageMetrics.Begin()
+	defer func() { s.listUsersPageMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ListUsersPage", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += 8
+	size += (4 + len(a2))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.Uint64(a1)
+	enc.String(a2)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 13, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_RawUser_9050e128(dec)
+	r1 = dec.String()
+	r2 = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) Register(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.registerMetrics.Begin()
+	defer func() { s.registerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Register", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += (4 + len(a2))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	enc.String(a2)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 14, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Uint64()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) ResetTotp(ctx context.Context, a0 uint64) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.resetTotpMetrics.Begin()
+	defer func() { s.resetTotpMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ResetTotp", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 15, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) ResolveLogin(ctx context.Context, a0 string) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.resolveLoginMetrics.Begin()
+	defer func() { s.resolveLoginMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.ResolveLogin", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 16, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) SendEmailVerification(ctx context.Context, a0 uint64) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.sendEmailVerificationMetrics.Begin()
+	defer func() { s.sendEmailVerificationMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.SendEmailVerification", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 17, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) StartTotp(ctx context.Context, a0 uint64) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.startTotpMetrics.Begin()
+	defer func() { s.startTotpMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.StartTotp", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 18, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) Verify(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.verifyMetrics.Begin()
+	defer func() { s.verifyMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.Verify", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += (4 + len(a2))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	enc.String(a2)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 19, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Uint64()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) VerifyEmail(ctx context.Context, a0 string) (r0 uint64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.verifyEmailMetrics.Begin()
+	defer func() { s.verifyEmailMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.VerifyEmail", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 20, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Uint64()
+	err = dec.Error()
+	return
+}
+
+func (s remoteLoginService_client_stub) VerifyTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.verifyTotpMetrics.Begin()
+	defer func() { s.verifyTotpMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "loginimpl.RemoteLoginService.VerifyTotp", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += (4 + len(a1))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.String(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 21, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+// Note that "weaver generate" will always generate the error message below.
+// Everything is okay. The error message is only relevant if you see it when
+// you run "go build" or "go run".
+var _ codegen.LatestVersion = codegen.Version[[0][20]struct{}](`
+
+ERROR: You generated this file with 'weaver generate' v0.23.0 (codegen
+version v0.20.0). The generated code is incompatible with the version of the
+github.com/ServiceWeaver/weaver module that you're using. The weaver module
+version can be found in your go.mod file or by running the following command.
+
+    go list -m github.com/ServiceWeaver/weaver
+
+We recommend updating the weaver module and the 'weaver generate' command by
+running the following.
+
+    go get github.com/ServiceWeaver/weaver@latest
+    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+
+Then, re-run 'weaver generate' and re-build your code. If the problem persists,
+please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+
+`)
+
+// Server stub implementations.
+
+type remoteLoginService_server_stub struct {
+	impl    RemoteLoginService
+	addLoad func(key uint64, load float64)
+}
+
+// Check that remoteLoginService_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*remoteLoginService_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s remoteLoginService_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "ChangeEmail":
+		return s.changeEmail
+	case "ChangeLogin":
+		return s.changeLogin
+	case "ChangePassword":
+		return s.changePassword
+	case "ClearLockout":
+		return s.clearLockout
+	case "ConfirmTotp":
+		return s.confirmTotp
+	case "Delete":
+		return s.delete
+	case "DisableTotp":
+		return s.disableTotp
+	case "GetEmail":
+		return s.getEmail
+	case "GetHashParams":
+		return s.getHashParams
+	case "GetTotpStatus":
+		return s.getTotpStatus
+	case "GetUsers":
+		return s.getUsers
+	case "ListLockouts":
+		return s.listLockouts
+	case "ListUsers":
+		return s.listUsers
+	case "ListUsersPage":
+		return s.listUsersPage
+	case "Register":
+		return s.register
+	case "ResetTotp":
+		return s.resetTotp
+	case "ResolveLogin":
+		return s.resolveLogin
+	case "SendEmailVerification":
+		return s.sendEmailVerification
+	case "StartTotp":
+		return s.startTotp
+	case "Verify":
+		return s.verify
+	case "VerifyEmail":
+		return s.verifyEmail
+	case "VerifyTotp":
+		return s.verifyTotp
+	default:
+		return nil
+	}
+}
+
+func (s remoteLoginService_server_stub) changeEmail(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+	var a2 string
+	a2 = dec.String()
+	var a3 string
+	a3 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.ChangeEmail(ctx, a0, a1, a2, a3)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) changeLogin(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+	var a2 string
+	a2 = dec.String()
+	var a3 string
+	a3 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.ChangeLogin(ctx, a0, a1, a2, a3)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) changePassword(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+	var a2 string
+	a2 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.ChangePassword(ctx, a0, a1, a2)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) clearLockout(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.ClearLockout(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) confirmTotp(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.ConfirmTotp(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_string_4af10117(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) delete(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Delete(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) disableTotp(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 string
+	a1 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.DisableTotp(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) getEmail(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.GetEmail(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Bool(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) getHashParams(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+	var a2 string
+	a2 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.GetHashParams(ctx, a0, a1, a2)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) getTotpStatus(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.GetTotpStatus(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Bool(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) getUsers(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 []uint64
+	a0 = serviceweaver_dec_slice_uint64_489cb07a(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.GetUsers(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_map_uint64_RawUser_53d8d616(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) listLockouts(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-ERROR: You generated this file with 'weaver generate' v0.23.0 (codegen
-version v0.20.0). The generated code is incompatible with the version of the
-github.com/ServiceWeaver/weaver module that you're using. The weaver module
-version can be found in your go.mod file or by running the following command.
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.ListLockouts(ctx)
 
-    go list -m github.com/ServiceWeaver/weaver
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_Lockout_d0fa8d52(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
 
-We recommend updating the weaver module and the 'weaver generate' command by
-running the following.
+func (s remoteLoginService_server_stub) listUsers(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-    go get github.com/ServiceWeaver/weaver@latest
-    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 uint64
+	a1 = dec.Uint64()
+	var a2 string
+	a2 = dec.String()
 
-Then, re-run 'weaver generate' and re-build your code. If the problem persists,
-please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.ListUsers(ctx, a0, a1, a2)
 
-`)
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Uint64(r0)
+	serviceweaver_enc_slice_RawUser_9050e128(enc, r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
 
-// Server stub implementations.
+func (s remoteLoginService_server_stub) listUsersPage(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-type remoteLoginService_server_stub struct {
-	impl    RemoteLoginService
-	addLoad func(key uint64, load float64)
-}
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 uint64
+	a1 = dec.Uint64()
+	var a2 string
+	a2 = dec.String()
 
-// Check that remoteLoginService_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*remoteLoginService_server_stub)(nil)
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, r2, appErr := s.impl.ListUsersPage(ctx, a0, a1, a2)
 
-// GetStubFn implements the codegen.Server interface.
-func (s remoteLoginService_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
-	switch method {
-	case "ChangeLogin":
-		return s.changeLogin
-	case "ChangePassword":
-		return s.changePassword
-	case "Delete":
-		return s.delete
-	case "GetUsers":
-		return s.getUsers
-	case "ListUsers":
-		return s.listUsers
-	case "Register":
-		return s.register
-	case "Verify":
-		return s.verify
-	default:
-		return nil
-	}
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_RawUser_9050e128(enc, r0)
+	enc.String(r1)
+	enc.String(r2)
+	enc.Error(appErr)
+	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) changeLogin(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) register(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -687,27 +2274,26 @@ func (s remoteLoginService_server_stub) changeLogin(ctx context.Context, args []
 
 	// Decode arguments.
 	dec := codegen.NewDecoder(args)
-	var a0 uint64
-	a0 = dec.Uint64()
+	var a0 string
+	a0 = dec.String()
 	var a1 string
 	a1 = dec.String()
 	var a2 string
 	a2 = dec.String()
-	var a3 string
-	a3 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	appErr := s.impl.ChangeLogin(ctx, a0, a1, a2, a3)
+	r0, appErr := s.impl.Register(ctx, a0, a1, a2)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
+	enc.Uint64(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) changePassword(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) resetTotp(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -719,15 +2305,11 @@ func (s remoteLoginService_server_stub) changePassword(ctx context.Context, args
 	dec := codegen.NewDecoder(args)
 	var a0 uint64
 	a0 = dec.Uint64()
-	var a1 string
-	a1 = dec.String()
-	var a2 string
-	a2 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	appErr := s.impl.ChangePassword(ctx, a0, a1, a2)
+	appErr := s.impl.ResetTotp(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
@@ -735,7 +2317,7 @@ func (s remoteLoginService_server_stub) changePassword(ctx context.Context, args
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) delete(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) resolveLogin(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -745,21 +2327,22 @@ func (s remoteLoginService_server_stub) delete(ctx context.Context, args []byte)
 
 	// Decode arguments.
 	dec := codegen.NewDecoder(args)
-	var a0 uint64
-	a0 = dec.Uint64()
+	var a0 string
+	a0 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	appErr := s.impl.Delete(ctx, a0)
+	r0, appErr := s.impl.ResolveLogin(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
+	enc.String(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) getUsers(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) sendEmailVerification(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -769,22 +2352,21 @@ func (s remoteLoginService_server_stub) getUsers(ctx context.Context, args []byt
 
 	// Decode arguments.
 	dec := codegen.NewDecoder(args)
-	var a0 []uint64
-	a0 = serviceweaver_dec_slice_uint64_489cb07a(dec)
+	var a0 uint64
+	a0 = dec.Uint64()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.GetUsers(ctx, a0)
+	appErr := s.impl.SendEmailVerification(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	serviceweaver_enc_map_uint64_RawUser_53d8d616(enc, r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) listUsers(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) startTotp(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -796,25 +2378,20 @@ func (s remoteLoginService_server_stub) listUsers(ctx context.Context, args []by
 	dec := codegen.NewDecoder(args)
 	var a0 uint64
 	a0 = dec.Uint64()
-	var a1 uint64
-	a1 = dec.Uint64()
-	var a2 string
-	a2 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, r1, appErr := s.impl.ListUsers(ctx, a0, a1, a2)
+	r0, appErr := s.impl.StartTotp(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Uint64(r0)
-	serviceweaver_enc_slice_RawUser_9050e128(enc, r1)
+	enc.String(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) register(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) verify(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -828,11 +2405,13 @@ func (s remoteLoginService_server_stub) register(ctx context.Context, args []byt
 	a0 = dec.String()
 	var a1 string
 	a1 = dec.String()
+	var a2 string
+	a2 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.Register(ctx, a0, a1)
+	r0, appErr := s.impl.Verify(ctx, a0, a1, a2)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
@@ -841,7 +2420,7 @@ func (s remoteLoginService_server_stub) register(ctx context.Context, args []byt
 	return enc.Data(), nil
 }
 
-func (s remoteLoginService_server_stub) verify(ctx context.Context, args []byte) (res []byte, err error) {
+func (s remoteLoginService_server_stub) verifyEmail(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -853,17 +2432,41 @@ func (s remoteLoginService_server_stub) verify(ctx context.Context, args []byte)
 	dec := codegen.NewDecoder(args)
 	var a0 string
 	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.VerifyEmail(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Uint64(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s remoteLoginService_server_stub) verifyTotp(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
 	var a1 string
 	a1 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.Verify(ctx, a0, a1)
+	appErr := s.impl.VerifyTotp(ctx, a0, a1)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Uint64(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
@@ -877,6 +2480,11 @@ type remoteLoginService_reflect_stub struct {
 // Check that remoteLoginService_reflect_stub implements the RemoteLoginService interface.
 var _ RemoteLoginService = (*remoteLoginService_reflect_stub)(nil)
 
+func (s remoteLoginService_reflect_stub) ChangeEmail(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
+	err = s.caller("ChangeEmail", ctx, []any{a0, a1, a2, a3}, []any{})
+	return
+}
+
 func (s remoteLoginService_reflect_stub) ChangeLogin(ctx context.Context, a0 uint64, a1 string, a2 string, a3 string) (err error) {
 	err = s.caller("ChangeLogin", ctx, []any{a0, a1, a2, a3}, []any{})
 	return
@@ -887,33 +2495,135 @@ func (s remoteLoginService_reflect_stub) ChangePassword(ctx context.Context, a0
 	return
 }
 
+func (s remoteLoginService_reflect_stub) ClearLockout(ctx context.Context, a0 string) (err error) {
+	err = s.caller("ClearLockout", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) ConfirmTotp(ctx context.Context, a0 uint64, a1 string) (r0 []string, err error) {
+	err = s.caller("ConfirmTotp", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
 func (s remoteLoginService_reflect_stub) Delete(ctx context.Context, a0 uint64) (err error) {
 	err = s.caller("Delete", ctx, []any{a0}, []any{})
 	return
 }
 
+func (s remoteLoginService_reflect_stub) DisableTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	err = s.caller("DisableTotp", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) GetEmail(ctx context.Context, a0 uint64) (r0 string, r1 bool, err error) {
+	err = s.caller("GetEmail", ctx, []any{a0}, []any{&r0, &r1})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) GetHashParams(ctx context.Context, a0 string, a1 string, a2 string) (r0 string, err error) {
+	err = s.caller("GetHashParams", ctx, []any{a0, a1, a2}, []any{&r0})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) GetTotpStatus(ctx context.Context, a0 uint64) (r0 bool, err error) {
+	err = s.caller("GetTotpStatus", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s remoteLoginService_reflect_stub) GetUsers(ctx context.Context, a0 []uint64) (r0 map[uint64]RawUser, err error) {
 	err = s.caller("GetUsers", ctx, []any{a0}, []any{&r0})
 	return
 }
 
+func (s remoteLoginService_reflect_stub) ListLockouts(ctx context.Context) (r0 []Lockout, err error) {
+	err = s.caller("ListLockouts", ctx, []any{}, []any{&r0})
+	return
+}
+
 func (s remoteLoginService_reflect_stub) ListUsers(ctx context.Context, a0 uint64, a1 uint64, a2 string) (r0 uint64, r1 []RawUser, err error) {
 	err = s.caller("ListUsers", ctx, []any{a0, a1, a2}, []any{&r0, &r1})
 	return
 }
 
-func (s remoteLoginService_reflect_stub) Register(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
-	err = s.caller("Register", ctx, []any{a0, a1}, []any{&r0})
+func (s remoteLoginService_reflect_stub) ListUsersPage(ctx context.Context, a0 string, a1 uint64, a2 string) (r0 []RawUser, r1 string, r2 string, err error) {
+	err = s.caller("ListUsersPage", ctx, []any{a0, a1, a2}, []any{&r0, &r1, &r2})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) Register(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
+	err = s.caller("Register", ctx, []any{a0, a1, a2}, []any{&r0})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) ResetTotp(ctx context.Context, a0 uint64) (err error) {
+	err = s.caller("ResetTotp", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) ResolveLogin(ctx context.Context, a0 string) (r0 string, err error) {
+	err = s.caller("ResolveLogin", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) SendEmailVerification(ctx context.Context, a0 uint64) (err error) {
+	err = s.caller("SendEmailVerification", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) StartTotp(ctx context.Context, a0 uint64) (r0 string, err error) {
+	err = s.caller("StartTotp", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) Verify(ctx context.Context, a0 string, a1 string, a2 string) (r0 uint64, err error) {
+	err = s.caller("Verify", ctx, []any{a0, a1, a2}, []any{&r0})
+	return
+}
+
+func (s remoteLoginService_reflect_stub) VerifyEmail(ctx context.Context, a0 string) (r0 uint64, err error) {
+	err = s.caller("VerifyEmail", ctx, []any{a0}, []any{&r0})
 	return
 }
 
-func (s remoteLoginService_reflect_stub) Verify(ctx context.Context, a0 string, a1 string) (r0 uint64, err error) {
-	err = s.caller("Verify", ctx, []any{a0, a1}, []any{&r0})
+func (s remoteLoginService_reflect_stub) VerifyTotp(ctx context.Context, a0 uint64, a1 string) (err error) {
+	err = s.caller("VerifyTotp", ctx, []any{a0, a1}, []any{})
 	return
 }
 
 // AutoMarshal implementations.
 
+var _ codegen.AutoMarshal = (*Lockout)(nil)
+
+type __is_Lockout[T ~struct {
+	weaver.AutoMarshal
+	Target      string
+	Failures    uint64
+	LastFailure int64
+	LockedUntil int64
+}] struct{}
+
+var _ __is_Lockout[Lockout]
+
+func (x *Lockout) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("Lockout.WeaverMarshal: nil receiver"))
+	}
+	enc.String(x.Target)
+	enc.Uint64(x.Failures)
+	enc.Int64(x.LastFailure)
+	enc.Int64(x.LockedUntil)
+}
+
+func (x *Lockout) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("Lockout.WeaverUnmarshal: nil receiver"))
+	}
+	x.Target = dec.String()
+	x.Failures = dec.Uint64()
+	x.LastFailure = dec.Int64()
+	x.LockedUntil = dec.Int64()
+}
+
 var _ codegen.AutoMarshal = (*RawUser)(nil)
 
 type __is_RawUser[T ~struct {
@@ -921,6 +2631,7 @@ type __is_RawUser[T ~struct {
 	Id          uint64
 	Login       string
 	RegistredAt int64
+	Snippet     string
 }] struct{}
 
 var _ __is_RawUser[RawUser]
@@ -932,6 +2643,7 @@ func (x *RawUser) WeaverMarshal(enc *codegen.Encoder) {
 	enc.Uint64(x.Id)
 	enc.String(x.Login)
 	enc.Int64(x.RegistredAt)
+	enc.String(x.Snippet)
 }
 
 func (x *RawUser) WeaverUnmarshal(dec *codegen.Decoder) {
@@ -941,10 +2653,34 @@ func (x *RawUser) WeaverUnmarshal(dec *codegen.Decoder) {
 	x.Id = dec.Uint64()
 	x.Login = dec.String()
 	x.RegistredAt = dec.Int64()
+	x.Snippet = dec.String()
 }
 
 // Encoding/decoding implementations.
 
+func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	for i := 0; i < len(arg); i++ {
+		enc.String(arg[i])
+	}
+}
+
+func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	res := make([]string, n)
+	for i := 0; i < n; i++ {
+		res[i] = dec.String()
+	}
+	return res
+}
+
 func serviceweaver_enc_slice_uint64_489cb07a(enc *codegen.Encoder, arg []uint64) {
 	if arg == nil {
 		enc.Len(-1)
@@ -996,6 +2732,29 @@ func serviceweaver_dec_map_uint64_RawUser_53d8d616(dec *codegen.Decoder) map[uin
 	return res
 }
 
+func serviceweaver_enc_slice_Lockout_d0fa8d52(enc *codegen.Encoder, arg []Lockout) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	for i := 0; i < len(arg); i++ {
+		(arg[i]).WeaverMarshal(enc)
+	}
+}
+
+func serviceweaver_dec_slice_Lockout_d0fa8d52(dec *codegen.Decoder) []Lockout {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	res := make([]Lockout, n)
+	for i := 0; i < n; i++ {
+		(&res[i]).WeaverUnmarshal(dec)
+	}
+	return res
+}
+
 func serviceweaver_enc_slice_RawUser_9050e128(enc *codegen.Encoder, arg []RawUser) {
 	if arg == nil {
 		enc.Len(-1)
@@ -19,8 +19,12 @@
 package passwordstrengthimpl
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	fsclient "github.com/dvaumoron/puzzleweaver/client/fs"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
@@ -33,26 +37,49 @@ type strengthConf struct {
 	AllLang         []string
 	FsConf          fsclient.FsConf
 	RuleFilePath    string
+	// polling of rule files, disabled when zero
+	ReloadInterval time.Duration
 }
 
 type initializedStrengthConf struct {
 	minEntropy     float64
-	localizedRules map[string]string
+	localizedRules *atomic.Pointer[map[string]string]
+	stopWatch      context.CancelFunc
 }
 
+var errEmptyRule = errors.New("empty rule file")
+
 func initStrengthConf(logger *slog.Logger, conf *strengthConf) (initializedStrengthConf, error) {
 	fileSystem, err := fsclient.New(conf.FsConf)
 	if err != nil {
 		return initializedStrengthConf{}, err
 	}
 
-	localizedRules, err := readRulesConfig(logger, fileSystem, conf)
+	loadedRules, err := readRulesConfig(logger, fileSystem, conf)
 	if err != nil {
 		return initializedStrengthConf{}, err
 	}
 
+	localizedRules := new(atomic.Pointer[map[string]string])
+	localizedRules.Store(&loadedRules)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	fsclient.Watch(watchCtx, logger, fileSystem, conf.ReloadInterval, rulePaths(conf), func() error {
+		newRules, err := readRulesConfig(logger, fileSystem, conf)
+		if err != nil {
+			return err
+		}
+		for _, rule := range newRules {
+			if rule == "" {
+				return errEmptyRule
+			}
+		}
+		localizedRules.Store(&newRules)
+		return nil
+	})
+
 	return initializedStrengthConf{
 		minEntropy: passwordvalidator.GetEntropy(conf.DefaultPassword), localizedRules: localizedRules,
+		stopWatch: stopWatch,
 	}, nil
 }
 
@@ -73,3 +100,11 @@ func readRulesConfig(logger *slog.Logger, fileSystem afero.Fs, conf *strengthCon
 	}
 	return localizedRules, nil
 }
+
+func rulePaths(conf *strengthConf) []string {
+	paths := make([]string, 0, len(conf.AllLang))
+	for _, lang := range conf.AllLang {
+		paths = append(paths, strings.ReplaceAll(conf.RuleFilePath, servicecommon.LangPlaceHolder, lang))
+	}
+	return paths
+}
@@ -35,9 +35,20 @@ type strengthImpl struct {
 
 func (impl *strengthImpl) Init(ctx context.Context) (err error) {
 	impl.initializedConf, err = initStrengthConf(impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// stops the polling of the watched files
+func (impl *strengthImpl) Shutdown(ctx context.Context) error {
+	if stopWatch := impl.initializedConf.stopWatch; stopWatch != nil {
+		stopWatch()
+	}
+	return nil
+}
+
 func (impl *strengthImpl) Validate(ctx context.Context, password string) error {
 	logger := impl.Logger(ctx)
 	err := passwordvalidator.Validate(password, impl.initializedConf.minEntropy)
@@ -50,7 +61,7 @@ func (impl *strengthImpl) Validate(ctx context.Context, password string) error {
 
 func (impl *strengthImpl) GetRules(ctx context.Context, lang string) (string, error) {
 	logger := impl.Logger(ctx)
-	description, ok := impl.initializedConf.localizedRules[lang]
+	description, ok := (*impl.initializedConf.localizedRules.Load())[lang]
 	if !ok {
 		logger.Error("Locale not found")
 		return "", servicecommon.ErrInternal
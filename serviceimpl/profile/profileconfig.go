@@ -19,19 +19,53 @@
 package profileimpl
 
 import (
+	"context"
+	"log/slog"
+
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const profilesUniqueIndex = "profiles_user"
+
+// ensured (or checked) at component start and by the indexes mode of the binary
+var Indexes = mongoclient.Indexes{Component: "profile", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(profilesUniqueIndex, userIdKey),
+}}
+
 type profileConf struct {
 	MongoAddress      string
 	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	// "ensure" (default) or "check"
+	IndexMode string
 }
 
 type initializedProfileConf struct {
-	clientOptions *options.ClientOptions
+	client       *mongo.Client
+	certificates *tlsclient.Certificates
+	collection   *mongo.Collection
 }
 
-func initProfileConf(conf *profileConf) initializedProfileConf {
-	return initializedProfileConf{clientOptions: mongoclient.New(conf.MongoAddress)}
+func initProfileConf(ctx context.Context, logger *slog.Logger, conf *profileConf) (initializedProfileConf, error) {
+	certificates, err := tlsclient.Load(logger, conf.MongoTLS)
+	if err != nil {
+		return initializedProfileConf{}, err
+	}
+
+	client, err := mongoclient.Connect(ctx, logger, conf.MongoAddress, conf.MongoOptions, certificates)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedProfileConf{}, err
+	}
+
+	database := client.Database(conf.MongoDatabaseName)
+	if err = Indexes.Apply(ctx, database, conf.IndexMode); err != nil {
+		mongoclient.Release(ctx, client)
+		tlsclient.Release(certificates)
+		return initializedProfileConf{}, err
+	}
+	return initializedProfileConf{client: client, certificates: certificates, collection: database.Collection(collectionName)}, nil
 }
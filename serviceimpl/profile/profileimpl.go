@@ -23,6 +23,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"go.mongodb.org/mongo-driver/bson"
@@ -49,27 +50,30 @@ type remoteProfileImpl struct {
 	initializedConf initializedProfileConf
 }
 
-func (impl *remoteProfileImpl) Init(ctx context.Context) error {
-	impl.initializedConf = initProfileConf(impl.Config())
-	return nil
+func (impl *remoteProfileImpl) Init(ctx context.Context) (err error) {
+	impl.initializedConf, err = initProfileConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
+	return
+}
+
+// releases the shared MongoDB client and its TLS certificates
+func (impl *remoteProfileImpl) Shutdown(ctx context.Context) error {
+	err := mongoclient.Release(ctx, impl.initializedConf.client)
+	tlsclient.Release(impl.initializedConf.certificates)
+	return err
 }
 
 func (impl *remoteProfileImpl) UpdateProfile(ctx context.Context, userId uint64, desc string, info map[string]string) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
 	infoB := bson.M{}
 	for k, v := range info {
 		infoB[k] = v
 	}
 	profile := bson.D{{Key: setOperator, Value: bson.M{userIdKey: userId, descKey: desc, infoKey: infoB}}}
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
-	_, err = collection.UpdateOne(
+	collection := impl.initializedConf.collection
+	_, err := collection.UpdateOne(
 		ctx, bson.D{{Key: userIdKey, Value: userId}}, profile, optsCreateUnexisting,
 	)
 	if err != nil {
@@ -81,16 +85,9 @@ func (impl *remoteProfileImpl) UpdateProfile(ctx context.Context, userId uint64,
 
 func (impl *remoteProfileImpl) UpdatePicture(ctx context.Context, userId uint64, data []byte) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
 	profile := bson.D{{Key: setOperator, Value: bson.M{userIdKey: userId, pictureKey: data}}}
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
-	_, err = collection.UpdateOne(
+	collection := impl.initializedConf.collection
+	_, err := collection.UpdateOne(
 		ctx, bson.D{{Key: userIdKey, Value: userId}}, profile, optsCreateUnexisting,
 	)
 	if err != nil {
@@ -102,16 +99,9 @@ func (impl *remoteProfileImpl) UpdatePicture(ctx context.Context, userId uint64,
 
 func (impl *remoteProfileImpl) GetPicture(ctx context.Context, userId uint64) ([]byte, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 	var result bson.D
-	err = collection.FindOne(
+	err := collection.FindOne(
 		ctx, bson.D{{Key: userIdKey, Value: userId}}, optsOnlyPictureField,
 	).Decode(&result)
 	if err != nil {
@@ -133,14 +123,7 @@ func (impl *remoteProfileImpl) GetPicture(ctx context.Context, userId uint64) ([
 
 func (impl *remoteProfileImpl) GetProfiles(ctx context.Context, userIds []uint64) (map[uint64]RawUserProfile, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 	filter := bson.D{{Key: userIdKey, Value: bson.D{{Key: "$in", Value: userIds}}}}
 	cursor, err := collection.Find(ctx, filter, optsExcludePictureField)
 	if err != nil {
@@ -165,15 +148,8 @@ func (impl *remoteProfileImpl) GetProfiles(ctx context.Context, userIds []uint64
 
 func (impl *remoteProfileImpl) Delete(ctx context.Context, userId uint64) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
-	_, err = collection.DeleteMany(ctx, bson.D{{Key: userIdKey, Value: userId}})
+	collection := impl.initializedConf.collection
+	_, err := collection.DeleteMany(ctx, bson.D{{Key: userIdKey, Value: userId}})
 	if err != nil {
 		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
 		return common.ErrUpdate
@@ -0,0 +1,41 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package saltimpl
+
+import (
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+)
+
+// applied with the migrate mode of the binary, checked at component start with the sql store,
+// the steps use copies of the models at their version, so they do not follow the later changes
+var Migrations = dbclient.Migrations{Component: "salt", Steps: []dbclient.Migration{{
+	Version: 1, Name: "create salts table",
+	Up: dbclient.CreateTables(&saltsV1{}), Down: dbclient.DropTables(&saltsV1{}),
+}}}
+
+type saltsV1 struct {
+	Login     string `gorm:"primaryKey;size:255"`
+	Salt      []byte
+	CreatedAt time.Time
+}
+
+func (saltsV1) TableName() string {
+	return "salts"
+}
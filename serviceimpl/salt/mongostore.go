@@ -0,0 +1,158 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package saltimpl
+
+import (
+	"context"
+	"time"
+
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName = "salts"
+
+	loginKey     = "login"
+	saltKey      = "salt"
+	createdAtKey = "createdAt"
+
+	saltsUniqueIndex = "salts_login"
+)
+
+// ensured (or checked) at component start with the mongo store and by the indexes mode of the binary
+var Indexes = mongoclient.Indexes{Component: "salt", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(saltsUniqueIndex, loginKey),
+}}
+
+var (
+	optsCreateOnlyNew = options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	optsUpsert        = options.Update().SetUpsert(true)
+	optsScan          = options.Find().SetBatchSize(scanBatchSize)
+)
+
+type saltDocument struct {
+	Login     string    `bson:"login"`
+	Salt      []byte    `bson:"salt"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type mongoStore struct {
+	collection   *mongo.Collection
+	certificates *tlsclient.Certificates
+}
+
+func (s mongoStore) Load(ctx context.Context, logins []string) ([][]byte, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := s.collection.Find(ctx, bson.D{{Key: loginKey, Value: bson.D{{Key: "$in", Value: logins}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []saltDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	byLogin := make(map[string][]byte, len(docs))
+	for _, doc := range docs {
+		byLogin[doc.Login] = doc.Salt
+	}
+
+	salts := make([][]byte, 0, len(logins))
+	for _, login := range logins {
+		salts = append(salts, byLogin[login])
+	}
+	return salts, nil
+}
+
+func (s mongoStore) Create(ctx context.Context, login string, salt []byte) ([]byte, error) {
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: saltKey, Value: salt}, {Key: createdAtKey, Value: time.Now()},
+	}}}
+
+	var doc saltDocument
+	err := s.collection.FindOneAndUpdate(ctx, bson.D{{Key: loginKey, Value: login}}, update, optsCreateOnlyNew).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// concurrent upsert, the other one has won
+		err = s.collection.FindOne(ctx, bson.D{{Key: loginKey, Value: login}}).Decode(&doc)
+	}
+	return doc.Salt, err
+}
+
+func (s mongoStore) Set(ctx context.Context, login string, salt []byte) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: saltKey, Value: salt}}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: createdAtKey, Value: time.Now()}}},
+	}
+	_, err := s.collection.UpdateOne(ctx, bson.D{{Key: loginKey, Value: login}}, update, optsUpsert)
+	return err
+}
+
+func (s mongoStore) Delete(ctx context.Context, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+	_, err := s.collection.DeleteMany(ctx, bson.D{{Key: loginKey, Value: bson.D{{Key: "$in", Value: logins}}}})
+	return err
+}
+
+// the client is shared, it is disconnected with its last user
+func (s mongoStore) Close(ctx context.Context) error {
+	tlsclient.Release(s.certificates)
+	return mongoclient.Release(ctx, s.collection.Database().Client())
+}
+
+func (s mongoStore) Scan(ctx context.Context, fn func([]Entry) error) error {
+	cursor, err := s.collection.Find(ctx, bson.D{}, optsScan)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	entries := make([]Entry, 0, scanBatchSize)
+	for cursor.Next(ctx) {
+		var doc saltDocument
+		if err = cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		entries = append(entries, Entry{Login: doc.Login, Salt: doc.Salt, Age: now.Sub(doc.CreatedAt)})
+		if len(entries) == scanBatchSize {
+			if err = fn(entries); err != nil {
+				return err
+			}
+			entries = make([]Entry, 0, scanBatchSize)
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return fn(entries)
+}
@@ -19,30 +19,121 @@
 package saltimpl
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"strings"
 
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
 	redisclient "github.com/dvaumoron/puzzleweaver/client/redis"
-	"github.com/redis/go-redis/v9"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 )
 
-type saltConf struct {
+const (
+	redisStoreKind = "redis"
+	sqlStoreKind   = "sql"
+	mongoStoreKind = "mongo"
+)
+
+var errUnknownStore = errors.New("unknown salt store")
+
+// StoreConf selects the backend of the salt component, it is also read by the salts mode of the binary.
+type StoreConf struct {
+	// "redis" (default), "sql" or "mongo"
+	Store         string
 	RedisAddress  string
 	RedisUser     string
 	RedisPassword string
 	RedisDBNum    int
-	SaltLen       int
+	RedisOptions  redisclient.Options
+	RedisTLS      tlsclient.Conf
+	// prepended to the logins in the Redis keys (like "salt:"), empty for the keys of the older versions
+	RedisKeyPrefix    string
+	DatabaseKind      string
+	DatabaseAddress   string
+	DatabaseTLS       tlsclient.Conf
+	MongoAddress      string
+	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	// "ensure" (default) or "check"
+	IndexMode string
+}
+
+// SharedScan reports whether a Scan of the store reads all the string keys of its Redis database
+// (without RedisKeyPrefix), so the database must be dedicated to the salts.
+func (c StoreConf) SharedScan() bool {
+	switch strings.ToLower(c.Store) {
+	case "", redisStoreKind:
+		return c.RedisKeyPrefix == ""
+	}
+	return false
+}
+
+type saltConf struct {
+	StoreConf
+	SaltLen int
 }
 
 type initializedSaltConf struct {
-	rdb *redis.Client
+	store Store
+}
+
+func initSaltConf(ctx context.Context, logger *slog.Logger, conf *saltConf) (initializedSaltConf, error) {
+	store, err := OpenStore(ctx, logger, conf.StoreConf)
+	return initializedSaltConf{store: store}, err
 }
 
-func initSaltConf(logger *slog.Logger, conf *saltConf) (initializedSaltConf, error) {
-	rdb, err := redisclient.New(logger, &redis.Options{
-		Addr:     conf.RedisAddress,
-		Username: conf.RedisUser,
-		Password: conf.RedisPassword,
-		DB:       conf.RedisDBNum,
-	})
-	return initializedSaltConf{rdb: rdb}, err
+// OpenStore checks the schema of the sql store and applies the indexes of the mongo store.
+func OpenStore(ctx context.Context, logger *slog.Logger, conf StoreConf) (Store, error) {
+	switch strings.ToLower(conf.Store) {
+	case "", redisStoreKind:
+		certificates, err := tlsclient.Load(logger, conf.RedisTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		rdb, err := redisclient.New(logger, conf.RedisAddress, conf.RedisUser, conf.RedisPassword, conf.RedisDBNum, conf.RedisOptions, certificates)
+		if err != nil {
+			tlsclient.Release(certificates)
+			return nil, err
+		}
+		return redisStore{rdb: rdb, keyPrefix: conf.RedisKeyPrefix, certificates: certificates}, nil
+	case sqlStoreKind:
+		certificates, err := tlsclient.Load(logger, conf.DatabaseTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress, certificates)
+		if err == nil {
+			err = Migrations.Check(db)
+		}
+		if err != nil {
+			tlsclient.Release(certificates)
+			return nil, err
+		}
+		return sqlStore{db: db, certificates: certificates}, nil
+	case mongoStoreKind:
+		certificates, err := tlsclient.Load(logger, conf.MongoTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := mongoclient.Connect(ctx, logger, conf.MongoAddress, conf.MongoOptions, certificates)
+		if err != nil {
+			tlsclient.Release(certificates)
+			return nil, err
+		}
+
+		database := client.Database(conf.MongoDatabaseName)
+		if err = Indexes.Apply(ctx, database, conf.IndexMode); err != nil {
+			mongoclient.Release(ctx, client)
+			tlsclient.Release(certificates)
+			return nil, err
+		}
+		return mongoStore{collection: database.Collection(collectionName), certificates: certificates}, nil
+	}
+	return nil, errUnknownStore
 }
@@ -21,15 +21,13 @@ package saltimpl
 import (
 	"context"
 	"crypto/rand"
-	"log/slog"
 
 	"github.com/ServiceWeaver/weaver"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
-	"github.com/redis/go-redis/v9"
 )
 
-const redisCallMsg = "Failed during Redis call"
+const storeCallMsg = "Failed during salt store call"
 const generateMsg = "Failed to generate"
 
 type saltImpl struct {
@@ -39,44 +37,80 @@ type saltImpl struct {
 }
 
 func (impl *saltImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initSaltConf(impl.Logger(ctx), impl.Config())
+	impl.initializedConf, err = initSaltConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// closes the store client
+func (impl *saltImpl) Shutdown(ctx context.Context) error {
+	return impl.initializedConf.store.Close(ctx)
+}
+
 func (impl *saltImpl) LoadOrGenerate(ctx context.Context, logins ...string) ([][]byte, error) {
 	logger := impl.Logger(ctx)
-	salts := make([][]byte, 0, len(logins))
-	for _, login := range logins {
-		salt, err := impl.innerLoadOrGenerate(ctx, logger, login)
-		if err != nil {
-			return nil, err
+
+	store := impl.initializedConf.store
+	salts, err := store.Load(ctx, logins)
+	if err != nil {
+		logger.Error(storeCallMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+
+	for index, salt := range salts {
+		if len(salt) != 0 {
+			continue
+		}
+
+		saltBuffer := make([]byte, impl.Config().SaltLen)
+		if _, err = rand.Read(saltBuffer); err != nil {
+			logger.Error(generateMsg, common.ErrorKey, err)
+			return nil, servicecommon.ErrInternal
+		}
+		// with a concurrent generation, the first saved salt is used by both
+		if salts[index], err = store.Create(ctx, logins[index], saltBuffer); err != nil {
+			logger.Error(storeCallMsg, common.ErrorKey, err)
+			return nil, servicecommon.ErrInternal
 		}
-		salts = append(salts, salt)
 	}
 	return salts, nil
 }
 
-func (impl *saltImpl) innerLoadOrGenerate(ctx context.Context, logger *slog.Logger, login string) ([]byte, error) {
-	rdb := impl.initializedConf.rdb
-	salt, err := rdb.Get(ctx, login).Result()
-	if err == nil {
-		return []byte(salt), nil
-	}
-	if err != redis.Nil {
-		logger.Error(redisCallMsg, common.ErrorKey, err)
+func (impl *saltImpl) Load(ctx context.Context, logins ...string) ([][]byte, error) {
+	salts, err := impl.initializedConf.store.Load(ctx, logins)
+	if err != nil {
+		impl.Logger(ctx).Error(storeCallMsg, common.ErrorKey, err)
 		return nil, servicecommon.ErrInternal
 	}
+	return salts, nil
+}
 
-	saltBuffer := make([]byte, impl.Config().SaltLen)
-	_, err = rand.Read(saltBuffer)
-	if err != nil {
-		logger.Error(generateMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
+func (impl *saltImpl) Delete(ctx context.Context, logins ...string) error {
+	if err := impl.initializedConf.store.Delete(ctx, logins); err != nil {
+		impl.Logger(ctx).Error(storeCallMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
 	}
-	salt = string(saltBuffer)
-	if err = rdb.Set(ctx, login, salt, 0).Err(); err != nil {
-		logger.Error(redisCallMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
+	return nil
+}
+
+// not atomic, the salt is written before the removal so it is never lost
+func (impl *saltImpl) Rename(ctx context.Context, oldLogin string, newLogin string) error {
+	if oldLogin == newLogin {
+		return nil
+	}
+
+	store := impl.initializedConf.store
+	salts, err := store.Load(ctx, []string{oldLogin})
+	if err == nil && len(salts) != 0 && len(salts[0]) != 0 {
+		if err = store.Set(ctx, newLogin, salts[0]); err == nil {
+			err = store.Delete(ctx, []string{oldLogin})
+		}
+	}
+	if err != nil {
+		impl.Logger(ctx).Error(storeCallMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
 	}
-	return saltBuffer, nil
+	return nil
 }
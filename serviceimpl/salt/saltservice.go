@@ -22,4 +22,9 @@ import "context"
 
 type SaltService interface {
 	LoadOrGenerate(ctx context.Context, logins ...string) ([][]byte, error)
+	// like LoadOrGenerate without generation, the salt of an unknown login is empty
+	Load(ctx context.Context, logins ...string) ([][]byte, error)
+	Delete(ctx context.Context, logins ...string) error
+	// move the salt of oldLogin to newLogin (replacing its salt), no effect when oldLogin has no salt
+	Rename(ctx context.Context, oldLogin string, newLogin string) error
 }
@@ -0,0 +1,201 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package saltimpl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"github.com/redis/go-redis/v9"
+)
+
+const scanBatchSize = 500
+
+// the special characters of a glob-style pattern of SCAN
+var redisPatternEscaper = strings.NewReplacer("\\", "\\\\", "*", "\\*", "?", "\\?", "[", "\\[", "]", "\\]")
+
+// Entry is a salt read by Store.Scan.
+type Entry struct {
+	Login string
+	Salt  []byte
+	// time since the last use with Redis, since the creation with the other stores
+	Age time.Duration
+	// Redis does not track the idle time with an LFU maxmemory-policy (Age is zero)
+	AgeUnknown bool
+}
+
+// Store is the backend of the salt component, also used by the salts mode of the binary.
+type Store interface {
+	// Load returns an empty salt for an unknown login
+	Load(ctx context.Context, logins []string) ([][]byte, error)
+	// Create saves salt unless the login already has one, it returns the stored salt
+	Create(ctx context.Context, login string, salt []byte) ([]byte, error)
+	// Set saves salt, replacing any previous one
+	Set(ctx context.Context, login string, salt []byte) error
+	Delete(ctx context.Context, logins []string) error
+	// Scan calls fn with batches of the stored salts
+	Scan(ctx context.Context, fn func([]Entry) error) error
+	Close(ctx context.Context) error
+}
+
+type redisStore struct {
+	rdb          redis.UniversalClient
+	keyPrefix    string
+	certificates *tlsclient.Certificates
+}
+
+func (s redisStore) key(login string) string {
+	return s.keyPrefix + login
+}
+
+func (s redisStore) Load(ctx context.Context, logins []string) ([][]byte, error) {
+	// a pipeline is split by slot with a Redis cluster (unlike MGET)
+	cmds := make([]*redis.StringCmd, 0, len(logins))
+	// the error of the pipeline is the first one, each command is checked to ignore the missing keys
+	s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, login := range logins {
+			cmds = append(cmds, pipe.Get(ctx, s.key(login)))
+		}
+		return nil
+	})
+
+	salts := make([][]byte, 0, len(cmds))
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		// empty for a missing key
+		salts = append(salts, []byte(cmd.Val()))
+	}
+	return salts, nil
+}
+
+func (s redisStore) Create(ctx context.Context, login string, salt []byte) ([]byte, error) {
+	key := s.key(login)
+	created, err := s.rdb.SetNX(ctx, key, salt, 0).Result()
+	if err != nil || created {
+		return salt, err
+	}
+	stored, err := s.rdb.Get(ctx, key).Bytes()
+	return stored, err
+}
+
+func (s redisStore) Set(ctx context.Context, login string, salt []byte) error {
+	return s.rdb.Set(ctx, s.key(login), salt, 0).Err()
+}
+
+func (s redisStore) Delete(ctx context.Context, logins []string) error {
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, login := range logins {
+			pipe.Del(ctx, s.key(login))
+		}
+		return nil
+	})
+	return err
+}
+
+func (s redisStore) Close(context.Context) error {
+	tlsclient.Release(s.certificates)
+	return s.rdb.Close()
+}
+
+// only the keys with the prefix are scanned, all the string keys of the database without it
+// (the hashes and sets of a session store sharing the database are ignored, but not the strings of other uses),
+// the keys of a cluster are scanned on each master (concurrently)
+func (s redisStore) Scan(ctx context.Context, fn func([]Entry) error) error {
+	switch typed := s.rdb.(type) {
+	case *redis.ClusterClient:
+		var mutex sync.Mutex
+		return typed.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanRedisNode(ctx, client, s.keyPrefix, func(entries []Entry) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return fn(entries)
+			})
+		})
+	case *redis.Client:
+		return scanRedisNode(ctx, typed, s.keyPrefix, fn)
+	}
+	return errors.ErrUnsupported
+}
+
+func scanRedisNode(ctx context.Context, client *redis.Client, keyPrefix string, fn func([]Entry) error) error {
+	pattern := redisPatternEscaper.Replace(keyPrefix) + "*"
+	var cursor uint64
+	for {
+		keys, nextCursor, err := client.ScanType(ctx, cursor, pattern, scanBatchSize, "string").Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) != 0 {
+			entries, err := readRedisEntries(ctx, client, keyPrefix, keys)
+			if err != nil {
+				return err
+			}
+			if err = fn(entries); err != nil {
+				return err
+			}
+		}
+
+		if cursor = nextCursor; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func readRedisEntries(ctx context.Context, client *redis.Client, keyPrefix string, keys []string) ([]Entry, error) {
+	saltCmds := make([]*redis.StringCmd, 0, len(keys))
+	idleCmds := make([]*redis.DurationCmd, 0, len(keys))
+	client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			// before the read which resets the idle time
+			idleCmds = append(idleCmds, pipe.ObjectIdleTime(ctx, key))
+			saltCmds = append(saltCmds, pipe.Get(ctx, key))
+		}
+		return nil
+	})
+
+	entries := make([]Entry, 0, len(keys))
+	for index, saltCmd := range saltCmds {
+		salt, err := saltCmd.Bytes()
+		if err == redis.Nil {
+			// removed since the scan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		idle, err := idleCmds[index].Result()
+		ageUnknown := false
+		if err != nil && err != redis.Nil {
+			if !strings.Contains(err.Error(), "LFU") {
+				return nil, err
+			}
+			ageUnknown = true
+		}
+		login := strings.TrimPrefix(keys[index], keyPrefix)
+		entries = append(entries, Entry{Login: login, Salt: salt, Age: idle, AgeUnknown: ageUnknown})
+	}
+	return entries, nil
+}
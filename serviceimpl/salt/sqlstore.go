@@ -0,0 +1,113 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package saltimpl
+
+import (
+	"context"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type saltRow struct {
+	Login     string `gorm:"primaryKey;size:255"`
+	Salt      []byte
+	CreatedAt time.Time
+}
+
+func (saltRow) TableName() string {
+	return "salts"
+}
+
+var upsertSalt = clause.OnConflict{
+	Columns: []clause.Column{{Name: "login"}}, DoUpdates: clause.AssignmentColumns([]string{"salt"}),
+}
+
+type sqlStore struct {
+	db           *gorm.DB
+	certificates *tlsclient.Certificates
+}
+
+func (s sqlStore) Load(ctx context.Context, logins []string) ([][]byte, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+
+	var rows []saltRow
+	if err := s.db.WithContext(ctx).Where("login IN ?", logins).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	byLogin := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		byLogin[row.Login] = row.Salt
+	}
+
+	salts := make([][]byte, 0, len(logins))
+	for _, login := range logins {
+		salts = append(salts, byLogin[login])
+	}
+	return salts, nil
+}
+
+func (s sqlStore) Create(ctx context.Context, login string, salt []byte) ([]byte, error) {
+	db := s.db.WithContext(ctx)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&saltRow{Login: login, Salt: salt})
+	if result.Error != nil || result.RowsAffected != 0 {
+		return salt, result.Error
+	}
+
+	var row saltRow
+	err := db.First(&row, "login = ?", login).Error
+	return row.Salt, err
+}
+
+func (s sqlStore) Set(ctx context.Context, login string, salt []byte) error {
+	return s.db.WithContext(ctx).Clauses(upsertSalt).Create(&saltRow{Login: login, Salt: salt}).Error
+}
+
+func (s sqlStore) Delete(ctx context.Context, logins []string) error {
+	if len(logins) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Where("login IN ?", logins).Delete(&saltRow{}).Error
+}
+
+func (s sqlStore) Close(context.Context) error {
+	tlsclient.Release(s.certificates)
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (s sqlStore) Scan(ctx context.Context, fn func([]Entry) error) error {
+	var rows []saltRow
+	return s.db.WithContext(ctx).FindInBatches(&rows, scanBatchSize, func(*gorm.DB, int) error {
+		now := time.Now()
+		entries := make([]Entry, 0, len(rows))
+		for _, row := range rows {
+			entries = append(entries, Entry{Login: row.Login, Salt: row.Salt, Age: now.Sub(row.CreatedAt)})
+		}
+		return fn(entries)
+	}).Error
+}
@@ -19,10 +19,10 @@ func init() {
 		Iface: reflect.TypeOf((*SaltService)(nil)).Elem(),
 		Impl:  reflect.TypeOf(saltImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return saltService_local_stub{impl: impl.(SaltService), tracer: tracer, loadOrGenerateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "LoadOrGenerate", Remote: false})}
+			return saltService_local_stub{impl: impl.(SaltService), tracer: tracer, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Delete", Remote: false}), loadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Load", Remote: false}), loadOrGenerateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "LoadOrGenerate", Remote: false}), renameMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Rename", Remote: false})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return saltService_client_stub{stub: stub, loadOrGenerateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "LoadOrGenerate", Remote: true})}
+			return saltService_client_stub{stub: stub, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Delete", Remote: true}), loadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Load", Remote: true}), loadOrGenerateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "LoadOrGenerate", Remote: true}), renameMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/salt/SaltService", Method: "Rename", Remote: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return saltService_server_stub{impl: impl.(SaltService), addLoad: addLoad}
@@ -45,12 +45,55 @@ var _ weaver.Unrouted = (*saltImpl)(nil)
 type saltService_local_stub struct {
 	impl                  SaltService
 	tracer                trace.Tracer
+	deleteMetrics         *codegen.MethodMetrics
+	loadMetrics           *codegen.MethodMetrics
 	loadOrGenerateMetrics *codegen.MethodMetrics
+	renameMetrics         *codegen.MethodMetrics
 }
 
 // Check that saltService_local_stub implements the SaltService interface.
 var _ SaltService = (*saltService_local_stub)(nil)
 
+func (s saltService_local_stub) Delete(ctx context.Context, a0 ...string) (err error) {
+	// Update metrics.
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "saltimpl.SaltService.Delete", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Delete(ctx, a0...)
+}
+
+func (s saltService_local_stub) Load(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
+	// Update metrics.
+	begin := s.loadMetrics.Begin()
+	defer func() { s.loadMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "saltimpl.SaltService.Load", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Load(ctx, a0...)
+}
+
 func (s saltService_local_stub) LoadOrGenerate(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
 	// Update metrics.
 	begin := s.loadOrGenerateMetrics.Begin()
@@ -71,16 +114,140 @@ func (s saltService_local_stub) LoadOrGenerate(ctx context.Context, a0 ...string
 	return s.impl.LoadOrGenerate(ctx, a0...)
 }
 
+func (s saltService_local_stub) Rename(ctx context.Context, a0 string, a1 string) (err error) {
+	// Update metrics.
+	begin := s.renameMetrics.Begin()
+	defer func() { s.renameMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "saltimpl.SaltService.Rename", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Rename(ctx, a0, a1)
+}
+
 // Client stub implementations.
 
 type saltService_client_stub struct {
 	stub                  codegen.Stub
+	deleteMetrics         *codegen.MethodMetrics
+	loadMetrics           *codegen.MethodMetrics
 	loadOrGenerateMetrics *codegen.MethodMetrics
+	renameMetrics         *codegen.MethodMetrics
 }
 
 // Check that saltService_client_stub implements the SaltService interface.
 var _ SaltService = (*saltService_client_stub)(nil)
 
+func (s saltService_client_stub) Delete(ctx context.Context, a0 ...string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "saltimpl.SaltService.Delete", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_string_4af10117(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s saltService_client_stub) Load(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.loadMetrics.Begin()
+	defer func() { s.loadMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "saltimpl.SaltService.Load", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_string_4af10117(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_slice_byte_8acc26ee(dec)
+	err = dec.Error()
+	return
+}
+
 func (s saltService_client_stub) LoadOrGenerate(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -118,7 +285,7 @@ func (s saltService_client_stub) LoadOrGenerate(ctx context.Context, a0 ...strin
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -132,6 +299,63 @@ func (s saltService_client_stub) LoadOrGenerate(ctx context.Context, a0 ...strin
 	return
 }
 
+func (s saltService_client_stub) Rename(ctx context.Context, a0 string, a1 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.renameMetrics.Begin()
+	defer func() { s.renameMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "saltimpl.SaltService.Rename", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
@@ -168,13 +392,68 @@ var _ codegen.Server = (*saltService_server_stub)(nil)
 // GetStubFn implements the codegen.Server interface.
 func (s saltService_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
+	case "Delete":
+		return s.delete
+	case "Load":
+		return s.load
 	case "LoadOrGenerate":
 		return s.loadOrGenerate
+	case "Rename":
+		return s.rename
 	default:
 		return nil
 	}
 }
 
+func (s saltService_server_stub) delete(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 []string
+	a0 = serviceweaver_dec_slice_string_4af10117(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Delete(ctx, a0...)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s saltService_server_stub) load(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 []string
+	a0 = serviceweaver_dec_slice_string_4af10117(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Load(ctx, a0...)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_slice_byte_8acc26ee(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s saltService_server_stub) loadOrGenerate(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -200,6 +479,32 @@ func (s saltService_server_stub) loadOrGenerate(ctx context.Context, args []byte
 	return enc.Data(), nil
 }
 
+func (s saltService_server_stub) rename(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Rename(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 // Reflect stub implementations.
 
 type saltService_reflect_stub struct {
@@ -209,11 +514,26 @@ type saltService_reflect_stub struct {
 // Check that saltService_reflect_stub implements the SaltService interface.
 var _ SaltService = (*saltService_reflect_stub)(nil)
 
+func (s saltService_reflect_stub) Delete(ctx context.Context, a0 ...string) (err error) {
+	err = s.caller("Delete", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s saltService_reflect_stub) Load(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
+	err = s.caller("Load", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s saltService_reflect_stub) LoadOrGenerate(ctx context.Context, a0 ...string) (r0 [][]byte, err error) {
 	err = s.caller("LoadOrGenerate", ctx, []any{a0}, []any{&r0})
 	return
 }
 
+func (s saltService_reflect_stub) Rename(ctx context.Context, a0 string, a1 string) (err error) {
+	err = s.caller("Rename", ctx, []any{a0, a1}, []any{})
+	return
+}
+
 // Encoding/decoding implementations.
 
 func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
@@ -0,0 +1,104 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sessionimpl
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
+	"github.com/dvaumoron/puzzleweb/common"
+)
+
+// the values are encrypted by the component (tied to the session id), the stores only see the keys,
+// a value is encrypted again with the active key at its next update, and the index of the sessions
+// by user is keyed by a digest of the user id
+type encryptedStore struct {
+	sessionStore
+	logger  *slog.Logger
+	keyRing *cryptoclient.KeyRing
+}
+
+func (s encryptedStore) create(ctx context.Context, id string, creationTime string, ttl time.Duration) (bool, error) {
+	encrypted, err := s.keyRing.Encrypt(id, creationTimeName, creationTime)
+	if err != nil {
+		return false, err
+	}
+	return s.sessionStore.create(ctx, id, encrypted, ttl)
+}
+
+func (s encryptedStore) get(ctx context.Context, id string) (map[string]string, error) {
+	info, err := s.sessionStore.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// a session which can not be decrypted (altered or with a removed key) is ended
+	decrypted, err := s.keyRing.DecryptMap(id, info)
+	if err != nil {
+		s.logger.Warn(servicecommon.EncryptionMsg, common.ErrorKey, err)
+		return map[string]string{}, s.sessionStore.remove(ctx, id)
+	}
+	return decrypted, nil
+}
+
+func (s encryptedStore) update(ctx context.Context, id string, keyToDelete []string, info map[string]string, creationTime string, ttl time.Duration) error {
+	encryptedInfo, err := s.keyRing.EncryptMap(id, info)
+	if err != nil {
+		return err
+	}
+	encryptedTime, err := s.keyRing.Encrypt(id, creationTimeName, creationTime)
+	if err != nil {
+		return err
+	}
+	return s.sessionStore.update(ctx, id, keyToDelete, encryptedInfo, encryptedTime, ttl)
+}
+
+func (s encryptedStore) link(ctx context.Context, userId string, id string) (bool, error) {
+	return s.sessionStore.link(ctx, s.keyRing.Index(UserIdName, userId), id)
+}
+
+// the digests of the keys kept after a rotation find the links made before it
+func (s encryptedStore) unlink(ctx context.Context, userId string, ids []string) error {
+	for _, index := range s.keyRing.Indexes(UserIdName, userId) {
+		if err := s.sessionStore.unlink(ctx, index, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s encryptedStore) linked(ctx context.Context, userId string) ([]string, error) {
+	var res []string
+	for _, index := range s.keyRing.Indexes(UserIdName, userId) {
+		ids, err := s.sessionStore.linked(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !slices.Contains(res, id) {
+				res = append(res, id)
+			}
+		}
+	}
+	return res, nil
+}
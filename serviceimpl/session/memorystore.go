@@ -0,0 +1,206 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sessionimpl
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+type memorySession struct {
+	info       map[string]string
+	expiration time.Time
+}
+
+// for a single node or tests, the sessions are lost on restart
+type memoryStore struct {
+	mutex    sync.Mutex
+	sessions map[string]*memorySession
+	users    map[string]map[string]struct{}
+	stop     chan struct{}
+}
+
+func newMemoryStore(sweepInterval time.Duration) *memoryStore {
+	store := &memoryStore{
+		sessions: map[string]*memorySession{}, users: map[string]map[string]struct{}{}, stop: make(chan struct{}),
+	}
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-store.stop:
+				return
+			case now := <-ticker.C:
+				store.sweep(now)
+			}
+		}
+	}()
+	return store
+}
+
+func (s *memoryStore) close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, session := range s.sessions {
+		if !now.Before(session.expiration) {
+			delete(s.sessions, id)
+		}
+	}
+	// the index keeps only the live sessions
+	for userId, ids := range s.users {
+		for id := range ids {
+			if _, ok := s.sessions[id]; !ok {
+				delete(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			delete(s.users, userId)
+		}
+	}
+}
+
+// must be called with the lock
+func (s *memoryStore) live(id string, now time.Time) (*memorySession, bool) {
+	session, ok := s.sessions[id]
+	if ok && !now.Before(session.expiration) {
+		delete(s.sessions, id)
+		return nil, false
+	}
+	return session, ok
+}
+
+func (s *memoryStore) create(ctx context.Context, id string, creationTime string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if _, ok := s.live(id, now); ok {
+		return false, nil
+	}
+	s.sessions[id] = &memorySession{info: map[string]string{creationTimeName: creationTime}, expiration: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) get(ctx context.Context, id string) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.live(id, time.Now())
+	if !ok {
+		return map[string]string{}, nil
+	}
+	// the caller can modify the copy
+	return maps.Clone(session.info), nil
+}
+
+func (s *memoryStore) update(ctx context.Context, id string, keyToDelete []string, info map[string]string, creationTime string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	session, ok := s.live(id, now)
+	if !ok {
+		session = &memorySession{info: map[string]string{creationTimeName: creationTime}, expiration: now.Add(ttl)}
+		s.sessions[id] = session
+	}
+	for _, key := range keyToDelete {
+		delete(session.info, key)
+	}
+	maps.Copy(session.info, info)
+	return nil
+}
+
+func (s *memoryStore) touch(ctx context.Context, id string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if session, ok := s.live(id, time.Now()); ok {
+		session.expiration = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+func (s *memoryStore) expiration(ctx context.Context, id string) (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if session, ok := s.live(id, time.Now()); ok {
+		return session.expiration, nil
+	}
+	return time.Time{}, nil
+}
+
+func (s *memoryStore) remove(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *memoryStore) link(ctx context.Context, userId string, id string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids, ok := s.users[userId]
+	if !ok {
+		ids = map[string]struct{}{}
+		s.users[userId] = ids
+	}
+	if _, ok = ids[id]; ok {
+		return false, nil
+	}
+	ids[id] = struct{}{}
+	return true, nil
+}
+
+func (s *memoryStore) unlink(ctx context.Context, userId string, ids []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	linkedIds := s.users[userId]
+	for _, id := range ids {
+		delete(linkedIds, id)
+	}
+	if len(linkedIds) == 0 {
+		delete(s.users, userId)
+	}
+	return nil
+}
+
+func (s *memoryStore) linked(ctx context.Context, userId string) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := make([]string, 0, len(s.users[userId]))
+	for id := range s.users[userId] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
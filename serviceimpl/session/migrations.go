@@ -0,0 +1,88 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package sessionimpl
+
+import (
+	"time"
+
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
+	"gorm.io/gorm"
+)
+
+// applied with the migrate mode of the binary, checked at component start with the sql store,
+// the steps use copies of the models at their version, so they do not follow the later changes
+var Migrations = dbclient.Migrations{Component: "session", Steps: []dbclient.Migration{{
+	Version: 1, Name: "create sessions tables",
+	Up: dbclient.CreateTables(&sessionsV1{}, &sessionValuesV1{}), Down: dbclient.DropTables(&sessionValuesV1{}, &sessionsV1{}),
+}, {
+	Version: 2, Name: "create user sessions index",
+	Up: dbclient.CreateTables(&sessionUsersV2{}), Down: dbclient.DropTables(&sessionUsersV2{}),
+}, {
+	// the index is rebuilt by the next update of each session
+	Version: 3, Name: "recreate user sessions index for the digests of user ids",
+	Up: func(tx *gorm.DB) error {
+		if err := dbclient.DropTables(&sessionUsersV2{})(tx); err != nil {
+			return err
+		}
+		return dbclient.CreateTables(&sessionUsersV3{})(tx)
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dbclient.DropTables(&sessionUsersV3{})(tx); err != nil {
+			return err
+		}
+		return dbclient.CreateTables(&sessionUsersV2{})(tx)
+	},
+}}}
+
+type sessionsV1 struct {
+	Id         string    `gorm:"primaryKey;size:20"`
+	Expiration time.Time `gorm:"index"`
+}
+
+func (sessionsV1) TableName() string {
+	return "sessions"
+}
+
+type sessionValuesV1 struct {
+	SessionId string `gorm:"primaryKey;size:20"`
+	Name      string `gorm:"primaryKey;size:255"`
+	Value     string
+}
+
+func (sessionValuesV1) TableName() string {
+	return "session_values"
+}
+
+type sessionUsersV2 struct {
+	UserId    string `gorm:"primaryKey;size:20"`
+	SessionId string `gorm:"primaryKey;size:20"`
+}
+
+func (sessionUsersV2) TableName() string {
+	return "session_users"
+}
+
+// the user id is a digest with the encryption (like "k1:" followed by 43 characters)
+type sessionUsersV3 struct {
+	UserId    string `gorm:"primaryKey;size:255"`
+	SessionId string `gorm:"primaryKey;size:20"`
+}
+
+func (sessionUsersV3) TableName() string {
+	return "session_users"
+}
@@ -19,44 +19,116 @@
 package sessionimpl
 
 import (
-	"context"
+	"errors"
 	"log/slog"
+	"strings"
 	"time"
 
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
+	dbclient "github.com/dvaumoron/puzzleweaver/client/db"
 	redisclient "github.com/dvaumoron/puzzleweaver/client/redis"
-	"github.com/redis/go-redis/v9"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 )
 
+const (
+	redisStoreKind  = "redis"
+	memoryStoreKind = "memory"
+	sqlStoreKind    = "sql"
+
+	defaultSweepInterval = time.Minute
+)
+
+var errUnknownStore = errors.New("unknown session store")
+
 type sessionConf struct {
 	SessionTimeout time.Duration
-	RetryNumber    int
-	RedisAddress   string
-	RedisUser      string
-	RedisPassword  string
-	RedisDBNum     int
-	Debug          bool
+	// absolute limit from the creation of the session, whatever its activity (no limit when zero)
+	MaxLifetime time.Duration
+	RetryNumber int
+	// "redis" (default), "memory" or "sql"
+	Store           string
+	RedisAddress    string
+	RedisUser       string
+	RedisPassword   string
+	RedisDBNum      int
+	RedisOptions    redisclient.Options
+	RedisTLS        tlsclient.Conf
+	DatabaseKind    string
+	DatabaseAddress string
+	DatabaseTLS     tlsclient.Conf
+	// removal of the expired sessions by the memory and sql stores (default 1m)
+	SweepInterval time.Duration
+	// optional encryption of the session values
+	Encryption cryptoclient.Conf
+	Debug      bool
 }
 
 type initializedSessionConf struct {
-	rdb     *redis.Client
-	updater func(*redis.Client, context.Context, string, []string, map[string]any) error
+	store sessionStore
 }
 
 func initSessionConf(logger *slog.Logger, conf *sessionConf) (initializedSessionConf, error) {
-	rdb, err := redisclient.New(logger, &redis.Options{
-		Addr:     conf.RedisAddress,
-		Username: conf.RedisUser,
-		Password: conf.RedisPassword,
-		DB:       conf.RedisDBNum,
-	})
+	store, err := newStore(logger, conf)
 	if err != nil {
 		return initializedSessionConf{}, err
 	}
 
-	updater := updateSessionInfoTx
+	keyRing, err := cryptoclient.Load(logger, conf.Encryption)
+	if err != nil {
+		// the component will not start, so its Shutdown will not be called
+		store.close()
+		return initializedSessionConf{}, err
+	}
+	if keyRing != nil {
+		store = encryptedStore{sessionStore: store, logger: logger, keyRing: keyRing}
+	}
+	return initializedSessionConf{store: store}, nil
+}
+
+func newStore(logger *slog.Logger, conf *sessionConf) (sessionStore, error) {
+	sweepInterval := conf.SweepInterval
+	if sweepInterval <= 0 {
+		sweepInterval = defaultSweepInterval
+	}
+
+	switch strings.ToLower(conf.Store) {
+	case "", redisStoreKind:
+		return newRedisStore(logger, conf)
+	case memoryStoreKind:
+		return newMemoryStore(sweepInterval), nil
+	case sqlStoreKind:
+		certificates, err := tlsclient.Load(logger, conf.DatabaseTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := dbclient.New(conf.DatabaseKind, conf.DatabaseAddress, certificates)
+		if err == nil {
+			err = Migrations.Check(db)
+		}
+		if err != nil {
+			tlsclient.Release(certificates)
+			return nil, err
+		}
+		return newSqlStore(logger, db, certificates, sweepInterval), nil
+	}
+	return nil, errUnknownStore
+}
+
+func newRedisStore(logger *slog.Logger, conf *sessionConf) (redisStore, error) {
+	certificates, err := tlsclient.Load(logger, conf.RedisTLS)
+	if err != nil {
+		return redisStore{}, err
+	}
+
+	rdb, err := redisclient.New(logger, conf.RedisAddress, conf.RedisUser, conf.RedisPassword, conf.RedisDBNum, conf.RedisOptions, certificates)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return redisStore{}, err
+	}
+
 	if conf.Debug {
 		logger.Info("Mode debug on")
-		updater = updateSessionInfo
 	}
-	return initializedSessionConf{rdb: rdb, updater: updater}, nil
+	return redisStore{rdb: rdb, tx: !conf.Debug, certificates: certificates}, nil
 }
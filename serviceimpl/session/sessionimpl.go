@@ -19,85 +19,122 @@
 package sessionimpl
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"log/slog"
-	"math/rand"
+	"slices"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/ServiceWeaver/weaver"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
-	"github.com/redis/go-redis/v9"
 )
 
-// this key maintains the existence of the session when there is no other data,
-// but it is never send to client nor updated by it
-const creationTimeName = "sessionCreationTime"
+const (
+	// this key maintains the existence of the session when there is no other data,
+	// but it is never send to client nor updated by it
+	creationTimeName = "sessionCreationTime"
+	// UserIdName and LoginName are the keys used by puzzleweb to store the connected user
+	UserIdName = "UserId"
+	LoginName  = "Login"
 
-var errGenerateRetry = errors.New("generate reached maximum number of retries")
+	// format of the creation time before the user index
+	legacyTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+)
+
+var (
+	errGenerateRetry = errors.New("generate reached maximum number of retries")
+	errSessionEnded  = errors.New("session ended")
+)
 
 type sessionImpl struct {
 	weaver.Implements[SessionService]
 	weaver.WithConfig[sessionConf]
 	initializedConf initializedSessionConf
-	generateMutex   sync.Mutex
 }
 
 func (impl *sessionImpl) Init(ctx context.Context) (err error) {
 	impl.initializedConf, err = initSessionConf(impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// stops the sweep of the memory and sql stores and closes the store client
+func (impl *sessionImpl) Shutdown(ctx context.Context) error {
+	return impl.initializedConf.store.close()
+}
+
 func (impl *sessionImpl) updateWithDefaultTTL(ctx context.Context, logger *slog.Logger, id string) {
-	if err := impl.initializedConf.rdb.Expire(ctx, id, impl.Config().SessionTimeout).Err(); err != nil {
+	if err := impl.initializedConf.store.touch(ctx, id, impl.Config().SessionTimeout); err != nil {
 		logger.Info("Failed to set TTL", common.ErrorKey, err)
 	}
 }
 
 func (impl *sessionImpl) Generate(ctx context.Context) (uint64, error) {
-	logger := impl.Logger(ctx)
+	id, _, err := impl.create(ctx, impl.Logger(ctx), time.Now().Format(time.RFC3339Nano))
+	return id, err
+}
+
+func (impl *sessionImpl) create(ctx context.Context, logger *slog.Logger, creationTime string) (uint64, string, error) {
+	conf := impl.Config()
+	for i := 0; i < conf.RetryNumber; i++ {
+		id, err := generateId()
+		if err != nil {
+			logger.Error("Failed to generate a random id", common.ErrorKey, err)
+			return 0, "", servicecommon.ErrInternal
+		}
 
-	// avoid id clash when generating, but possible bottleneck
-	impl.generateMutex.Lock()
-	defer impl.generateMutex.Unlock()
-	for i := 0; i < impl.Config().RetryNumber; i++ {
-		id := rand.Uint64()
 		idStr := strconv.FormatUint(id, 10)
-		nb, err := impl.initializedConf.rdb.Exists(ctx, idStr).Result()
+		created, err := impl.initializedConf.store.create(ctx, idStr, creationTime, conf.SessionTimeout)
 		if err != nil {
-			logger.Error(servicecommon.RedisCallMsg, common.ErrorKey, err)
-			return 0, servicecommon.ErrInternal
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return 0, "", servicecommon.ErrInternal
 		}
-		if nb == 0 {
-			err := impl.initializedConf.rdb.HSet(ctx, idStr, creationTimeName, time.Now().String()).Err()
-			if err != nil {
-				logger.Error(servicecommon.RedisCallMsg, common.ErrorKey, err)
-				return 0, servicecommon.ErrInternal
-			}
-			impl.updateWithDefaultTTL(ctx, logger, idStr)
+		if created {
+			return id, idStr, nil
+		}
+	}
+	return 0, "", errGenerateRetry
+}
+
+// unpredictable, zero is avoided as it could be confused with an absent id
+func generateId() (uint64, error) {
+	var buffer [8]byte
+	for {
+		if _, err := rand.Read(buffer[:]); err != nil {
+			return 0, err
+		}
+		if id := binary.BigEndian.Uint64(buffer[:]); id != 0 {
 			return id, nil
 		}
 	}
-	return 0, errGenerateRetry
 }
 
 func (impl *sessionImpl) Get(ctx context.Context, id uint64) (map[string]string, error) {
 	logger := impl.Logger(ctx)
 
 	idStr := strconv.FormatUint(id, 10)
-	info, err := impl.initializedConf.rdb.HGetAll(ctx, idStr).Result()
+	info, err := impl.initializedConf.store.get(ctx, idStr)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
-
-		logger.Error(servicecommon.RedisCallMsg, common.ErrorKey, err)
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
 		return nil, servicecommon.ErrInternal
 	}
 
+	if impl.ended(info, time.Now()) {
+		if err = impl.end(ctx, idStr, info[UserIdName]); err != nil {
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return nil, servicecommon.ErrInternal
+		}
+		return map[string]string{}, nil
+	}
+
 	impl.updateWithDefaultTTL(ctx, logger, idStr)
 	delete(info, creationTimeName)
 	return info, nil
@@ -106,7 +143,7 @@ func (impl *sessionImpl) Get(ctx context.Context, id uint64) (map[string]string,
 func (impl *sessionImpl) Update(ctx context.Context, id uint64, info map[string]string) error {
 	logger := impl.Logger(ctx)
 
-	infoCopy := map[string]any{}
+	infoCopy := map[string]string{}
 	var keyToDelete []string
 	for k, v := range info {
 		if k == creationTimeName {
@@ -118,40 +155,226 @@ func (impl *sessionImpl) Update(ctx context.Context, id uint64, info map[string]
 		}
 	}
 	idStr := strconv.FormatUint(id, 10)
-	if err := impl.initializedConf.updater(impl.initializedConf.rdb, ctx, idStr, keyToDelete, infoCopy); err != nil {
-		logger.Error(servicecommon.RedisCallMsg, common.ErrorKey, err)
+	store := impl.initializedConf.store
+	// an ended session is not extended (an unknown one starts again)
+	stored, err := store.get(ctx, idStr)
+	if err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+	if impl.ended(stored, time.Now()) {
+		if err = impl.end(ctx, idStr, stored[UserIdName]); err != nil {
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return servicecommon.ErrInternal
+		}
+		return errSessionEnded
+	}
+
+	creationTime := time.Now().Format(time.RFC3339Nano)
+	if err = store.update(ctx, idStr, keyToDelete, infoCopy, creationTime, impl.Config().SessionTimeout); err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
 		return servicecommon.ErrInternal
 	}
+	if userId := infoCopy[UserIdName]; userId != "" {
+		if err := impl.linkUser(ctx, userId, idStr); err != nil {
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return servicecommon.ErrInternal
+		}
+	}
 	impl.updateWithDefaultTTL(ctx, logger, idStr)
 	return nil
 }
 
-func updateSessionInfoTx(rdb *redis.Client, ctx context.Context, id string, keyToDelete []string, info map[string]any) error {
-	haveActions := false
-	pipe := rdb.TxPipeline()
-	if len(keyToDelete) != 0 {
-		haveActions = true
-		pipe.HDel(ctx, id, keyToDelete...)
+// the data are copied in a new session with the same creation time (the lifetime is not extended),
+// an unknown or ended session is replaced by an empty one
+func (impl *sessionImpl) Renew(ctx context.Context, id uint64) (uint64, error) {
+	logger := impl.Logger(ctx)
+
+	store := impl.initializedConf.store
+	idStr := strconv.FormatUint(id, 10)
+	info, err := store.get(ctx, idStr)
+	if err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return 0, servicecommon.ErrInternal
+	}
+
+	userId := info[UserIdName]
+	if len(info) == 0 || impl.ended(info, time.Now()) {
+		if err = impl.end(ctx, idStr, userId); err != nil {
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return 0, servicecommon.ErrInternal
+		}
+		return impl.Generate(ctx)
+	}
+
+	creationTime := info[creationTimeName]
+	if creationTime == "" {
+		creationTime = time.Now().Format(time.RFC3339Nano)
+	}
+	delete(info, creationTimeName)
+
+	newId, newIdStr, err := impl.create(ctx, logger, creationTime)
+	if err != nil {
+		return 0, err
+	}
+	err = store.update(ctx, newIdStr, nil, info, creationTime, impl.Config().SessionTimeout)
+	if err == nil && userId != "" {
+		err = impl.linkUser(ctx, userId, newIdStr)
+	}
+	if err == nil {
+		err = impl.end(ctx, idStr, userId)
+	}
+	if err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return 0, servicecommon.ErrInternal
+	}
+	return newId, nil
+}
+
+// the absolute lifetime is counted from the creation time, without limit when it is not set
+func (impl *sessionImpl) ended(info map[string]string, now time.Time) bool {
+	maxLifetime := impl.Config().MaxLifetime
+	if maxLifetime <= 0 {
+		return false
 	}
-	if len(info) != 0 {
-		haveActions = true
-		pipe.HSet(ctx, id, info)
+	creationTime, ok := parseCreationTime(info[creationTimeName])
+	return ok && !now.Before(creationTime.Add(maxLifetime))
+}
+
+func (impl *sessionImpl) end(ctx context.Context, id string, userId string) error {
+	store := impl.initializedConf.store
+	err := store.remove(ctx, id)
+	if err == nil && userId != "" {
+		err = store.unlink(ctx, userId, []string{id})
 	}
-	if haveActions {
-		_, err := pipe.Exec(ctx)
-		return err
+	return err
+}
+
+// on a new link (a login), the index is cleaned to not grow with each connection
+func (impl *sessionImpl) linkUser(ctx context.Context, userId string, id string) error {
+	added, err := impl.initializedConf.store.link(ctx, userId, id)
+	if err == nil && added {
+		_, err = impl.userSessions(ctx, userId)
+	}
+	return err
+}
+
+func (impl *sessionImpl) ListUserSessions(ctx context.Context, userId uint64) ([]SessionInfo, error) {
+	sessions, err := impl.userSessions(ctx, strconv.FormatUint(userId, 10))
+	if err != nil {
+		impl.Logger(ctx).Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+	return sessions, nil
+}
+
+func (impl *sessionImpl) RevokeUserSession(ctx context.Context, userId uint64, sessionId uint64) error {
+	logger := impl.Logger(ctx)
+
+	store := impl.initializedConf.store
+	userIdStr, idStr := strconv.FormatUint(userId, 10), strconv.FormatUint(sessionId, 10)
+	info, err := store.get(ctx, idStr)
+	if err == nil && info[UserIdName] == userIdStr {
+		err = store.remove(ctx, idStr)
+	}
+	if err == nil {
+		err = store.unlink(ctx, userIdStr, []string{idStr})
+	}
+	if err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
 	}
 	return nil
 }
 
-func updateSessionInfo(rdb *redis.Client, ctx context.Context, id string, keyToDelete []string, info map[string]any) error {
-	if len(keyToDelete) != 0 {
-		if err := rdb.HDel(ctx, id, keyToDelete...).Err(); err != nil {
-			return err
+func (impl *sessionImpl) RevokeUserSessions(ctx context.Context, userId uint64, keepId uint64) error {
+	logger := impl.Logger(ctx)
+
+	store := impl.initializedConf.store
+	userIdStr := strconv.FormatUint(userId, 10)
+	sessions, err := impl.userSessions(ctx, userIdStr)
+	if err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Id == keepId {
+			continue
 		}
+		idStr := strconv.FormatUint(session.Id, 10)
+		if err = store.remove(ctx, idStr); err != nil {
+			logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+			return servicecommon.ErrInternal
+		}
+		ids = append(ids, idStr)
 	}
-	if len(info) != 0 {
-		return rdb.HSet(ctx, id, info).Err()
+	if err = store.unlink(ctx, userIdStr, ids); err != nil {
+		logger.Error(servicecommon.SessionStoreMsg, common.ErrorKey, err)
+		return servicecommon.ErrInternal
 	}
 	return nil
 }
+
+// the stale entries of the index (expired session or logout) are removed
+func (impl *sessionImpl) userSessions(ctx context.Context, userId string) ([]SessionInfo, error) {
+	store := impl.initializedConf.store
+	ids, err := store.linked(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	timeout := impl.Config().SessionTimeout
+	var staleIds []string
+	sessions := make([]SessionInfo, 0, len(ids))
+	for _, idStr := range ids {
+		info, err := store.get(ctx, idStr)
+		if err != nil {
+			return nil, err
+		}
+		expiration := time.Time{}
+		if info[UserIdName] == userId {
+			if impl.ended(info, now) {
+				if err = store.remove(ctx, idStr); err != nil {
+					return nil, err
+				}
+			} else if expiration, err = store.expiration(ctx, idStr); err != nil {
+				return nil, err
+			}
+		}
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil || expiration.IsZero() {
+			staleIds = append(staleIds, idStr)
+			continue
+		}
+
+		var createdAt int64
+		if creationTime, ok := parseCreationTime(info[creationTimeName]); ok {
+			createdAt = creationTime.Unix()
+		}
+		// the expiration is pushed by each access
+		sessions = append(sessions, SessionInfo{Id: id, CreatedAt: createdAt, LastAccess: expiration.Add(-timeout).Unix()})
+	}
+
+	if err = store.unlink(ctx, userId, staleIds); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(sessions, func(a SessionInfo, b SessionInfo) int {
+		return cmp.Compare(b.LastAccess, a.LastAccess)
+	})
+	return sessions, nil
+}
+
+func parseCreationTime(value string) (time.Time, bool) {
+	creationTime, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		// remove the monotonic clock reading
+		value, _, _ = strings.Cut(value, " m=")
+		if creationTime, err = time.Parse(legacyTimeLayout, value); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return creationTime, true
+}
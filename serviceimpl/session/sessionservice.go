@@ -21,10 +21,27 @@ package sessionimpl
 import (
 	"context"
 
+	"github.com/ServiceWeaver/weaver"
 	settingsimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/settings"
 )
 
+// times are unix timestamps
+type SessionInfo struct {
+	weaver.AutoMarshal
+	Id         uint64
+	CreatedAt  int64
+	LastAccess int64
+}
+
 type SessionService interface {
 	settingsimpl.SettingsService
 	Generate(ctx context.Context) (uint64, error)
+	// reissue the session with a new id, the old one is no longer valid
+	Renew(ctx context.Context, id uint64) (uint64, error)
+	// active sessions of the user, most recently accessed first
+	ListUserSessions(ctx context.Context, userId uint64) ([]SessionInfo, error)
+	// no effect when the session does not belong to the user
+	RevokeUserSession(ctx context.Context, userId uint64, sessionId uint64) error
+	// the session keepId is not revoked (zero revokes all the sessions)
+	RevokeUserSessions(ctx context.Context, userId uint64, keepId uint64) error
 }
@@ -0,0 +1,160 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sessionimpl
+
+import (
+	"context"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"github.com/redis/go-redis/v9"
+)
+
+// the store only keeps maps of strings with an expiration,
+// the rules on session keys are applied by the component
+type sessionStore interface {
+	// create saves the hidden creation time key, it returns false when the id is already used
+	create(ctx context.Context, id string, creationTime string, ttl time.Duration) (bool, error)
+	// get returns an empty map for an unknown or expired session
+	get(ctx context.Context, id string) (map[string]string, error)
+	// update recreates the session (with the creation time and the ttl) when it has expired
+	update(ctx context.Context, id string, keyToDelete []string, info map[string]string, creationTime string, ttl time.Duration) error
+	touch(ctx context.Context, id string, ttl time.Duration) error
+	// expiration returns a zero time for an unknown or expired session
+	expiration(ctx context.Context, id string) (time.Time, error)
+	remove(ctx context.Context, id string) error
+	// link adds the session to the index of the user, it returns false when it was already there
+	link(ctx context.Context, userId string, id string) (bool, error)
+	unlink(ctx context.Context, userId string, ids []string) error
+	// linked can return sessions which have expired or changed of user
+	linked(ctx context.Context, userId string) ([]string, error)
+	// close stops the background sweep and releases the client
+	close() error
+}
+
+// create the session only when the id is unused, with its TTL in the same step
+var createSessionScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return 1`)
+
+type redisStore struct {
+	rdb redis.UniversalClient
+	// without transaction in debug mode
+	tx           bool
+	certificates *tlsclient.Certificates
+}
+
+func (s redisStore) close() error {
+	tlsclient.Release(s.certificates)
+	return s.rdb.Close()
+}
+
+func (s redisStore) create(ctx context.Context, id string, creationTime string, ttl time.Duration) (bool, error) {
+	return createSessionScript.Run(ctx, s.rdb, []string{id}, creationTimeName, creationTime, ttl.Milliseconds()).Bool()
+}
+
+func (s redisStore) get(ctx context.Context, id string) (map[string]string, error) {
+	info, err := s.rdb.HGetAll(ctx, id).Result()
+	if err == redis.Nil {
+		return map[string]string{}, nil
+	}
+	return info, err
+}
+
+// the ttl is set by the following touch
+func (s redisStore) update(ctx context.Context, id string, keyToDelete []string, info map[string]string, creationTime string, ttl time.Duration) error {
+	if len(keyToDelete) == 0 && len(info) == 0 {
+		return nil
+	}
+
+	if !s.tx {
+		if len(keyToDelete) != 0 {
+			if err := s.rdb.HDel(ctx, id, keyToDelete...).Err(); err != nil {
+				return err
+			}
+		}
+		if len(info) != 0 {
+			if err := s.rdb.HSet(ctx, id, info).Err(); err != nil {
+				return err
+			}
+		}
+		return s.rdb.HSetNX(ctx, id, creationTimeName, creationTime).Err()
+	}
+
+	pipe := s.rdb.TxPipeline()
+	if len(keyToDelete) != 0 {
+		pipe.HDel(ctx, id, keyToDelete...)
+	}
+	if len(info) != 0 {
+		pipe.HSet(ctx, id, info)
+	}
+	pipe.HSetNX(ctx, id, creationTimeName, creationTime)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s redisStore) touch(ctx context.Context, id string, ttl time.Duration) error {
+	return s.rdb.Expire(ctx, id, ttl).Err()
+}
+
+func (s redisStore) expiration(ctx context.Context, id string) (time.Time, error) {
+	ttl, err := s.rdb.PTTL(ctx, id).Result()
+	// negative for a missing key or a key without expiration
+	if err != nil || ttl < 0 {
+		return time.Time{}, err
+	}
+	return time.Now().Add(ttl), nil
+}
+
+func (s redisStore) remove(ctx context.Context, id string) error {
+	return s.rdb.Del(ctx, id).Err()
+}
+
+// session ids are only digits, so there is no collision
+func userSessionsKey(userId string) string {
+	return "userSessions:" + userId
+}
+
+func (s redisStore) link(ctx context.Context, userId string, id string) (bool, error) {
+	added, err := s.rdb.SAdd(ctx, userSessionsKey(userId), id).Result()
+	return added != 0, err
+}
+
+func (s redisStore) unlink(ctx context.Context, userId string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	members := make([]any, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	return s.rdb.SRem(ctx, userSessionsKey(userId), members...).Err()
+}
+
+func (s redisStore) linked(ctx context.Context, userId string) ([]string, error) {
+	ids, err := s.rdb.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return ids, err
+}
@@ -0,0 +1,228 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sessionimpl
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"github.com/dvaumoron/puzzleweb/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sessionRow struct {
+	Id         string    `gorm:"primaryKey;size:20"`
+	Expiration time.Time `gorm:"index"`
+}
+
+func (sessionRow) TableName() string {
+	return "sessions"
+}
+
+type sessionValue struct {
+	SessionId string `gorm:"primaryKey;size:20"`
+	Name      string `gorm:"primaryKey;size:255"`
+	Value     string
+}
+
+func (sessionValue) TableName() string {
+	return "session_values"
+}
+
+type sessionUser struct {
+	UserId    string `gorm:"primaryKey;size:255"`
+	SessionId string `gorm:"primaryKey;size:20"`
+}
+
+func (sessionUser) TableName() string {
+	return "session_users"
+}
+
+var upsertValue = clause.OnConflict{
+	Columns: []clause.Column{{Name: "session_id"}, {Name: "name"}}, DoUpdates: clause.AssignmentColumns([]string{"value"}),
+}
+
+type sqlStore struct {
+	db           *gorm.DB
+	certificates *tlsclient.Certificates
+	stop         chan struct{}
+}
+
+func newSqlStore(logger *slog.Logger, db *gorm.DB, certificates *tlsclient.Certificates, sweepInterval time.Duration) sqlStore {
+	store := sqlStore{db: db, certificates: certificates, stop: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-store.stop:
+				return
+			case now := <-ticker.C:
+				if err := store.sweep(now); err != nil {
+					logger.Error("Failed to remove expired sessions", common.ErrorKey, err)
+				}
+			}
+		}
+	}()
+	return store
+}
+
+func (s sqlStore) close() error {
+	close(s.stop)
+	tlsclient.Release(s.certificates)
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (s sqlStore) sweep(now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&sessionRow{}).Select("id").Where("expiration <= ?", now)
+		if err := tx.Where("session_id IN (?)", expired).Delete(&sessionValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id IN (?)", expired).Delete(&sessionUser{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expiration <= ?", now).Delete(&sessionRow{}).Error
+	})
+}
+
+// must be called in a transaction, the values of an expired session with the same id are removed
+func clearExpired(tx *gorm.DB, id string, now time.Time) error {
+	result := tx.Where("id = ? AND expiration <= ?", id, now).Delete(&sessionRow{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	return tx.Where("session_id = ?", id).Delete(&sessionValue{}).Error
+}
+
+func (s sqlStore) create(ctx context.Context, id string, creationTime string, ttl time.Duration) (bool, error) {
+	created := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := clearExpired(tx, id, now); err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sessionRow{Id: id, Expiration: now.Add(ttl)})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		created = true
+		return tx.Create(&sessionValue{SessionId: id, Name: creationTimeName, Value: creationTime}).Error
+	})
+	return created, err
+}
+
+func (s sqlStore) get(ctx context.Context, id string) (map[string]string, error) {
+	var values []sessionValue
+	err := s.db.WithContext(ctx).Where(
+		"session_id = ? AND EXISTS (SELECT 1 FROM sessions WHERE id = ? AND expiration > ?)", id, id, time.Now(),
+	).Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	info := make(map[string]string, len(values))
+	for _, value := range values {
+		info[value.Name] = value.Value
+	}
+	return info, nil
+}
+
+func (s sqlStore) update(ctx context.Context, id string, keyToDelete []string, info map[string]string, creationTime string, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := clearExpired(tx, id, now); err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sessionRow{Id: id, Expiration: now.Add(ttl)})
+		if err := result.Error; err != nil {
+			return err
+		}
+		if result.RowsAffected != 0 {
+			if err := tx.Create(&sessionValue{SessionId: id, Name: creationTimeName, Value: creationTime}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(keyToDelete) != 0 {
+			if err := tx.Where("session_id = ? AND name IN ?", id, keyToDelete).Delete(&sessionValue{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(info) == 0 {
+			return nil
+		}
+
+		values := make([]sessionValue, 0, len(info))
+		for name, value := range info {
+			values = append(values, sessionValue{SessionId: id, Name: name, Value: value})
+		}
+		return tx.Clauses(upsertValue).Create(&values).Error
+	})
+}
+
+func (s sqlStore) touch(ctx context.Context, id string, ttl time.Duration) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&sessionRow{}).Where("id = ? AND expiration > ?", id, now).Update("expiration", now.Add(ttl)).Error
+}
+
+func (s sqlStore) expiration(ctx context.Context, id string) (time.Time, error) {
+	var rows []sessionRow
+	if err := s.db.WithContext(ctx).Where("id = ? AND expiration > ?", id, time.Now()).Find(&rows).Error; err != nil || len(rows) == 0 {
+		return time.Time{}, err
+	}
+	return rows[0].Expiration, nil
+}
+
+func (s sqlStore) remove(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&sessionValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&sessionRow{}).Error
+	})
+}
+
+func (s sqlStore) link(ctx context.Context, userId string, id string) (bool, error) {
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&sessionUser{UserId: userId, SessionId: id})
+	return result.RowsAffected != 0, result.Error
+}
+
+func (s sqlStore) unlink(ctx context.Context, userId string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Where("user_id = ? AND session_id IN ?", userId, ids).Delete(&sessionUser{}).Error
+}
+
+func (s sqlStore) linked(ctx context.Context, userId string) ([]string, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Model(&sessionUser{}).Where("user_id = ?", userId).Pluck("session_id", &ids).Error
+	return ids, err
+}
@@ -6,6 +6,7 @@ package sessionimpl
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ServiceWeaver/weaver"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"go.opentelemetry.io/otel/codes"
@@ -19,10 +20,10 @@ func init() {
 		Iface: reflect.TypeOf((*SessionService)(nil)).Elem(),
 		Impl:  reflect.TypeOf(sessionImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return sessionService_local_stub{impl: impl.(SessionService), tracer: tracer, generateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Generate", Remote: false}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Get", Remote: false}), updateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Update", Remote: false})}
+			return sessionService_local_stub{impl: impl.(SessionService), tracer: tracer, generateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Generate", Remote: false}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Get", Remote: false}), listUserSessionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "ListUserSessions", Remote: false}), renewMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Renew", Remote: false}), revokeUserSessionMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "RevokeUserSession", Remote: false}), revokeUserSessionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "RevokeUserSessions", Remote: false}), updateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Update", Remote: false})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return sessionService_client_stub{stub: stub, generateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Generate", Remote: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Get", Remote: true}), updateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Update", Remote: true})}
+			return sessionService_client_stub{stub: stub, generateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Generate", Remote: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Get", Remote: true}), listUserSessionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "ListUserSessions", Remote: true}), renewMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Renew", Remote: true}), revokeUserSessionMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "RevokeUserSession", Remote: true}), revokeUserSessionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "RevokeUserSessions", Remote: true}), updateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/dvaumoron/puzzleweaver/serviceimpl/session/SessionService", Method: "Update", Remote: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return sessionService_server_stub{impl: impl.(SessionService), addLoad: addLoad}
@@ -43,11 +44,15 @@ var _ weaver.Unrouted = (*sessionImpl)(nil)
 // Local stub implementations.
 
 type sessionService_local_stub struct {
-	impl            SessionService
-	tracer          trace.Tracer
-	generateMetrics *codegen.MethodMetrics
-	getMetrics      *codegen.MethodMetrics
-	updateMetrics   *codegen.MethodMetrics
+	impl                      SessionService
+	tracer                    trace.Tracer
+	generateMetrics           *codegen.MethodMetrics
+	getMetrics                *codegen.MethodMetrics
+	listUserSessionsMetrics   *codegen.MethodMetrics
+	renewMetrics              *codegen.MethodMetrics
+	revokeUserSessionMetrics  *codegen.MethodMetrics
+	revokeUserSessionsMetrics *codegen.MethodMetrics
+	updateMetrics             *codegen.MethodMetrics
 }
 
 // Check that sessionService_local_stub implements the SessionService interface.
@@ -93,6 +98,86 @@ func (s sessionService_local_stub) Get(ctx context.Context, a0 uint64) (r0 map[s
 	return s.impl.Get(ctx, a0)
 }
 
+func (s sessionService_local_stub) ListUserSessions(ctx context.Context, a0 uint64) (r0 []SessionInfo, err error) {
+	// Update metrics.
+	begin := s.listUserSessionsMetrics.Begin()
+	defer func() { s.listUserSessionsMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sessionimpl.SessionService.ListUserSessions", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.ListUserSessions(ctx, a0)
+}
+
+func (s sessionService_local_stub) Renew(ctx context.Context, a0 uint64) (r0 uint64, err error) {
+	// Update metrics.
+	begin := s.renewMetrics.Begin()
+	defer func() { s.renewMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sessionimpl.SessionService.Renew", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Renew(ctx, a0)
+}
+
+func (s sessionService_local_stub) RevokeUserSession(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	// Update metrics.
+	begin := s.revokeUserSessionMetrics.Begin()
+	defer func() { s.revokeUserSessionMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sessionimpl.SessionService.RevokeUserSession", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.RevokeUserSession(ctx, a0, a1)
+}
+
+func (s sessionService_local_stub) RevokeUserSessions(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	// Update metrics.
+	begin := s.revokeUserSessionsMetrics.Begin()
+	defer func() { s.revokeUserSessionsMetrics.End(begin, err != nil, 0, 0) }()
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sessionimpl.SessionService.RevokeUserSessions", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.RevokeUserSessions(ctx, a0, a1)
+}
+
 func (s sessionService_local_stub) Update(ctx context.Context, a0 uint64, a1 map[string]string) (err error) {
 	// Update metrics.
 	begin := s.updateMetrics.Begin()
@@ -116,10 +201,14 @@ func (s sessionService_local_stub) Update(ctx context.Context, a0 uint64, a1 map
 // Client stub implementations.
 
 type sessionService_client_stub struct {
-	stub            codegen.Stub
-	generateMetrics *codegen.MethodMetrics
-	getMetrics      *codegen.MethodMetrics
-	updateMetrics   *codegen.MethodMetrics
+	stub                      codegen.Stub
+	generateMetrics           *codegen.MethodMetrics
+	getMetrics                *codegen.MethodMetrics
+	listUserSessionsMetrics   *codegen.MethodMetrics
+	renewMetrics              *codegen.MethodMetrics
+	revokeUserSessionMetrics  *codegen.MethodMetrics
+	revokeUserSessionsMetrics *codegen.MethodMetrics
+	updateMetrics             *codegen.MethodMetrics
 }
 
 // Check that sessionService_client_stub implements the SessionService interface.
@@ -228,6 +317,232 @@ func (s sessionService_client_stub) Get(ctx context.Context, a0 uint64) (r0 map[
 	return
 }
 
+func (s sessionService_client_stub) ListUserSessions(ctx context.Context, a0 uint64) (r0 []SessionInfo, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.listUserSessionsMetrics.Begin()
+	defer func() { s.listUserSessionsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sessionimpl.SessionService.ListUserSessions", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_slice_SessionInfo_fc219f80(dec)
+	err = dec.Error()
+	return
+}
+
+func (s sessionService_client_stub) Renew(ctx context.Context, a0 uint64) (r0 uint64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.renewMetrics.Begin()
+	defer func() { s.renewMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sessionimpl.SessionService.Renew", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Uint64()
+	err = dec.Error()
+	return
+}
+
+func (s sessionService_client_stub) RevokeUserSession(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.revokeUserSessionMetrics.Begin()
+	defer func() { s.revokeUserSessionMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sessionimpl.SessionService.RevokeUserSession", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.Uint64(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s sessionService_client_stub) RevokeUserSessions(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.revokeUserSessionsMetrics.Begin()
+	defer func() { s.revokeUserSessionsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sessionimpl.SessionService.RevokeUserSessions", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += 8
+	enc := codegen.NewEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Uint64(a0)
+	enc.Uint64(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
 func (s sessionService_client_stub) Update(ctx context.Context, a0 uint64, a1 map[string]string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -266,7 +581,7 @@ func (s sessionService_client_stub) Update(ctx context.Context, a0 uint64, a1 ma
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -319,6 +634,14 @@ func (s sessionService_server_stub) GetStubFn(method string) func(ctx context.Co
 		return s.generate
 	case "Get":
 		return s.get
+	case "ListUserSessions":
+		return s.listUserSessions
+	case "Renew":
+		return s.renew
+	case "RevokeUserSession":
+		return s.revokeUserSession
+	case "RevokeUserSessions":
+		return s.revokeUserSessions
 	case "Update":
 		return s.update
 	default:
@@ -371,6 +694,108 @@ func (s sessionService_server_stub) get(ctx context.Context, args []byte) (res [
 	return enc.Data(), nil
 }
 
+func (s sessionService_server_stub) listUserSessions(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.ListUserSessions(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_SessionInfo_fc219f80(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s sessionService_server_stub) renew(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Renew(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Uint64(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s sessionService_server_stub) revokeUserSession(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 uint64
+	a1 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.RevokeUserSession(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s sessionService_server_stub) revokeUserSessions(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 uint64
+	a0 = dec.Uint64()
+	var a1 uint64
+	a1 = dec.Uint64()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.RevokeUserSessions(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s sessionService_server_stub) update(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -416,11 +841,62 @@ func (s sessionService_reflect_stub) Get(ctx context.Context, a0 uint64) (r0 map
 	return
 }
 
+func (s sessionService_reflect_stub) ListUserSessions(ctx context.Context, a0 uint64) (r0 []SessionInfo, err error) {
+	err = s.caller("ListUserSessions", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s sessionService_reflect_stub) Renew(ctx context.Context, a0 uint64) (r0 uint64, err error) {
+	err = s.caller("Renew", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s sessionService_reflect_stub) RevokeUserSession(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	err = s.caller("RevokeUserSession", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+func (s sessionService_reflect_stub) RevokeUserSessions(ctx context.Context, a0 uint64, a1 uint64) (err error) {
+	err = s.caller("RevokeUserSessions", ctx, []any{a0, a1}, []any{})
+	return
+}
+
 func (s sessionService_reflect_stub) Update(ctx context.Context, a0 uint64, a1 map[string]string) (err error) {
 	err = s.caller("Update", ctx, []any{a0, a1}, []any{})
 	return
 }
 
+// AutoMarshal implementations.
+
+var _ codegen.AutoMarshal = (*SessionInfo)(nil)
+
+type __is_SessionInfo[T ~struct {
+	weaver.AutoMarshal
+	Id         uint64
+	CreatedAt  int64
+	LastAccess int64
+}] struct{}
+
+var _ __is_SessionInfo[SessionInfo]
+
+func (x *SessionInfo) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("SessionInfo.WeaverMarshal: nil receiver"))
+	}
+	enc.Uint64(x.Id)
+	enc.Int64(x.CreatedAt)
+	enc.Int64(x.LastAccess)
+}
+
+func (x *SessionInfo) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("SessionInfo.WeaverUnmarshal: nil receiver"))
+	}
+	x.Id = dec.Uint64()
+	x.CreatedAt = dec.Int64()
+	x.LastAccess = dec.Int64()
+}
+
 // Encoding/decoding implementations.
 
 func serviceweaver_enc_map_string_string_219dd46d(enc *codegen.Encoder, arg map[string]string) {
@@ -450,3 +926,26 @@ func serviceweaver_dec_map_string_string_219dd46d(dec *codegen.Decoder) map[stri
 	}
 	return res
 }
+
+func serviceweaver_enc_slice_SessionInfo_fc219f80(enc *codegen.Encoder, arg []SessionInfo) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	for i := 0; i < len(arg); i++ {
+		(arg[i]).WeaverMarshal(enc)
+	}
+}
+
+func serviceweaver_dec_slice_SessionInfo_fc219f80(dec *codegen.Decoder) []SessionInfo {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	res := make([]SessionInfo, n)
+	for i := 0; i < n; i++ {
+		(&res[i]).WeaverUnmarshal(dec)
+	}
+	return res
+}
@@ -19,19 +19,62 @@
 package settingsimpl
 
 import (
+	"context"
+	"log/slog"
+
+	cryptoclient "github.com/dvaumoron/puzzleweaver/client/crypto"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const settingsUniqueIndex = "settings_user"
+
+// ensured (or checked) at component start and by the indexes mode of the binary
+var Indexes = mongoclient.Indexes{Component: "settings", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(settingsUniqueIndex, userIdKey),
+}}
+
 type settingsConf struct {
 	MongoAddress      string
 	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	// "ensure" (default) or "check"
+	IndexMode string
+	// optional encryption of the settings values
+	Encryption cryptoclient.Conf
 }
 
 type initializedSettingsConf struct {
-	clientOptions *options.ClientOptions
+	client       *mongo.Client
+	certificates *tlsclient.Certificates
+	collection   *mongo.Collection
+	keyRing      *cryptoclient.KeyRing
 }
 
-func initSettingsConf(conf *settingsConf) initializedSettingsConf {
-	return initializedSettingsConf{clientOptions: mongoclient.New(conf.MongoAddress)}
+func initSettingsConf(ctx context.Context, logger *slog.Logger, conf *settingsConf) (initializedSettingsConf, error) {
+	keyRing, err := cryptoclient.Load(logger, conf.Encryption)
+	if err != nil {
+		return initializedSettingsConf{}, err
+	}
+
+	certificates, err := tlsclient.Load(logger, conf.MongoTLS)
+	if err != nil {
+		return initializedSettingsConf{}, err
+	}
+
+	client, err := mongoclient.Connect(ctx, logger, conf.MongoAddress, conf.MongoOptions, certificates)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedSettingsConf{}, err
+	}
+
+	database := client.Database(conf.MongoDatabaseName)
+	if err = Indexes.Apply(ctx, database, conf.IndexMode); err != nil {
+		mongoclient.Release(ctx, client)
+		tlsclient.Release(certificates)
+		return initializedSettingsConf{}, err
+	}
+	return initializedSettingsConf{client: client, certificates: certificates, collection: database.Collection(collectionName), keyRing: keyRing}, nil
 }
@@ -20,9 +20,11 @@ package settingsimpl
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/ServiceWeaver/weaver"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"go.mongodb.org/mongo-driver/bson"
@@ -48,23 +50,26 @@ type settingsImpl struct {
 	initializedConf initializedSettingsConf
 }
 
-func (impl *settingsImpl) Init(ctx context.Context) error {
-	impl.initializedConf = initSettingsConf(impl.Config())
-	return nil
+func (impl *settingsImpl) Init(ctx context.Context) (err error) {
+	impl.initializedConf, err = initSettingsConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
+	return
+}
+
+// releases the shared MongoDB client and its TLS certificates
+func (impl *settingsImpl) Shutdown(ctx context.Context) error {
+	err := mongoclient.Release(ctx, impl.initializedConf.client)
+	tlsclient.Release(impl.initializedConf.certificates)
+	return err
 }
 
 func (impl *settingsImpl) Get(ctx context.Context, id uint64) (map[string]string, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 	var result bson.D
-	err = collection.FindOne(
+	err := collection.FindOne(
 		ctx, bson.D{{Key: userIdKey, Value: id}}, optsOnlySettingsField,
 	).Decode(&result)
 	if err != nil {
@@ -77,20 +82,25 @@ func (impl *settingsImpl) Get(ctx context.Context, id uint64) (map[string]string
 	}
 
 	// call [1] to get picture because result has only the id and one field
-	return mongoclient.ExtractStringMap(result[1].Value), nil
+	info, err := impl.initializedConf.keyRing.DecryptMap(strconv.FormatUint(id, 10), mongoclient.ExtractStringMap(result[1].Value))
+	if err != nil {
+		logger.Error(servicecommon.EncryptionMsg, common.ErrorKey, err)
+		return nil, servicecommon.ErrInternal
+	}
+	return info, nil
 }
 
 func (impl *settingsImpl) Update(ctx context.Context, id uint64, info map[string]string) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
+	// the whole document is replaced, so every value is encrypted with the active key
+	encryptedInfo, err := impl.initializedConf.keyRing.EncryptMap(strconv.FormatUint(id, 10), info)
 	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
+		logger.Error(servicecommon.EncryptionMsg, common.ErrorKey, err)
 		return servicecommon.ErrInternal
 	}
-	defer mongoclient.Disconnect(client, ctx, logger)
 
-	settings := bson.M{userIdKey: id, settingsKey: info}
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	settings := bson.M{userIdKey: id, settingsKey: encryptedInfo}
+	collection := impl.initializedConf.collection
 	_, err = collection.ReplaceOne(
 		ctx, bson.D{{Key: userIdKey, Value: id}}, settings, optsCreateUnexisting,
 	)
@@ -20,7 +20,11 @@ package templatesimpl
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"log/slog"
 	"strings"
+	"sync/atomic"
 	"text/template"
 	"time"
 
@@ -37,14 +41,19 @@ type templateConf struct {
 	ViewsPath      string
 	LocaleFilePath string
 	DateFormat     string
+	// polling of locale files, disabled when zero
+	ReloadInterval time.Duration
 }
 
 type initializedTemplateConf struct {
 	templates part.PartRenderer
-	messages  map[string]map[string]string
+	messages  *atomic.Pointer[map[string]map[string]string]
+	stopWatch context.CancelFunc
 }
 
-func initTemplateConf(conf *templateConf) (initializedTemplateConf, error) {
+var errEmptyLocale = errors.New("locale file without message")
+
+func initTemplateConf(logger *slog.Logger, conf *templateConf) (initializedTemplateConf, error) {
 	fileSystem, err := fsclient.New(conf.FsConf)
 	if err != nil {
 		return initializedTemplateConf{}, err
@@ -55,11 +64,37 @@ func initTemplateConf(conf *templateConf) (initializedTemplateConf, error) {
 		return initializedTemplateConf{}, err
 	}
 
-	messages, err := loadLocales(fileSystem, conf)
+	loadedMessages, err := loadLocales(fileSystem, conf)
 	if err != nil {
 		return initializedTemplateConf{}, err
 	}
-	return initializedTemplateConf{templates: templates, messages: messages}, nil
+
+	messages := new(atomic.Pointer[map[string]map[string]string])
+	messages.Store(&loadedMessages)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	fsclient.Watch(watchCtx, logger, fileSystem, conf.ReloadInterval, localePaths(conf), func() error {
+		newMessages, err := loadLocales(fileSystem, conf)
+		if err != nil {
+			return err
+		}
+		// a file emptied during its edition should not erase the messages
+		for _, messagesLang := range newMessages {
+			if len(messagesLang) == 0 {
+				return errEmptyLocale
+			}
+		}
+		messages.Store(&newMessages)
+		return nil
+	})
+	return initializedTemplateConf{templates: templates, messages: messages, stopWatch: stopWatch}, nil
+}
+
+func localePaths(conf *templateConf) []string {
+	paths := make([]string, 0, len(conf.AllLang))
+	for _, lang := range conf.AllLang {
+		paths = append(paths, strings.ReplaceAll(conf.LocaleFilePath, servicecommon.LangPlaceHolder, lang))
+	}
+	return paths
 }
 
 func loadTemplates(fileSystem afero.Fs, conf *templateConf) (part.PartRenderer, error) {
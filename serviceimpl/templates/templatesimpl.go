@@ -35,10 +35,21 @@ type templateImpl struct {
 }
 
 func (impl *templateImpl) Init(ctx context.Context) (err error) {
-	impl.initializedConf, err = initTemplateConf(impl.Config())
+	impl.initializedConf, err = initTemplateConf(impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
 	return
 }
 
+// stops the polling of the watched files
+func (impl *templateImpl) Shutdown(ctx context.Context) error {
+	if stopWatch := impl.initializedConf.stopWatch; stopWatch != nil {
+		stopWatch()
+	}
+	return nil
+}
+
 func (impl *templateImpl) Render(ctx context.Context, templateName string, data []byte) ([]byte, error) {
 	logger := impl.Logger(ctx)
 
@@ -48,7 +59,7 @@ func (impl *templateImpl) Render(ctx context.Context, templateName string, data
 		logger.Error("Failed to parse JSON", common.ErrorKey, err)
 		return nil, servicecommon.ErrInternal
 	}
-	parsedData["Messages"] = impl.initializedConf.messages[asString(parsedData["lang"])]
+	parsedData["Messages"] = (*impl.initializedConf.messages.Load())[asString(parsedData["lang"])]
 	var content bytes.Buffer
 	if err = impl.initializedConf.templates.ExecuteTemplate(&content, templateName, parsedData); err != nil {
 		logger.Error("Failed to call go template", common.ErrorKey, err)
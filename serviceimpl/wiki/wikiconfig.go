@@ -19,19 +19,54 @@
 package wikiimpl
 
 import (
+	"context"
+	"log/slog"
+
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const pagesUniqueIndex = "pages_wiki_ref_version"
+
+// ensured (or checked) at component start and by the indexes mode of the binary,
+// the unique index also serves the search of the last version
+var Indexes = mongoclient.Indexes{Component: "wiki", Collection: collectionName, Models: []mongo.IndexModel{
+	mongoclient.UniqueIndex(pagesUniqueIndex, wikiIdKey, wikiRefKey, versionKey),
+}}
+
 type wikiConf struct {
 	MongoAddress      string
 	MongoDatabaseName string
+	MongoOptions      mongoclient.Options
+	MongoTLS          tlsclient.Conf
+	// "ensure" (default) or "check"
+	IndexMode string
 }
 
 type initializedWikiConf struct {
-	clientOptions *options.ClientOptions
+	client       *mongo.Client
+	certificates *tlsclient.Certificates
+	collection   *mongo.Collection
 }
 
-func initWikiConf(conf *wikiConf) initializedWikiConf {
-	return initializedWikiConf{clientOptions: mongoclient.New(conf.MongoAddress)}
+func initWikiConf(ctx context.Context, logger *slog.Logger, conf *wikiConf) (initializedWikiConf, error) {
+	certificates, err := tlsclient.Load(logger, conf.MongoTLS)
+	if err != nil {
+		return initializedWikiConf{}, err
+	}
+
+	client, err := mongoclient.Connect(ctx, logger, conf.MongoAddress, conf.MongoOptions, certificates)
+	if err != nil {
+		tlsclient.Release(certificates)
+		return initializedWikiConf{}, err
+	}
+
+	database := client.Database(conf.MongoDatabaseName)
+	if err = Indexes.Apply(ctx, database, conf.IndexMode); err != nil {
+		mongoclient.Release(ctx, client)
+		tlsclient.Release(certificates)
+		return initializedWikiConf{}, err
+	}
+	return initializedWikiConf{client: client, certificates: certificates, collection: database.Collection(collectionName)}, nil
 }
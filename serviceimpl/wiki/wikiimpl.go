@@ -23,6 +23,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	mongoclient "github.com/dvaumoron/puzzleweaver/client/mongo"
+	tlsclient "github.com/dvaumoron/puzzleweaver/client/tls"
 	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	"github.com/dvaumoron/puzzleweb/common"
 	"go.mongodb.org/mongo-driver/bson"
@@ -55,21 +56,24 @@ type remoteWikiImpl struct {
 	initializedConf initializedWikiConf
 }
 
-func (impl *remoteWikiImpl) Init(ctx context.Context) error {
-	impl.initializedConf = initWikiConf(impl.Config())
-	return nil
+func (impl *remoteWikiImpl) Init(ctx context.Context) (err error) {
+	impl.initializedConf, err = initWikiConf(ctx, impl.Logger(ctx), impl.Config())
+	if err == nil {
+		servicecommon.CallShutdownOnExit(impl.Logger(ctx), impl)
+	}
+	return
+}
+
+// releases the shared MongoDB client and its TLS certificates
+func (impl *remoteWikiImpl) Shutdown(ctx context.Context) error {
+	err := mongoclient.Release(ctx, impl.initializedConf.client)
+	tlsclient.Release(impl.initializedConf.certificates)
+	return err
 }
 
 func (impl *remoteWikiImpl) Load(ctx context.Context, wikiId uint64, wikiRef string, version uint64) (RawWikiContent, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return RawWikiContent{}, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
 	filters := bson.D{
 		{Key: wikiIdKey, Value: wikiId}, {Key: wikiRefKey, Value: wikiRef},
@@ -82,7 +86,7 @@ func (impl *remoteWikiImpl) Load(ctx context.Context, wikiId uint64, wikiRef str
 	}
 
 	var result bson.M
-	err = collection.FindOne(ctx, filters, opts).Decode(&result)
+	err := collection.FindOne(ctx, filters, opts).Decode(&result)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// an empty Content has Version 0, which is recognized by client
@@ -97,14 +101,7 @@ func (impl *remoteWikiImpl) Load(ctx context.Context, wikiId uint64, wikiRef str
 
 func (impl *remoteWikiImpl) Store(ctx context.Context, wikiId uint64, userId uint64, wikiRef string, last uint64, markdown string) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
 	// rely on the mongo server to ensure there will be no duplicate
 	page := bson.M{
@@ -112,7 +109,7 @@ func (impl *remoteWikiImpl) Store(ctx context.Context, wikiId uint64, userId uin
 		userIdKey: userId, textKey: markdown,
 	}
 
-	if _, err = collection.InsertOne(ctx, page); err != nil {
+	if _, err := collection.InsertOne(ctx, page); err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			return common.ErrBaseVersion
 		}
@@ -125,14 +122,7 @@ func (impl *remoteWikiImpl) Store(ctx context.Context, wikiId uint64, userId uin
 
 func (impl *remoteWikiImpl) GetVersions(ctx context.Context, wikiId uint64, wikiRef string) ([]RawWikiContent, error) {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return nil, servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
 	cursor, err := collection.Find(ctx, bson.D{
 		{Key: wikiIdKey, Value: wikiId}, {Key: wikiRefKey, Value: wikiRef},
@@ -152,16 +142,9 @@ func (impl *remoteWikiImpl) GetVersions(ctx context.Context, wikiId uint64, wiki
 
 func (impl *remoteWikiImpl) Delete(ctx context.Context, wikiId uint64, wikiRef string, version uint64) error {
 	logger := impl.Logger(ctx)
-	client, err := mongo.Connect(ctx, impl.initializedConf.clientOptions)
-	if err != nil {
-		logger.Error(servicecommon.MongoCallMsg, common.ErrorKey, err)
-		return servicecommon.ErrInternal
-	}
-	defer mongoclient.Disconnect(client, ctx, logger)
-
-	collection := client.Database(impl.Config().MongoDatabaseName).Collection(collectionName)
+	collection := impl.initializedConf.collection
 
-	_, err = collection.DeleteMany(ctx, bson.D{
+	_, err := collection.DeleteMany(ctx, bson.D{
 		{Key: wikiIdKey, Value: wikiId}, {Key: wikiRefKey, Value: wikiRef},
 		{Key: versionKey, Value: version},
 	})
@@ -0,0 +1,145 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package clientaddr
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// the http server stores the local address of the connection in the context of its requests,
+// the wrapped connections return one which also holds their remote address
+type addr struct {
+	net.Addr
+	remote net.Addr
+}
+
+type conn struct {
+	net.Conn
+}
+
+func (c conn) LocalAddr() net.Addr {
+	return addr{Addr: c.Conn.LocalAddr(), remote: c.Conn.RemoteAddr()}
+}
+
+type listener struct {
+	net.Listener
+}
+
+func (l listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return conn{Conn: c}, nil
+}
+
+// Listener makes the client IP readable by the FromContext of a Resolver in the requests served with the returned listener.
+func Listener(inner net.Listener) net.Listener {
+	return listener{Listener: inner}
+}
+
+// Resolver finds the IP of the client of a request, the X-Forwarded-For header is only read
+// when the connection comes from a trusted proxy.
+type Resolver struct {
+	trustedProxies []netip.Prefix
+}
+
+// NewResolver parses the trusted proxies, given as addresses or CIDR ranges.
+func NewResolver(trustedProxies []string) (Resolver, error) {
+	prefixes := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return Resolver{}, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return Resolver{}, err
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return Resolver{trustedProxies: prefixes}, nil
+}
+
+// FromRequest returns the IP of the client, the last address of X-Forwarded-For which is not a trusted proxy
+// (the ones before can be forged by the client), or an empty string when it can not be known.
+func (r Resolver) FromRequest(req *http.Request) string {
+	ip, ok := parseIp(req.RemoteAddr)
+	if !ok {
+		return ""
+	}
+
+	var hops []string
+	for _, header := range req.Header.Values(forwardedForHeader) {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for index := len(hops) - 1; index >= 0 && r.trusted(ip); index-- {
+		if ip, ok = parseIp(strings.TrimSpace(hops[index])); !ok {
+			return ""
+		}
+	}
+	return ip.String()
+}
+
+// FromContext returns the IP of the client connected to the server, or an empty string when it is a trusted proxy
+// (the headers are not readable here) or when the request was not served through Listener.
+func (r Resolver) FromContext(ctx context.Context) string {
+	localAddr, ok := ctx.Value(http.LocalAddrContextKey).(addr)
+	if !ok {
+		return ""
+	}
+
+	ip, ok := parseIp(localAddr.remote.String())
+	if !ok || r.trusted(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+func (r Resolver) trusted(ip netip.Addr) bool {
+	for _, prefix := range r.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts an address with or without port
+func parseIp(address string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap().WithZone(""), true
+}
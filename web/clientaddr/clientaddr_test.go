@@ -0,0 +1,71 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package clientaddr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.5:4321", want: "203.0.113.5"},
+		{name: "direct ignores the header", remoteAddr: "203.0.113.5:4321", forwardedFor: []string{"198.51.100.7"}, want: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:4321", forwardedFor: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "forged first hop", remoteAddr: "10.1.2.3:4321", forwardedFor: []string{"1.2.3.4, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "proxy chain", remoteAddr: "192.0.2.1:4321", forwardedFor: []string{"198.51.100.7, 10.0.0.1"}, want: "198.51.100.7"},
+		{name: "several headers", remoteAddr: "10.1.2.3:4321", forwardedFor: []string{"198.51.100.7", "10.0.0.1"}, want: "198.51.100.7"},
+		{name: "only proxies", remoteAddr: "10.1.2.3:4321", forwardedFor: []string{"10.0.0.2, 10.0.0.1"}, want: "10.0.0.2"},
+		{name: "proxy without header", remoteAddr: "10.1.2.3:4321", want: "10.1.2.3"},
+		{name: "invalid hop", remoteAddr: "10.1.2.3:4321", forwardedFor: []string{"198.51.100.7, unknown"}, want: ""},
+		{name: "ipv6 proxy", remoteAddr: "[2001:db8::1]:4321", forwardedFor: []string{"2001:db9::7"}, want: "2001:db9::7"},
+		{name: "mapped ipv4", remoteAddr: "[::ffff:10.1.2.3]:4321", forwardedFor: []string{"198.51.100.7"}, want: "198.51.100.7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			for _, header := range test.forwardedFor {
+				req.Header.Add(forwardedForHeader, header)
+			}
+
+			if got := resolver.FromRequest(req); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	for _, proxy := range []string{"10.0.0.0/33", "proxy.example.com", ""} {
+		if _, err := NewResolver([]string{proxy}); err == nil {
+			t.Errorf("no error for %q", proxy)
+		}
+	}
+}
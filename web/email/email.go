@@ -0,0 +1,146 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package email
+
+import (
+	"net/url"
+
+	loginimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/login"
+	sessionimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/session"
+	"github.com/dvaumoron/puzzleweaver/web/clientaddr"
+	"github.com/dvaumoron/puzzleweaver/web/loginclient"
+	"github.com/dvaumoron/puzzleweb/common"
+	puzzleweb "github.com/dvaumoron/puzzleweb/core"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	pageName     = "email"
+	pageUrl      = "/" + pageName
+	templateName = "email"
+
+	emailName    = "Email"
+	passwordName = "Password"
+	tokenName    = "token"
+	verifiedName = "Verified"
+	// the token of the link is posted back by the confirmation form
+	tokenFormName = "Token"
+
+	stepName          = "EmailStep"
+	emailVerifiedName = "EmailVerified"
+
+	confirmStep  = "confirm"
+	settingsStep = "settings"
+	verifiedStep = "verified"
+	visitorStep  = "visitor"
+)
+
+type emailWidget struct {
+	displayHandler gin.HandlerFunc
+	changeHandler  gin.HandlerFunc
+	resendHandler  gin.HandlerFunc
+	confirmHandler gin.HandlerFunc
+	verifyHandler  gin.HandlerFunc
+}
+
+func (w emailWidget) LoadInto(router gin.IRouter) {
+	router.GET("/", w.displayHandler)
+	router.POST("/change", w.changeHandler)
+	router.POST("/resend", w.resendHandler)
+	// opening the link does not change anything (mail scanners follow the links)
+	router.GET("/verify", w.confirmHandler)
+	router.POST("/verify", w.verifyHandler)
+}
+
+// MakePage returns the hidden page where the connected users manage their email (the "email" template
+// receives the current step in EmailStep), the links of verification lead to its verify route,
+// where a form posts the token back.
+func MakePage(loginService loginclient.EmailLoginService, emailService loginimpl.RemoteLoginService, clientAddr clientaddr.Resolver) puzzleweb.Page {
+	p := puzzleweb.MakeHiddenPage(pageName)
+	p.Widget = emailWidget{
+		displayHandler: puzzleweb.CreateTemplate(func(data gin.H, c *gin.Context) (string, string) {
+			// the link can be opened without being connected
+			if c.Query(verifiedName) == "true" {
+				data[stepName] = verifiedStep
+				return templateName, ""
+			}
+
+			userId, _ := data[common.UserIdName].(uint64)
+			if userId == 0 {
+				// only the error message to display
+				data[stepName] = visitorStep
+				return templateName, ""
+			}
+
+			email, verified, err := emailService.GetEmail(c.Request.Context(), userId)
+			if err != nil {
+				return "", common.DefaultErrorRedirect(puzzleweb.GetLogger(c), err.Error())
+			}
+
+			data[stepName] = settingsStep
+			data[emailName] = email
+			data[emailVerifiedName] = verified
+			return templateName, ""
+		}),
+		changeHandler: common.CreateRedirect(func(c *gin.Context) string {
+			userId := puzzleweb.GetSessionUserId(c)
+			if userId == 0 {
+				return errorUrl(common.ErrorNotAuthorizedKey)
+			}
+
+			password := c.PostForm(passwordName)
+			if password == "" {
+				return errorUrl(common.ErrorEmptyPasswordKey)
+			}
+
+			login := puzzleweb.GetSession(c).Load(sessionimpl.LoginName)
+			err := loginService.ChangeEmail(c.Request.Context(), userId, login, c.PostForm(emailName), password, clientAddr.FromRequest(c.Request))
+			if err != nil {
+				return errorUrl(err.Error())
+			}
+			return pageUrl
+		}),
+		resendHandler: common.CreateRedirect(func(c *gin.Context) string {
+			userId := puzzleweb.GetSessionUserId(c)
+			if userId == 0 {
+				return errorUrl(common.ErrorNotAuthorizedKey)
+			}
+
+			if err := emailService.SendEmailVerification(c.Request.Context(), userId); err != nil {
+				return errorUrl(err.Error())
+			}
+			return pageUrl
+		}),
+		confirmHandler: puzzleweb.CreateTemplate(func(data gin.H, c *gin.Context) (string, string) {
+			data[stepName] = confirmStep
+			data[tokenFormName] = c.Query(tokenName)
+			return templateName, ""
+		}),
+		verifyHandler: common.CreateRedirect(func(c *gin.Context) string {
+			if _, err := emailService.VerifyEmail(c.Request.Context(), c.PostForm(tokenFormName)); err != nil {
+				return errorUrl(err.Error())
+			}
+			return pageUrl + "?" + verifiedName + "=true"
+		}),
+	}
+	return p
+}
+
+func errorUrl(errorMsg string) string {
+	return pageUrl + common.QueryError + url.QueryEscape(errorMsg)
+}
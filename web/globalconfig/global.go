@@ -43,10 +43,12 @@ import (
 	wikiimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/wiki"
 	"github.com/dvaumoron/puzzleweaver/web/adminclient"
 	blogclient "github.com/dvaumoron/puzzleweaver/web/blogclient"
+	"github.com/dvaumoron/puzzleweaver/web/clientaddr"
 	"github.com/dvaumoron/puzzleweaver/web/customwidgetclient"
 	forumclient "github.com/dvaumoron/puzzleweaver/web/forumclient"
 	"github.com/dvaumoron/puzzleweaver/web/loginclient"
 	"github.com/dvaumoron/puzzleweaver/web/profileclient"
+	"github.com/dvaumoron/puzzleweaver/web/sessionclient"
 	"github.com/dvaumoron/puzzleweaver/web/templateclient"
 	wikiclient "github.com/dvaumoron/puzzleweaver/web/wikiclient"
 	adminservice "github.com/dvaumoron/puzzleweb/admin/service"
@@ -148,6 +150,11 @@ type ParsedConfig struct {
 	ProfileGroupId            uint64
 	ProfileDefaultPicturePath string
 
+	PasswordHash loginclient.HashConf
+	TotpGroupIds []uint64
+	// addresses or CIDR ranges of the proxies allowed to give the client IP in X-Forwarded-For
+	TrustedProxies []string
+
 	Locales     []parser.LocaleConfig
 	StaticPages []parser.StaticPagesConfig
 	Widgets     map[string]parser.WidgetConfig
@@ -165,10 +172,14 @@ type GlobalConfig struct {
 	LangPicturePaths        map[string]string
 	StaticFileSystem        http.FileSystem
 	SessionService          sessionservice.SessionService
+	SessionClient           sessionclient.SessionService
+	ClientAddr              clientaddr.Resolver
 	TemplateService         templateservice.TemplateService
 	SettingsService         sessionservice.SessionService
 	PasswordStrengthService passwordstrengthimpl.PasswordStrengthService
 	LoginService            loginservice.FullLoginService
+	LoginClient             loginclient.EmailLoginService
+	LoginImpl               loginimpl.RemoteLoginService
 	AdminService            adminservice.AdminService
 	ProfileService          profileservice.AdvancedProfileService
 	ForumImpl               forumimpl.RemoteForumService
@@ -203,9 +214,19 @@ func New(conf *ParsedConfig, loggerGetter servicecommon.LoggerGetter, logger *sl
 
 	wrappedLoggerGetter := loggerGetterWrapper{inner: loggerGetter}
 
-	loginServiceWrapper := loginclient.MakeLoginServiceWrapper(
-		loginService, saltService, passwordStrengthService, conf.DateFormat,
+	clientAddr, err := clientaddr.NewResolver(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionServiceWrapper := sessionclient.MakeSessionServiceWrapper(sessionService)
+	loginServiceWrapper, err := loginclient.MakeLoginServiceWrapper(
+		loginService, saltService, passwordStrengthService, sessionServiceWrapper, clientAddr, adminService, wrappedLoggerGetter,
+		conf.PasswordHash, conf.TotpGroupIds, conf.DateFormat,
 	)
+	if err != nil {
+		return nil, err
+	}
 	profileServiceWrapper := profileclient.MakeProfileServiceWrapper(
 		profileService, loginServiceWrapper, adminService, wrappedLoggerGetter, conf.ProfileGroupId, defaultPicture,
 	)
@@ -218,11 +239,15 @@ func New(conf *ParsedConfig, loggerGetter servicecommon.LoggerGetter, logger *sl
 		AllLang:                 allLang,
 		LangPicturePaths:        langPicturePaths,
 		StaticFileSystem:        afero.NewHttpFs(afero.NewBasePathFs(baseFS, conf.StaticPath)),
-		SessionService:          sessionService,
+		SessionService:          sessionServiceWrapper,
+		SessionClient:           sessionServiceWrapper,
+		ClientAddr:              clientAddr,
 		TemplateService:         templateclient.MakeTemplateServiceWrapper(templateService, wrappedLoggerGetter),
 		SettingsService:         settingsServiceWrapper{SettingsService: settingsService},
 		PasswordStrengthService: passwordStrengthService,
 		LoginService:            loginServiceWrapper,
+		LoginClient:             loginServiceWrapper,
+		LoginImpl:               loginService,
 		AdminService:            adminclient.MakeAdminServiceWrapper(adminService),
 		ProfileService:          profileServiceWrapper,
 		ForumImpl:               forumService,
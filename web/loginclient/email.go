@@ -0,0 +1,37 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginclient
+
+import "context"
+
+// EmailLoginService adds the email addresses of the users, an email can replace the login in VerifyPassword.
+type EmailLoginService interface {
+	TwoFactorLoginService
+	// email is optional
+	RegisterWithEmail(ctx context.Context, login string, email string, password string) (uint64, error)
+	// an empty email removes the address
+	ChangeEmail(ctx context.Context, userId uint64, login string, email string, password string, clientIp string) error
+}
+
+func (client loginServiceWrapper) ChangeEmail(ctx context.Context, userId uint64, login string, email string, password string, clientIp string) error {
+	salted, _, err := client.loadSalted(ctx, login, password, clientIp)
+	if err != nil {
+		return err
+	}
+	return client.loginService.ChangeEmail(ctx, userId, email, salted, clientIp)
+}
@@ -0,0 +1,184 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptName      = "scrypt"
+	argon2idName    = "argon2id"
+	argon2idVersion = "v=19"
+
+	keyLen = 64
+
+	// cost of the hashes stored before the parameters
+	legacyN = 1 << 16
+	legacyR = 8
+	legacyP = 1
+
+	defaultArgon2Memory  = 64 * 1024
+	defaultArgon2Time    = 3
+	defaultArgon2Threads = 4
+	// limits of the costs read from the stored hashes (1 GiB)
+	maxArgon2Memory = 1024 * 1024
+	maxArgon2Time   = 64
+)
+
+var (
+	errInvalidHashConf = errors.New("invalid password hash configuration")
+	errUnknownHash     = errors.New("unknown password hash parameters")
+)
+
+// HashConf selects the algorithm and the cost of the new password hashes,
+// a stored hash is upgraded at the next successful login of its user.
+type HashConf struct {
+	// "scrypt" (default) or "argon2id"
+	Algorithm string
+	// scrypt cost (a power of two, default 1 << 16), block size (default 8) and parallelization (default 1)
+	ScryptN int
+	ScryptR int
+	ScryptP int
+	// argon2id memory in KiB (default 64 * 1024, at most 1024 * 1024), iterations (default 3, at most 64)
+	// and threads (default 4)
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+type hasher struct {
+	// prefix of the hashes, empty for the legacy format
+	params string
+	key    func(password []byte, salt []byte) ([]byte, error)
+}
+
+func (h hasher) hash(password string, salt []byte) (string, error) {
+	key, err := h.key([]byte(password), salt)
+	if err != nil {
+		return "", err
+	}
+	return h.params + base64.StdEncoding.EncodeToString(key), nil
+}
+
+func newHasher(conf HashConf) (hasher, error) {
+	var params string
+	switch conf.Algorithm {
+	case "", scryptName:
+		n, r, p := withDefault(conf.ScryptN, legacyN), withDefault(conf.ScryptR, legacyR), withDefault(conf.ScryptP, legacyP)
+		logN := bits.Len(uint(n)) - 1
+		if n < 2 || n != 1<<logN {
+			return hasher{}, errInvalidHashConf
+		}
+		params = fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$", scryptName, logN, r, p)
+	case argon2idName:
+		params = fmt.Sprintf(
+			"$%s$%s$m=%d,t=%d,p=%d$", argon2idName, argon2idVersion, withDefault(conf.Argon2Memory, defaultArgon2Memory),
+			withDefault(conf.Argon2Time, defaultArgon2Time), withDefault(conf.Argon2Threads, defaultArgon2Threads),
+		)
+	default:
+		return hasher{}, errInvalidHashConf
+	}
+
+	h, err := parseHasher(params)
+	if err != nil {
+		return hasher{}, errInvalidHashConf
+	}
+	return h, nil
+}
+
+// parseHasher read the parameters returned by GetHashParams
+func parseHasher(params string) (hasher, error) {
+	if params == "" {
+		return makeScryptHasher("", legacyN, legacyR, legacyP), nil
+	}
+
+	// like "$scrypt$ln=16,r=8,p=1$" or "$argon2id$v=19$m=65536,t=3,p=4$"
+	parts := strings.Split(params, "$")
+	switch {
+	case len(parts) == 4 && parts[0] == "" && parts[1] == scryptName && parts[3] == "":
+		values, ok := parseValues(parts[2], "ln", "r", "p")
+		if !ok || values[0] > 31 {
+			return hasher{}, errUnknownHash
+		}
+		return makeScryptHasher(params, 1<<values[0], int(values[1]), int(values[2])), nil
+	case len(parts) == 5 && parts[0] == "" && parts[1] == argon2idName && parts[2] == argon2idVersion && parts[4] == "":
+		values, ok := parseValues(parts[3], "m", "t", "p")
+		if !ok || values[0] > maxArgon2Memory || values[1] > maxArgon2Time || values[2] > 255 {
+			return hasher{}, errUnknownHash
+		}
+		return makeArgon2idHasher(params, uint32(values[0]), uint32(values[1]), uint8(values[2])), nil
+	}
+	return hasher{}, errUnknownHash
+}
+
+func makeScryptHasher(params string, n int, r int, p int) hasher {
+	return hasher{params: params, key: func(password []byte, salt []byte) ([]byte, error) {
+		return scrypt.Key(password, salt, n, r, p, keyLen)
+	}}
+}
+
+func makeArgon2idHasher(params string, memory uint32, time uint32, threads uint8) hasher {
+	return hasher{params: params, key: func(password []byte, salt []byte) ([]byte, error) {
+		return argon2.IDKey(password, salt, time, memory, threads, keyLen), nil
+	}}
+}
+
+// parse "name1=value1,name2=value2" with the names in the given order, the values must be positive
+func parseValues(list string, names ...string) ([]uint64, bool) {
+	fields := strings.Split(list, ",")
+	if len(fields) != len(names) {
+		return nil, false
+	}
+
+	values := make([]uint64, 0, len(names))
+	for index, field := range fields {
+		rawValue, ok := strings.CutPrefix(field, names[index]+"=")
+		if !ok {
+			return nil, false
+		}
+
+		value, err := strconv.ParseUint(rawValue, 10, 32)
+		if err != nil || value == 0 {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// the parameters of a hash built by a hasher (everything up to the last '$')
+func hashParams(hash string) string {
+	return hash[:strings.LastIndexByte(hash, '$')+1]
+}
+
+func withDefault[T comparable](value T, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+	return value
+}
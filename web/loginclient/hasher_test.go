@@ -0,0 +1,185 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginclient
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestParseHasher(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{name: "legacy", params: ""},
+		{name: "scrypt", params: "$scrypt$ln=16,r=8,p=1$"},
+		{name: "scrypt max cost", params: "$scrypt$ln=31,r=8,p=1$"},
+		{name: "scrypt cost too high", params: "$scrypt$ln=32,r=8,p=1$", wantErr: true},
+		{name: "scrypt zero value", params: "$scrypt$ln=16,r=0,p=1$", wantErr: true},
+		{name: "scrypt negative value", params: "$scrypt$ln=16,r=-8,p=1$", wantErr: true},
+		{name: "scrypt swapped names", params: "$scrypt$r=8,ln=16,p=1$", wantErr: true},
+		{name: "scrypt missing value", params: "$scrypt$ln=16,r=8$", wantErr: true},
+		{name: "scrypt extra value", params: "$scrypt$ln=16,r=8,p=1,x=1$", wantErr: true},
+		{name: "scrypt without last separator", params: "$scrypt$ln=16,r=8,p=1", wantErr: true},
+		{name: "argon2id", params: "$argon2id$v=19$m=65536,t=3,p=4$"},
+		{name: "argon2id max costs", params: "$argon2id$v=19$m=1048576,t=64,p=255$"},
+		{name: "argon2id memory too high", params: "$argon2id$v=19$m=1048577,t=3,p=4$", wantErr: true},
+		{name: "argon2id time too high", params: "$argon2id$v=19$m=65536,t=65,p=4$", wantErr: true},
+		{name: "argon2id threads too high", params: "$argon2id$v=19$m=65536,t=3,p=256$", wantErr: true},
+		{name: "argon2id value beyond 32 bits", params: "$argon2id$v=19$m=4294967296,t=3,p=4$", wantErr: true},
+		{name: "argon2id other version", params: "$argon2id$v=16$m=65536,t=3,p=4$", wantErr: true},
+		{name: "argon2id without version", params: "$argon2id$m=65536,t=3,p=4$", wantErr: true},
+		{name: "unknown algorithm", params: "$bcrypt$cost=10$", wantErr: true},
+		{name: "garbage", params: "$$$", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := parseHasher(test.params)
+			if test.wantErr {
+				if err != errUnknownHash {
+					t.Errorf("got error %v, want %v", err, errUnknownHash)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.params != test.params {
+				t.Errorf("got parameters %q, want %q", h.params, test.params)
+			}
+		})
+	}
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       HashConf
+		wantParams string
+		wantErr    bool
+	}{
+		{name: "default", conf: HashConf{}, wantParams: "$scrypt$ln=16,r=8,p=1$"},
+		{name: "scrypt", conf: HashConf{Algorithm: scryptName, ScryptN: 1 << 15, ScryptR: 16, ScryptP: 2}, wantParams: "$scrypt$ln=15,r=16,p=2$"},
+		{name: "scrypt minimal cost", conf: HashConf{ScryptN: 2}, wantParams: "$scrypt$ln=1,r=8,p=1$"},
+		{name: "scrypt cost of one", conf: HashConf{ScryptN: 1}, wantErr: true},
+		{name: "scrypt cost not a power of two", conf: HashConf{ScryptN: 1000}, wantErr: true},
+		{name: "argon2id default", conf: HashConf{Algorithm: argon2idName}, wantParams: "$argon2id$v=19$m=65536,t=3,p=4$"},
+		{name: "argon2id", conf: HashConf{Algorithm: argon2idName, Argon2Memory: 32768, Argon2Time: 2, Argon2Threads: 1}, wantParams: "$argon2id$v=19$m=32768,t=2,p=1$"},
+		{name: "argon2id max memory", conf: HashConf{Algorithm: argon2idName, Argon2Memory: maxArgon2Memory}, wantParams: "$argon2id$v=19$m=1048576,t=3,p=4$"},
+		{name: "argon2id memory too high", conf: HashConf{Algorithm: argon2idName, Argon2Memory: maxArgon2Memory + 1}, wantErr: true},
+		{name: "argon2id time too high", conf: HashConf{Algorithm: argon2idName, Argon2Time: maxArgon2Time + 1}, wantErr: true},
+		{name: "unknown algorithm", conf: HashConf{Algorithm: "bcrypt"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := newHasher(test.conf)
+			if test.wantErr {
+				if err != errInvalidHashConf {
+					t.Errorf("got error %v, want %v", err, errInvalidHashConf)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.params != test.wantParams {
+				t.Errorf("got parameters %q, want %q", h.params, test.wantParams)
+			}
+		})
+	}
+}
+
+// a stored hash gives back its hasher, so the password is hashed the same way after a change of configuration
+func TestHasherRoundTrip(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	tests := []struct {
+		name string
+		conf HashConf
+	}{
+		{name: "scrypt", conf: HashConf{ScryptN: 16, ScryptR: 2, ScryptP: 1}},
+		{name: "argon2id", conf: HashConf{Algorithm: argon2idName, Argon2Memory: 8, Argon2Time: 1, Argon2Threads: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := newHasher(test.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hash, err := h.hash("password", salt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			params := hashParams(hash)
+			if params != h.params {
+				t.Errorf("got parameters %q, want %q", params, h.params)
+			}
+
+			parsed, err := parseHasher(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again, _ := parsed.hash("password", salt); again != hash {
+				t.Error("the parsed hasher gives another hash")
+			}
+			if other, _ := parsed.hash("password2", salt); other == hash {
+				t.Error("two passwords with the same hash")
+			}
+			if other, _ := parsed.hash("password", []byte("fedcba9876543210")); other == hash {
+				t.Error("two salts with the same hash")
+			}
+		})
+	}
+}
+
+// the hashes stored before the parameters stay readable, and are upgraded since their parameters differ
+func TestLegacyHasher(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	legacy, err := parseHasher("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := legacy.hash("password", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := scrypt.Key([]byte("password"), salt, legacyN, legacyR, legacyP, keyLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != base64.StdEncoding.EncodeToString(key) {
+		t.Error("the legacy format changed")
+	}
+	if strings.Contains(hash, "$") || hashParams(hash) != "" {
+		t.Errorf("unexpected parameters in %q", hash)
+	}
+
+	current, err := newHasher(HashConf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashParams(hash) == current.params {
+		t.Error("a legacy hash would not be upgraded")
+	}
+}
@@ -20,52 +20,99 @@ package loginclient
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"time"
 
+	adminimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/admin"
+	servicecommon "github.com/dvaumoron/puzzleweaver/serviceimpl/common"
 	loginimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/login"
 	passwordstrengthimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/passwordstrength"
 	saltimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/salt"
+	"github.com/dvaumoron/puzzleweaver/web/clientaddr"
+	"github.com/dvaumoron/puzzleweaver/web/sessionclient"
+	"github.com/dvaumoron/puzzleweb/common/log"
 	loginservice "github.com/dvaumoron/puzzleweb/login/service"
-	"golang.org/x/crypto/scrypt"
-)
-
-// those values are not configurable because a change imply a migration of user database.
-const (
-	n      = 1 << 16
-	r      = 8
-	p      = 1
-	keyLen = 64
+	"go.uber.org/zap"
 )
 
 var errNotEnoughValues = errors.New("not enough return values from saltService call")
 
+// used when the login has no salt, to spend the same time as with a known login
+var dummySalt = make([]byte, 16)
+
 type loginServiceWrapper struct {
 	loginService    loginimpl.RemoteLoginService
 	saltService     saltimpl.SaltService
 	strengthService passwordstrengthimpl.PasswordStrengthService
+	sessionService  sessionclient.SessionService
+	clientAddr      clientaddr.Resolver
+	authService     adminimpl.AuthService
+	loggerGetter    log.LoggerGetter
+	hasher          hasher
+	totpGroupIds    []uint64
 	dateFormat      string
 }
 
-func MakeLoginServiceWrapper(loginService loginimpl.RemoteLoginService, saltService saltimpl.SaltService, strengthService passwordstrengthimpl.PasswordStrengthService, dateFormat string) loginservice.FullLoginService {
-	return loginServiceWrapper{
-		loginService: loginService, saltService: saltService, strengthService: strengthService, dateFormat: dateFormat,
+func MakeLoginServiceWrapper(loginService loginimpl.RemoteLoginService, saltService saltimpl.SaltService, strengthService passwordstrengthimpl.PasswordStrengthService, sessionService sessionclient.SessionService, clientAddr clientaddr.Resolver, authService adminimpl.AuthService, loggerGetter log.LoggerGetter, hashConf HashConf, totpGroupIds []uint64, dateFormat string) (EmailLoginService, error) {
+	hasher, err := newHasher(hashConf)
+	if err != nil {
+		return nil, err
 	}
+
+	return loginServiceWrapper{
+		loginService: loginService, saltService: saltService, strengthService: strengthService,
+		sessionService: sessionService, clientAddr: clientAddr, authService: authService, loggerGetter: loggerGetter, hasher: hasher,
+		totpGroupIds: totpGroupIds, dateFormat: dateFormat,
+	}, nil
 }
 
+// the login page of puzzleweb can not send the cookie of a renewed session,
+// the logins go through the twofactor page (which has the same form)
 func (client loginServiceWrapper) Verify(ctx context.Context, login string, password string) (uint64, error) {
-	salteds, err := client.salt(ctx, [2]string{login, password})
+	return 0, servicecommon.ErrLoginPageRequired
+}
+
+func (client loginServiceWrapper) VerifyPassword(ctx context.Context, login string, password string, clientIp string) (uint64, string, SecondFactor, error) {
+	// the user can give a verified email instead of the login
+	login, err := client.loginService.ResolveLogin(ctx, login)
 	if err != nil {
-		return 0, err
+		return 0, "", NoSecondFactor, err
 	}
-	if len(salteds) == 0 {
-		return 0, errNotEnoughValues
+
+	salted, salt, err := client.loadSalted(ctx, login, password, clientIp)
+	if err != nil {
+		return 0, "", NoSecondFactor, err
 	}
-	return client.loginService.Verify(ctx, login, salteds[0])
+
+	userId, err := client.loginService.Verify(ctx, login, salted, clientIp)
+	if err != nil {
+		return 0, "", NoSecondFactor, err
+	}
+
+	// upgrade to the current algorithm and cost, a failure does not prevent the connection
+	if hashParams(salted) != client.hasher.params {
+		newSalted, err := client.hasher.hash(password, salt)
+		if err == nil {
+			err = client.loginService.ChangePassword(ctx, userId, salted, newSalted)
+		}
+		if err != nil {
+			client.loggerGetter.Logger(ctx).Warn("Failed to upgrade the password hash", zap.Error(err))
+		}
+	}
+
+	factor, err := client.secondFactor(ctx, userId)
+	if err != nil {
+		return 0, "", NoSecondFactor, err
+	}
+	return userId, login, factor, nil
 }
 
+// like Verify, the registrations go through the twofactor page
 func (client loginServiceWrapper) Register(ctx context.Context, login string, password string) (uint64, error) {
+	return 0, servicecommon.ErrLoginPageRequired
+}
+
+func (client loginServiceWrapper) RegisterWithEmail(ctx context.Context, login string, email string, password string) (uint64, error) {
 	err := client.strengthService.Validate(ctx, password)
 	if err != nil {
 		return 0, err
@@ -78,7 +125,7 @@ func (client loginServiceWrapper) Register(ctx context.Context, login string, pa
 	if len(salteds) == 0 {
 		return 0, errNotEnoughValues
 	}
-	return client.loginService.Register(ctx, login, salteds[0])
+	return client.loginService.Register(ctx, login, email, salteds[0])
 }
 
 // You should remove duplicate id in list
@@ -101,14 +148,28 @@ func (client loginServiceWrapper) ChangeLogin(ctx context.Context, userId uint64
 		return nil
 	}
 
-	salteds, err := client.salt(ctx, [2]string{oldLogin, password}, [2]string{newLogin, password})
+	// called by the profile page of puzzleweb, without the headers of the request
+	oldSalted, _, err := client.loadSalted(ctx, oldLogin, password, client.clientAddr.FromContext(ctx))
+	if err != nil {
+		return err
+	}
+	// the new login gets its own salt, the old one is removed once the change is done
+	// (a salt generated for a failed change is left to the sweep)
+	newSalteds, err := client.salt(ctx, [2]string{newLogin, password})
 	if err != nil {
 		return err
 	}
-	if len(salteds) < 2 {
+	if len(newSalteds) == 0 {
 		return errNotEnoughValues
 	}
-	return client.loginService.ChangeLogin(ctx, userId, newLogin, salteds[0], salteds[1])
+	if err = client.loginService.ChangeLogin(ctx, userId, newLogin, oldSalted, newSalteds[0]); err != nil {
+		return err
+	}
+	if err = client.saltService.Delete(ctx, oldLogin); err != nil {
+		return err
+	}
+	// the current session receives the new login
+	return client.sessionService.RevokeOtherSessions(ctx, userId)
 }
 
 func (client loginServiceWrapper) ChangePassword(ctx context.Context, userId uint64, login string, oldPassword string, newPassword string) error {
@@ -122,19 +183,25 @@ func (client loginServiceWrapper) ChangePassword(ctx context.Context, userId uin
 		return err
 	}
 
-	salteds, err := client.salt(ctx, [2]string{login, oldPassword}, [2]string{login, newPassword})
+	oldSalted, salt, err := client.loadSalted(ctx, login, oldPassword, client.clientAddr.FromContext(ctx))
 	if err != nil {
 		return err
 	}
-	if len(salteds) < 2 {
-		return errNotEnoughValues
+	// the new password gets the current algorithm and cost
+	newSalted, err := client.hasher.hash(newPassword, salt)
+	if err != nil {
+		return err
 	}
 
 	// avoid useless call (unlikely since oldPassword != newPassword)
-	if salteds[0] == salteds[1] {
+	if oldSalted == newSalted {
 		return nil
 	}
-	return client.loginService.ChangePassword(ctx, userId, salteds[0], salteds[1])
+	if err = client.loginService.ChangePassword(ctx, userId, oldSalted, newSalted); err != nil {
+		return err
+	}
+	// the sessions opened with the old credentials are closed, except the current one
+	return client.sessionService.RevokeOtherSessions(ctx, userId)
 }
 
 func (client loginServiceWrapper) ListUsers(ctx context.Context, start uint64, end uint64, filter string) (uint64, []loginservice.User, error) {
@@ -152,7 +219,20 @@ func (client loginServiceWrapper) ListUsers(ctx context.Context, start uint64, e
 
 // no right check
 func (client loginServiceWrapper) Delete(ctx context.Context, userId uint64) error {
-	return client.loginService.Delete(ctx, userId)
+	// the login is needed to remove the salt
+	users, err := client.loginService.GetUsers(ctx, []uint64{userId})
+	if err != nil {
+		return err
+	}
+	if err = client.loginService.Delete(ctx, userId); err != nil {
+		return err
+	}
+	if user, ok := users[userId]; ok {
+		if err = client.saltService.Delete(ctx, user.Login); err != nil {
+			return err
+		}
+	}
+	return client.sessionService.RevokeUserSessions(ctx, userId)
 }
 
 func (client loginServiceWrapper) salt(ctx context.Context, loginPasswords ...[2]string) ([]string, error) {
@@ -169,15 +249,52 @@ func (client loginServiceWrapper) salt(ctx context.Context, loginPasswords ...[2
 
 	salteds := make([]string, 0, size)
 	for index, salt := range salts {
-		dk, err := scrypt.Key([]byte(loginPasswords[index][1]), salt, n, r, p, keyLen)
+		salted, err := client.hasher.hash(loginPasswords[index][1], salt)
 		if err != nil {
 			return nil, err
 		}
-		salteds = append(salteds, base64.StdEncoding.EncodeToString(dk))
+		salteds = append(salteds, salted)
 	}
 	return salteds, nil
 }
 
+// never generate a salt, the password is hashed with the salt of login and the parameters
+// of its stored hash (the salt is returned too). An unknown login gets the same requests and a hash
+// with a dummy salt, which is refused by the login component (counting the failure).
+func (client loginServiceWrapper) loadSalted(ctx context.Context, login string, password string, clientIp string) (string, []byte, error) {
+	salts, err := client.saltService.Load(ctx, login)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(salts) == 0 {
+		return "", nil, errNotEnoughValues
+	}
+
+	// a locked login or client IP is refused before the hash
+	params, err := client.loginService.GetHashParams(ctx, login, client.hasher.params, clientIp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the parameters of an unknown login are the current ones
+	hasher, err := parseHasher(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	salt := salts[0]
+	if len(salt) == 0 {
+		salted, err := hasher.hash(password, dummySalt)
+		return salted, dummySalt, err
+	}
+
+	salted, err := hasher.hash(password, salt)
+	if err != nil {
+		return "", nil, err
+	}
+	return salted, salt, nil
+}
+
 func convertUser(user loginimpl.RawUser, dateFormat string) loginservice.User {
 	registredAt := time.Unix(user.RegistredAt, 0)
 	return loginservice.User{Id: user.Id, Login: user.Login, RegistredAt: registredAt.Format(dateFormat)}
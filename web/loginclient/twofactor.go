@@ -0,0 +1,66 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package loginclient
+
+import (
+	"context"
+
+	adminimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/admin"
+	"github.com/dvaumoron/puzzleweb/common"
+	loginservice "github.com/dvaumoron/puzzleweb/login/service"
+)
+
+type SecondFactor int
+
+const (
+	NoSecondFactor SecondFactor = iota
+	// the user has to give a TOTP code or a recovery code
+	TotpCode
+	// the user belongs to a group requiring a second factor and has to enable one before the login
+	TotpEnrolment
+)
+
+// TwoFactorLoginService adds the first step of the login with a second factor.
+type TwoFactorLoginService interface {
+	loginservice.FullLoginService
+	// check the password and return the login (login can be a verified email),
+	// the user is not logged in when a second factor is still needed, clientIp is empty when unknown
+	VerifyPassword(ctx context.Context, login string, password string, clientIp string) (uint64, string, SecondFactor, error)
+}
+
+func (client loginServiceWrapper) secondFactor(ctx context.Context, userId uint64) (SecondFactor, error) {
+	enabled, err := client.loginService.GetTotpStatus(ctx, userId)
+	if err != nil {
+		return NoSecondFactor, err
+	}
+	if enabled {
+		return TotpCode, nil
+	}
+
+	// a role in one of the groups is enough
+	for _, groupId := range client.totpGroupIds {
+		switch err = client.authService.AuthQuery(ctx, userId, groupId, adminimpl.ActionAccess); err {
+		case nil:
+			return TotpEnrolment, nil
+		case common.ErrNotAuthorized:
+		default:
+			return NoSecondFactor, err
+		}
+	}
+	return NoSecondFactor, nil
+}
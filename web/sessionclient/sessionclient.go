@@ -0,0 +1,185 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package sessionclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+
+	sessionimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/session"
+	sessionservice "github.com/dvaumoron/puzzleweb/session/service"
+	"github.com/gin-gonic/gin"
+)
+
+var errNoSessionCookie = errors.New("no session cookie in the response")
+
+type currentSession struct {
+	mutex sync.Mutex
+	id    uint64
+	// set when a login has changed the id, puzzleweb keeps saving the session with the old one
+	renewedId uint64
+}
+
+// SessionService knows the session of the current request (puzzleweb retrieves it at the start of each request,
+// and gives the same context to the following calls of the request).
+type SessionService interface {
+	sessionservice.SessionService
+	// the id of the session of the current request, zero when it is unknown
+	CurrentId(ctx context.Context) uint64
+	// give a new id to the session of the request (after a login) and replace it in the session cookie
+	// sent by puzzleweb, no effect when the session is unknown or already renewed
+	Renew(c *gin.Context) error
+	// the session of the current request is kept
+	RevokeOtherSessions(ctx context.Context, userId uint64) error
+	RevokeUserSessions(ctx context.Context, userId uint64) error
+}
+
+type sessionServiceWrapper struct {
+	sessionimpl.SessionService
+	// the sessions of the requests being served, by request context (removed at the end of the request)
+	requests *sync.Map
+}
+
+func MakeSessionServiceWrapper(sessionService sessionimpl.SessionService) SessionService {
+	return sessionServiceWrapper{SessionService: sessionService, requests: &sync.Map{}}
+}
+
+func (client sessionServiceWrapper) Get(ctx context.Context, id uint64) (map[string]string, error) {
+	// a context never cancelled is not a request one
+	if ctx.Done() != nil {
+		client.requests.Store(ctx, &currentSession{id: id})
+		context.AfterFunc(ctx, func() {
+			client.requests.Delete(ctx)
+		})
+	}
+	return client.SessionService.Get(ctx, id)
+}
+
+func (client sessionServiceWrapper) Update(ctx context.Context, id uint64, info map[string]string) error {
+	if current := client.current(ctx); current != nil {
+		current.mutex.Lock()
+		if current.id == id && current.renewedId != 0 {
+			id = current.renewedId
+		}
+		current.mutex.Unlock()
+	}
+	return client.SessionService.Update(ctx, id, info)
+}
+
+func (client sessionServiceWrapper) CurrentId(ctx context.Context) uint64 {
+	current := client.current(ctx)
+	if current == nil {
+		return 0
+	}
+
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+
+	if current.renewedId != 0 {
+		return current.renewedId
+	}
+	return current.id
+}
+
+func (client sessionServiceWrapper) Renew(c *gin.Context) error {
+	ctx := c.Request.Context()
+	current := client.current(ctx)
+	if current == nil {
+		return nil
+	}
+
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+
+	if current.renewedId != 0 {
+		return nil
+	}
+
+	// checked before the renewal, the session must not be lost
+	cookies := c.Writer.Header()["Set-Cookie"]
+	index := sessionCookieIndex(cookies, current.id)
+	if index == -1 {
+		return errNoSessionCookie
+	}
+
+	newId, err := client.SessionService.Renew(ctx, current.id)
+	if err != nil {
+		return err
+	}
+	current.renewedId = newId
+
+	cookies[index] = replaceCookieValue(cookies[index], encodeId(newId))
+	return nil
+}
+
+func (client sessionServiceWrapper) RevokeOtherSessions(ctx context.Context, userId uint64) error {
+	return client.SessionService.RevokeUserSessions(ctx, userId, client.CurrentId(ctx))
+}
+
+func (client sessionServiceWrapper) RevokeUserSessions(ctx context.Context, userId uint64) error {
+	return client.SessionService.RevokeUserSessions(ctx, userId, 0)
+}
+
+func (client sessionServiceWrapper) current(ctx context.Context) *currentSession {
+	current, _ := client.requests.Load(ctx)
+	typed, _ := current.(*currentSession)
+	return typed
+}
+
+// puzzleweb sets the session cookie at the start of each request, it is found by its value
+func sessionCookieIndex(cookies []string, id uint64) int {
+	value := encodeId(id)
+	for index, cookie := range cookies {
+		if cookieValue(cookie) == value {
+			return index
+		}
+	}
+	return -1
+}
+
+func cookieValue(cookie string) string {
+	_, value, _ := strings.Cut(cookie, "=")
+	value, _, _ = strings.Cut(value, ";")
+	value, err := url.QueryUnescape(value)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func replaceCookieValue(cookie string, value string) string {
+	name, rest, _ := strings.Cut(cookie, "=")
+	_, attributes, found := strings.Cut(rest, ";")
+	cookie = name + "=" + url.QueryEscape(value)
+	if found {
+		cookie += ";" + attributes
+	}
+	return cookie
+}
+
+// same format as the cookie of puzzleweb
+func encodeId(id uint64) string {
+	var buffer [8]byte
+	binary.LittleEndian.PutUint64(buffer[:], id)
+	return base64.StdEncoding.EncodeToString(buffer[:])
+}
@@ -0,0 +1,108 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package sessionclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sessionimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/session"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSessionService struct {
+	sessionimpl.SessionService
+	renewed map[uint64]uint64
+	updated []uint64
+}
+
+func (s *fakeSessionService) Get(ctx context.Context, id uint64) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (s *fakeSessionService) Renew(ctx context.Context, id uint64) (uint64, error) {
+	newId := id + 1
+	s.renewed[id] = newId
+	return newId, nil
+}
+
+func (s *fakeSessionService) Update(ctx context.Context, id uint64, info map[string]string) error {
+	s.updated = append(s.updated, id)
+	return nil
+}
+
+func TestRenew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		cookieId   uint64
+		wantErr    error
+		wantUpdate uint64
+	}{
+		{name: "renewed", cookieId: 41, wantUpdate: 42},
+		{name: "other cookie", cookieId: 7, wantErr: errNoSessionCookie, wantUpdate: 41},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inner := &fakeSessionService{renewed: map[uint64]uint64{}}
+			client := MakeSessionServiceWrapper(inner)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx, cancel := context.WithCancel(context.Background())
+			c.Request = httptest.NewRequest(http.MethodPost, "/twofactor/submit", nil).WithContext(ctx)
+
+			// like the session manager of puzzleweb
+			c.SetCookie("lang", "en", 60, "/", "", true, true)
+			c.SetCookie("session", encodeId(test.cookieId), 60, "/", "", true, true)
+			if _, err := client.Get(ctx, 41); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.Renew(c); err != test.wantErr {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			if err := client.Update(ctx, 41, map[string]string{}); err != nil {
+				t.Fatal(err)
+			}
+			if inner.updated[0] != test.wantUpdate {
+				t.Errorf("updated session %d, want %d", inner.updated[0], test.wantUpdate)
+			}
+
+			cookies := (&http.Response{Header: c.Writer.Header()}).Cookies()
+			if len(cookies) != 2 || cookies[0].Value != "en" {
+				t.Fatalf("unexpected cookies %v", cookies)
+			}
+			if got := cookies[1].Value; got != url.QueryEscape(encodeId(test.cookieId+uint64(len(inner.renewed)))) {
+				t.Errorf("got session cookie %q", got)
+			}
+
+			// the session is forgotten asynchronously at the end of the request
+			cancel()
+			for deadline := time.Now().Add(time.Second); client.CurrentId(ctx) != 0; time.Sleep(time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("the session is still known after the end of the request")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+/*
+ *
+ * Copyright 2023 puzzleweaver authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package twofactor
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	loginimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/login"
+	sessionimpl "github.com/dvaumoron/puzzleweaver/serviceimpl/session"
+	"github.com/dvaumoron/puzzleweaver/web/clientaddr"
+	"github.com/dvaumoron/puzzleweaver/web/loginclient"
+	"github.com/dvaumoron/puzzleweaver/web/sessionclient"
+	"github.com/dvaumoron/puzzleweb/common"
+	puzzleweb "github.com/dvaumoron/puzzleweb/core"
+	"github.com/dvaumoron/puzzleweb/locale"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	pageName     = "twofactor"
+	pageUrl      = "/" + pageName
+	templateName = "twofactor"
+
+	// session keys of a login waiting for its second factor
+	pendingIdName    = "TotpPendingId"
+	pendingLoginName = "TotpPendingLogin"
+	pendingStepName  = "TotpPendingStep"
+	pendingTimeName  = "TotpPendingTime"
+	// the second step must follow the first one
+	pendingTimeout = 5 * time.Minute
+
+	// fields of the forms
+	loginName           = "Login"
+	passwordName        = "Password"
+	confirmPasswordName = "ConfirmPassword"
+	registerName        = "Register"
+	emailName           = "Email"
+	codeName            = "Code"
+	enrolName           = "Enrol"
+
+	stepName          = "TotpStep"
+	provisioningName  = "ProvisioningUri"
+	recoveryCodesName = "RecoveryCodes"
+
+	passwordStep  = "password"
+	codeStep      = "code"
+	enrolmentStep = "enrolment"
+	recoveryStep  = "recovery"
+	enabledStep   = "enabled"
+	disabledStep  = "disabled"
+)
+
+type twoFactorWidget struct {
+	displayHandler gin.HandlerFunc
+	submitHandler  gin.HandlerFunc
+	verifyHandler  gin.HandlerFunc
+	confirmHandler gin.HandlerFunc
+	disableHandler gin.HandlerFunc
+}
+
+func (w twoFactorWidget) LoadInto(router gin.IRouter) {
+	router.GET("/", w.displayHandler)
+	router.POST("/submit", w.submitHandler)
+	router.POST("/verify", w.verifyHandler)
+	router.POST("/confirm", w.confirmHandler)
+	router.POST("/disable", w.disableHandler)
+}
+
+// MakePage returns the hidden page of the login, with its optional second factor (the "twofactor" template
+// receives the current step in TotpStep), it replaces the login page of puzzleweb and is also used by the connected users to enable or disable their TOTP
+// and accepts the registrations with an email.
+func MakePage(loginService loginclient.EmailLoginService, totpService loginimpl.RemoteLoginService, sessionService sessionclient.SessionService, clientAddr clientaddr.Resolver, settingsManager *puzzleweb.SettingsManager) puzzleweb.Page {
+	p := puzzleweb.MakeHiddenPage(pageName)
+	p.Widget = twoFactorWidget{
+		displayHandler: puzzleweb.CreateTemplate(func(data gin.H, c *gin.Context) (string, string) {
+			ctx := c.Request.Context()
+			data[common.RedirectName] = c.Query(common.RedirectName)
+
+			userId, _, step := loadPending(puzzleweb.GetSession(c))
+			if step == "" {
+				userId, _ = data[common.UserIdName].(uint64)
+				if userId == 0 {
+					data[stepName] = passwordStep
+					return templateName, ""
+				}
+
+				enabled, err := totpService.GetTotpStatus(ctx, userId)
+				if err != nil {
+					return "", common.DefaultErrorRedirect(puzzleweb.GetLogger(c), err.Error())
+				}
+
+				switch {
+				case enabled:
+					step = enabledStep
+				case c.Query(enrolName) == "true":
+					step = enrolmentStep
+				default:
+					step = disabledStep
+				}
+			}
+
+			data[stepName] = step
+			if step == enrolmentStep {
+				uri, err := totpService.StartTotp(ctx, userId)
+				if err != nil {
+					return "", common.DefaultErrorRedirect(puzzleweb.GetLogger(c), err.Error())
+				}
+				data[provisioningName] = uri
+			}
+			return templateName, ""
+		}),
+		submitHandler: common.CreateRedirect(func(c *gin.Context) string {
+			ctx := c.Request.Context()
+			login := c.PostForm(loginName)
+			password := c.PostForm(passwordName)
+			redirect := c.PostForm(common.RedirectName)
+			if login == "" {
+				return errorUrl(common.ErrorEmptyLoginKey, redirect, false)
+			}
+			if password == "" {
+				return errorUrl(common.ErrorEmptyPasswordKey, redirect, false)
+			}
+
+			// a new user has no second factor
+			if c.PostForm(registerName) == "true" {
+				if c.PostForm(confirmPasswordName) != password {
+					return errorUrl(common.ErrorWrongConfirmPasswordKey, redirect, false)
+				}
+
+				userId, err := loginService.RegisterWithEmail(ctx, login, c.PostForm(emailName), password)
+				if err == nil {
+					err = logIn(c, sessionService, settingsManager, userId, login)
+				}
+				if err != nil {
+					return errorUrl(err.Error(), redirect, false)
+				}
+				return redirect
+			}
+
+			// login is replaced when an email is given
+			userId, login, factor, err := loginService.VerifyPassword(ctx, login, password, clientAddr.FromRequest(c.Request))
+			if err != nil {
+				return errorUrl(err.Error(), redirect, false)
+			}
+
+			session := puzzleweb.GetSession(c)
+			switch factor {
+			case loginclient.TotpCode:
+				storePending(session, userId, login, codeStep)
+			case loginclient.TotpEnrolment:
+				storePending(session, userId, login, enrolmentStep)
+			default:
+				if err = logIn(c, sessionService, settingsManager, userId, login); err != nil {
+					return errorUrl(err.Error(), redirect, false)
+				}
+				return redirect
+			}
+			return pageUrl + "?" + common.RedirectName + "=" + url.QueryEscape(redirect)
+		}),
+		verifyHandler: common.CreateRedirect(func(c *gin.Context) string {
+			redirect := c.PostForm(common.RedirectName)
+			session := puzzleweb.GetSession(c)
+			userId, login, step := loadPending(session)
+			if step != codeStep {
+				return errorUrl(common.ErrorNotAuthorizedKey, redirect, false)
+			}
+
+			if err := totpService.VerifyTotp(c.Request.Context(), userId, c.PostForm(codeName)); err != nil {
+				return errorUrl(err.Error(), redirect, false)
+			}
+
+			clearPending(session)
+			if err := logIn(c, sessionService, settingsManager, userId, login); err != nil {
+				return errorUrl(err.Error(), redirect, false)
+			}
+			return redirect
+		}),
+		confirmHandler: puzzleweb.CreateTemplate(func(data gin.H, c *gin.Context) (string, string) {
+			redirect := c.PostForm(common.RedirectName)
+			session := puzzleweb.GetSession(c)
+			userId, login, step := loadPending(session)
+			pending := step == enrolmentStep
+			if !pending {
+				if step != "" {
+					return "", errorUrl(common.ErrorNotAuthorizedKey, redirect, false)
+				}
+
+				userId, _ = data[common.UserIdName].(uint64)
+				if userId == 0 {
+					return "", errorUrl(common.ErrorNotAuthorizedKey, redirect, false)
+				}
+			}
+
+			codes, err := totpService.ConfirmTotp(c.Request.Context(), userId, c.PostForm(codeName))
+			if err != nil {
+				return "", errorUrl(err.Error(), redirect, true)
+			}
+
+			// the recovery codes are only displayed here
+			if pending {
+				clearPending(session)
+				if err = logIn(c, sessionService, settingsManager, userId, login); err != nil {
+					return "", errorUrl(err.Error(), redirect, false)
+				}
+			}
+			data[common.RedirectName] = redirect
+			data[stepName] = recoveryStep
+			data[recoveryCodesName] = codes
+			return templateName, ""
+		}),
+		disableHandler: common.CreateRedirect(func(c *gin.Context) string {
+			redirect := c.PostForm(common.RedirectName)
+			userId := puzzleweb.GetSessionUserId(c)
+			if userId == 0 {
+				return errorUrl(common.ErrorNotAuthorizedKey, redirect, false)
+			}
+
+			if err := totpService.DisableTotp(c.Request.Context(), userId, c.PostForm(codeName)); err != nil {
+				return errorUrl(err.Error(), redirect, false)
+			}
+			return pageUrl + "?" + common.RedirectName + "=" + url.QueryEscape(redirect)
+		}),
+	}
+	return p
+}
+
+func errorUrl(errorMsg string, redirect string, enrol bool) string {
+	query := url.Values{}
+	query.Set(common.ErrorKey, errorMsg)
+	query.Set(common.RedirectName, redirect)
+	if enrol {
+		query.Set(enrolName, "true")
+	}
+	return pageUrl + "?" + query.Encode()
+}
+
+func storePending(session *puzzleweb.Session, userId uint64, login string, step string) {
+	session.Store(pendingIdName, strconv.FormatUint(userId, 10))
+	session.Store(pendingLoginName, login)
+	session.Store(pendingStepName, step)
+	session.Store(pendingTimeName, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// the step is empty when there is no valid pending login
+func loadPending(session *puzzleweb.Session) (uint64, string, string) {
+	step := session.Load(pendingStepName)
+	if step == "" {
+		return 0, "", ""
+	}
+
+	userId, err := strconv.ParseUint(session.Load(pendingIdName), 10, 64)
+	if err != nil {
+		clearPending(session)
+		return 0, "", ""
+	}
+	pendingTime, err := strconv.ParseInt(session.Load(pendingTimeName), 10, 64)
+	if err != nil || time.Since(time.Unix(pendingTime, 0)) > pendingTimeout {
+		clearPending(session)
+		return 0, "", ""
+	}
+	return userId, session.Load(pendingLoginName), step
+}
+
+func clearPending(session *puzzleweb.Session) {
+	session.Delete(pendingIdName)
+	session.Delete(pendingLoginName)
+	session.Delete(pendingStepName)
+	session.Delete(pendingTimeName)
+}
+
+// the session gets a new id (in the cookie of the response) before the connection of the user
+func logIn(c *gin.Context, sessionService sessionclient.SessionService, settingsManager *puzzleweb.SettingsManager, userId uint64, login string) error {
+	if err := sessionService.Renew(c); err != nil {
+		return err
+	}
+
+	session := puzzleweb.GetSession(c)
+	session.Store(sessionimpl.LoginName, login)
+	session.Store(sessionimpl.UserIdName, strconv.FormatUint(userId, 10))
+
+	puzzleweb.GetLocalesManager(c).SetLangCookie(settingsManager.Get(c.Request.Context(), userId, c)[locale.LangName], c)
+	return nil
+}